@@ -0,0 +1,356 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 contains API Schema definitions for the infrastructure v1alpha1 API group
+// +kubebuilder:object:generate=true
+// +groupName=infrastructure.cluster.x-k8s.io
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Apart from the differences handled below, both versions have the same json representation.
+// So the spec and status are converted through json, and the differences are fixed up afterwards.
+func convertJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (src *ScvmmCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmCluster)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmCluster{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	if ok {
+		restoreFailureDomains(dst.Spec.FailureDomains, restored.Spec.FailureDomains)
+	}
+	return nil
+}
+
+func (dst *ScvmmCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmCluster)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+func (src *ScvmmClusterTemplate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmClusterTemplate)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmClusterTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if ok {
+		restoreFailureDomains(dst.Spec.Template.Spec.FailureDomains, restored.Spec.Template.Spec.FailureDomains)
+	}
+	return nil
+}
+
+func (dst *ScvmmClusterTemplate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmClusterTemplate)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+func (src *ScvmmMachine) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmMachine)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmMachine{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	if ok {
+		dst.Spec.DNS = restored.Spec.DNS
+		dst.Status.IPAddresses = restored.Status.IPAddresses
+		dst.Status.DNS = restored.Status.DNS
+		restoreNetworking(dst.Spec.Networking, restored.Spec.Networking)
+	}
+	return nil
+}
+
+func (dst *ScvmmMachine) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmMachine)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+func (src *ScvmmMachineMigration) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmMachineMigration)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (dst *ScvmmMachineMigration) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmMachineMigration)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (src *ScvmmMachinePool) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmMachinePool)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmMachinePool{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	if ok {
+		dst.Spec.Template.Spec.DNS = restored.Spec.Template.Spec.DNS
+		restoreNetworking(dst.Spec.Template.Spec.Networking, restored.Spec.Template.Spec.Networking)
+	}
+	return nil
+}
+
+func (dst *ScvmmMachinePool) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmMachinePool)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+func (src *ScvmmMachineSnapshot) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmMachineSnapshot)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (dst *ScvmmMachineSnapshot) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmMachineSnapshot)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (src *ScvmmMachineTemplate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmMachineTemplate)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmMachineTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if ok {
+		dst.Spec.Template.Spec.DNS = restored.Spec.Template.Spec.DNS
+		restoreNetworking(dst.Spec.Template.Spec.Networking, restored.Spec.Template.Spec.Networking)
+	}
+	return nil
+}
+
+func (dst *ScvmmMachineTemplate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmMachineTemplate)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+func (src *ScvmmNamePool) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmNamePool)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec.VMNameRanges = nil
+	for _, nameRange := range src.Spec.VMNameRanges {
+		dst.Spec.VMNameRanges = append(dst.Spec.VMNameRanges, infrav1.VmNameRange{From: nameRange.Start, To: nameRange.End})
+	}
+	return convertJSON(src.Status, &dst.Status)
+}
+
+func (dst *ScvmmNamePool) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmNamePool)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec.VMNameRanges = nil
+	for _, nameRange := range src.Spec.VMNameRanges {
+		dst.Spec.VMNameRanges = append(dst.Spec.VMNameRanges, VmNameRange{Start: nameRange.From, End: nameRange.To})
+	}
+	return convertJSON(src.Status, &dst.Status)
+}
+
+func (src *ScvmmProvider) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ScvmmProvider)
+	// Restore the fields that only exist in v1beta1, and drop the annotation they were kept in
+	restored := &infrav1.ScvmmProvider{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	if ok {
+		dst.Spec.DNS = restored.Spec.DNS
+	}
+	// Credentials are filled in at runtime and never serialized
+	dst.Spec.ScvmmUsername = src.Spec.ScvmmUsername
+	dst.Spec.ScvmmPassword = src.Spec.ScvmmPassword
+	dst.Spec.ADUsername = src.Spec.ADUsername
+	dst.Spec.ADPassword = src.Spec.ADPassword
+	dst.Spec.SensitiveEnv = src.Spec.SensitiveEnv
+	return nil
+}
+
+func (dst *ScvmmProvider) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ScvmmProvider)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertJSON(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(src.Status, &dst.Status); err != nil {
+		return err
+	}
+	dst.Spec.ScvmmUsername = src.Spec.ScvmmUsername
+	dst.Spec.ScvmmPassword = src.Spec.ScvmmPassword
+	dst.Spec.ADUsername = src.Spec.ADUsername
+	dst.Spec.ADPassword = src.Spec.ADPassword
+	dst.Spec.SensitiveEnv = src.Spec.SensitiveEnv
+	// Keep the fields that v1alpha1 does not have in an annotation
+	return utilconversion.MarshalData(src, dst)
+}
+
+// Restore the networking fields of the failure domains that only exist in v1beta1
+func restoreFailureDomains(dst, restored map[string]infrav1.ScvmmFailureDomainSpec) {
+	for name, fd := range dst {
+		if restoredFD, ok := restored[name]; ok {
+			restoreNetworking(fd.Networking, restoredFD.Networking)
+		}
+	}
+}
+
+// Restore the networking fields that only exist in v1beta1
+func restoreNetworking(dst, restored *infrav1.Networking) {
+	if dst == nil || restored == nil {
+		return
+	}
+	for i := range dst.Devices {
+		if i < len(restored.Devices) {
+			dst.Devices[i].DefaultRoutePool = restored.Devices[i].DefaultRoutePool
+			restoreRoutes(dst.Devices[i].Routes, restored.Devices[i].Routes)
+		}
+	}
+	for i := range dst.Bonds {
+		if i < len(restored.Bonds) {
+			restoreRoutes(dst.Bonds[i].Routes, restored.Bonds[i].Routes)
+		}
+	}
+	for i := range dst.VLANs {
+		if i < len(restored.VLANs) {
+			restoreRoutes(dst.VLANs[i].Routes, restored.VLANs[i].Routes)
+		}
+	}
+}
+
+func restoreRoutes(dst, restored []infrav1.NetworkRoute) {
+	for i := range dst {
+		if i < len(restored) {
+			dst[i].From = restored[i].From
+			dst[i].Table = restored[i].Table
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestFuzzyConversion(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	t.Run("for ScvmmCluster", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmCluster{},
+		Spoke:  &ScvmmCluster{},
+	}))
+	t.Run("for ScvmmClusterTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmClusterTemplate{},
+		Spoke:  &ScvmmClusterTemplate{},
+	}))
+	t.Run("for ScvmmMachine", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmMachine{},
+		Spoke:  &ScvmmMachine{},
+	}))
+	t.Run("for ScvmmMachineMigration", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmMachineMigration{},
+		Spoke:  &ScvmmMachineMigration{},
+	}))
+	t.Run("for ScvmmMachinePool", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmMachinePool{},
+		Spoke:  &ScvmmMachinePool{},
+	}))
+	t.Run("for ScvmmMachineSnapshot", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmMachineSnapshot{},
+		Spoke:  &ScvmmMachineSnapshot{},
+	}))
+	t.Run("for ScvmmMachineTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmMachineTemplate{},
+		Spoke:  &ScvmmMachineTemplate{},
+	}))
+	t.Run("for ScvmmNamePool", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmNamePool{},
+		Spoke:  &ScvmmNamePool{},
+	}))
+	t.Run("for ScvmmProvider", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &infrav1.ScvmmProvider{},
+		Spoke:  &ScvmmProvider{},
+	}))
+}
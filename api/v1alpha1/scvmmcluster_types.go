@@ -45,6 +45,11 @@ type ScvmmFailureDomainSpec struct {
 	// Host Group for this failure domain
 	HostGroup string `json:"hostGroup"`
 
+	// ProviderRef points to the ScvmmProvider for this failure domain.
+	// Defaults to the providerRef of the cluster, set this to stretch a cluster over multiple SCVMM servers.
+	// +optional
+	ProviderRef *ScvmmProviderReference `json:"providerRef,omitempty"`
+
 	// Networking settings for this failure domain
 	// +optional
 	Networking *Networking `json:"networking,omitempty"`
@@ -63,6 +68,24 @@ type ScvmmClusterStatus struct {
 	// FailureDomains is a slice of failure domain objects copied from the spec
 	// +optional
 	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// FailureDomainStatus is the health of the provider of each failure domain
+	// +optional
+	FailureDomainStatus map[string]ScvmmFailureDomainStatus `json:"failureDomainStatus,omitempty"`
+}
+
+type ScvmmFailureDomainStatus struct {
+	// Provider used for this failure domain
+	// +optional
+	ProviderRef *ScvmmProviderReference `json:"providerRef,omitempty"`
+	// The provider can reach the cloud and host group of the failure domain
+	Ready bool `json:"ready"`
+	// Result of the last check
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time of the check that gave this result
+	// +optional
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
 }
 
 //+kubebuilder:object:root=true
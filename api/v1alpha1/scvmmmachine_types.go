@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
 // ScvmmMachineSpec defines the desired state of ScvmmMachine
@@ -67,6 +68,12 @@ type ScvmmMachineSpec struct {
 	// OperatingSystem
 	// +optional
 	OperatingSystem string `json:"operatingSystem,omitempty"`
+	// Guest operating system family
+	// Windows guests get a config drive for cloudbase-init instead of NoCloud data,
+	// and join the activeDirectory domain offline when that is set
+	// +kubebuilder:validation:Enum=Linux;Windows
+	// +optional
+	OSType string `json:"osType,omitempty"`
 	// Network settings
 	// +optional
 	Networking *Networking `json:"networking,omitempty"`
@@ -76,6 +83,15 @@ type ScvmmMachineSpec struct {
 	// AvailabilitySet
 	// +optional
 	AvailabilitySet string `json:"availabilitySet,omitempty"`
+	// Manage the availability set automatically, overriding availabilitySet.
+	// Control plane machines get one per cluster, workers one per MachineDeployment.
+	// The availability set is created when needed, and removed with its last VM.
+	// +optional
+	AutoAvailabilitySet bool `json:"autoAvailabilitySet,omitempty"`
+	// Placement picks the host to create the VM on from the SCVMM host ratings
+	// Without it, SCVMM places the VM in the host group itself
+	// +optional
+	Placement *Placement `json:"placement,omitempty"`
 	// Options for New-SCVirtualMachine
 	// +optional
 	VMOptions *VmOptions `json:"vmOptions,omitempty"`
@@ -90,6 +106,9 @@ type ScvmmMachineSpec struct {
 	// Will be copied from scvmmcluster if not using local bootstrap
 	// +optional
 	ProviderRef *ScvmmProviderReference `json:"providerRef,omitEmpty"`
+	// Cloud-init settings, overriding the ones from the provider
+	// +optional
+	CloudInit *ScvmmMachineCloudInit `json:"cloudInit,omitempty"`
 	// Custom bootstrap secret ref
 	// This triggers the controller to create the machine without a (cluster-api) cluster
 	// For testing purposes, or just for creating VMs
@@ -97,6 +116,14 @@ type ScvmmMachineSpec struct {
 	Bootstrap *clusterv1.Bootstrap `json:"bootstrap,omitempty"`
 }
 
+type ScvmmMachineCloudInit struct {
+	// Detach the cloud-init device and delete it from the library share
+	// once the VM is running and reports its addresses.
+	// Defaults to the setting of the provider
+	// +optional
+	DetachAfterBoot *bool `json:"detachAfterBoot,omitempty"`
+}
+
 type VmOptions struct {
 	// Description
 	// +optional
@@ -124,6 +151,18 @@ type VmOptions struct {
 	CheckpointType string `json:"checkpointType,omitempty"`
 }
 
+type Placement struct {
+	// Hosts to prefer over the other hosts, if they have capacity
+	// +optional
+	PreferredHosts []string `json:"preferredHosts,omitempty"`
+	// Hosts never to place the VM on
+	// +optional
+	ExcludedHosts []string `json:"excludedHosts,omitempty"`
+	// Memory that has to be left free on the host after placing the VM
+	// +optional
+	MinimumFreeMemory *resource.Quantity `json:"minimumFreeMemory,omitempty"`
+}
+
 type VmDisk struct {
 	// Size of the virtual disk
 	// +optional
@@ -142,23 +181,109 @@ type NetworkDevice struct {
 	DeviceName string `json:"deviceName,omitempty"`
 	// Virtual Network identifier
 	VMNetwork string `json:"vmNetwork"`
-	// IP Address
+	// Static MAC address of the network adapter
+	// Without it, the MAC address that SCVMM assigns is used, if it is known before the first boot.
+	// The guest network configuration matches on the MAC address when it is known, and on the device name otherwise
+	// +kubebuilder:validation:Pattern=`^([0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}$`
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// Let SCVMM assign a static MAC address from the MAC address pool of the host group
+	// Ignored when macAddress is set
+	// +optional
+	MACAddressFromPool bool `json:"macAddressFromPool,omitempty"`
+	// VM subnet of the VM network to connect to
+	// Defaults to the first subnet of the VM network
+	// +optional
+	VMSubnet string `json:"vmSubnet,omitempty"`
+	// VLAN ID of the virtual network adapter
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLanID *int `json:"vlanID,omitempty"`
+	// SCVMM port classification of the virtual network adapter
+	// +optional
+	PortClassification string `json:"portClassification,omitempty"`
+	// SCVMM static IP address pool to assign an IPv4 address from
+	// The assigned address, the gateway and the dns servers of the pool are used
+	// for the guest network configuration when ipAddresses is not set
+	// +optional
+	StaticIPAddressPool string `json:"staticIPAddressPool,omitempty"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
+	// List of IPAddressPools that should be assigned
+	// to IPAddressClaims. The machine's cloud-init metadata will be populated
+	// with IPAddresses fulfilled by an IPAM provider.
+	// +optional
+	AddressesFromPools []corev1.TypedLocalObjectReference `json:"addressesFromPools,omitempty"`
+}
+
+type NetworkAddressing struct {
+	// IP Addresses (IPv4 and/or IPv6) in CIDR notation
 	// +optional
 	IPAddresses []string `json:"ipAddresses,omitempty"`
-	// Gateway
+	// IPv4 Gateway
 	// +optional
 	Gateway string `json:"gateway,omitempty"`
+	// IPv6 Gateway
+	// +optional
+	Gateway6 string `json:"gateway6,omitempty"`
 	// Nameservers
 	// +optional
 	Nameservers []string `json:"nameservers,omitempty"`
 	// List of search domains used when resolving with DNS
 	// +optional
 	SearchDomains []string `json:"searchDomains,omitempty"`
-	// List of IPAddressPools that should be assigned
-	// to IPAddressClaims. The machine's cloud-init metadata will be populated
-	// with IPAddresses fulfilled by an IPAM provider.
+	// Static routes
 	// +optional
-	AddressesFromPools []corev1.TypedLocalObjectReference `json:"addressesFromPools,omitempty"`
+	Routes []NetworkRoute `json:"routes,omitempty"`
+	// MTU
+	// +kubebuilder:validation:Minimum=576
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+}
+
+type NetworkRoute struct {
+	// Destination network in CIDR notation
+	To string `json:"to"`
+	// Gateway
+	Via string `json:"via"`
+	// Route metric
+	// +optional
+	Metric *int `json:"metric,omitempty"`
+}
+
+// VLAN subinterface
+type NetworkVLAN struct {
+	// Interface name
+	Name string `json:"name"`
+	// VLAN ID
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	ID int `json:"id"`
+	// Parent interface, a device or bond name
+	Link string `json:"link"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
+}
+
+// Bond over multiple network devices
+type NetworkBond struct {
+	// Interface name
+	Name string `json:"name"`
+	// Names of the devices in the bond
+	// +kubebuilder:validation:MinItems=1
+	Interfaces []string `json:"interfaces"`
+	// Bonding mode
+	// Modes other than active-backup need MAC address spoofing on the virtual network adapters
+	// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;"802.3ad";balance-tlb;balance-alb
+	// +kubebuilder:default:=active-backup
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// Link monitoring interval in milliseconds
+	// +optional
+	MIIMonitorInterval *int `json:"miiMonitorInterval,omitempty"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
 }
 
 type Networking struct {
@@ -167,6 +292,18 @@ type Networking struct {
 	// +listType=map
 	// +listMapKey=deviceName
 	Devices []NetworkDevice `json:"devices,omitempty"`
+	// Bonds over network devices
+	// Not supported on windows guests
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Bonds []NetworkBond `json:"bonds,omitempty"`
+	// VLAN subinterfaces of network devices or bonds
+	// Not supported on windows guests
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	VLANs []NetworkVLAN `json:"vlans,omitempty"`
 	// Host domain
 	// +optional
 	Domain string `json:"domain,omitempty"`
@@ -181,6 +318,11 @@ type FibreChannel struct {
 	VirtualSAN string `json:"virtualSAN,omitEmpty"`
 }
 
+const (
+	OSTypeLinux   = "Linux"
+	OSTypeWindows = "Windows"
+)
+
 type ActiveDirectory struct {
 	// Domain Controller
 	// +optional
@@ -205,6 +347,42 @@ type DynamicMemory struct {
 	BufferPercentage *int `json:"bufferPercentage,omitempty"`
 }
 
+type NetworkAdapterStatus struct {
+	// Network device name from the spec, eth<slot> if it has none
+	// +optional
+	DeviceName string `json:"deviceName,omitempty"`
+	// MAC address
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// Static or Dynamic
+	// +optional
+	MACAddressType string `json:"macAddressType,omitempty"`
+	// VM network
+	// +optional
+	VMNetwork string `json:"vmNetwork,omitempty"`
+	// VM subnet
+	// +optional
+	VMSubnet string `json:"vmSubnet,omitempty"`
+	// VLAN ID, if VLAN is enabled
+	// +optional
+	VLanID int `json:"vlanID,omitempty"`
+	// Port classification
+	// +optional
+	PortClassification string `json:"portClassification,omitempty"`
+	// IPv4 addresses assigned from SCVMM static IP address pools
+	// +optional
+	StaticIPAddresses []string `json:"staticIPAddresses,omitempty"`
+	// Default gateway of the SCVMM static IP address pool
+	// +optional
+	StaticGateway string `json:"staticGateway,omitempty"`
+	// DNS servers of the SCVMM static IP address pool
+	// +optional
+	StaticNameservers []string `json:"staticNameservers,omitempty"`
+	// DNS search suffixes of the SCVMM static IP address pool
+	// +optional
+	StaticSearchDomains []string `json:"staticSearchDomains,omitempty"`
+}
+
 // ScvmmMachineStatus defines the observed state of ScvmmMachine
 type ScvmmMachineStatus struct {
 	// Mandatory field, is machine ready
@@ -225,18 +403,60 @@ type ScvmmMachineStatus struct {
 	// Host name of the VM
 	// +optional
 	Hostname string `json:"hostname,omitempty"`
+	// Hyper-V host the VM is running on
+	// When using placement, this is filled in with the selected host before creating the VM
+	// +optional
+	VMHost string `json:"vmHost,omitempty"`
+	// SCVMM cloud the VM is in
+	// +optional
+	Cloud string `json:"cloud,omitempty"`
+	// Path of the host group of the Hyper-V host the VM is running on
+	// +optional
+	HostGroup string `json:"hostGroup,omitempty"`
 	// Addresses contains the associated addresses for the virtual machine
 	// +optional
 	Addresses []clusterv1.MachineAddress `json:"addresses,omitempty"`
+	// The virtual network adapters as configured in SCVMM
+	// +optional
+	NetworkAdapters []NetworkAdapterStatus `json:"networkAdapters,omitempty"`
+	// SCVMM job the controller is waiting on
+	// +optional
+	Job *VmJob `json:"job,omitempty"`
+	// FailureReason is set when SCVMM returned an error that will not go away by retrying,
+	// like a missing template or an exceeded quota.  The controller stops reconciling the
+	// machine, which has to be deleted and recreated.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+	// FailureMessage is the error message that goes with FailureReason
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
 	// Conditions defines current service state of the ScvmmMachine.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
+type VmJob struct {
+	// SCVMM job ID
+	Id string `json:"id"`
+	// Name of the job as given by SCVMM
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Status of the job as given by SCVMM
+	// +optional
+	Status string `json:"status,omitempty"`
+	// Progress of the job as given by SCVMM
+	// +optional
+	Progress string `json:"progress,omitempty"`
+	// Condition of the machine that the job is reported on while it runs, or when it fails
+	// +optional
+	Condition clusterv1.ConditionType `json:"condition,omitempty"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 // +kubebuilder:printcolumn:JSONPath=".status.vmStatus",type="string",name="STATUS",description="Virtual Machine Status"
 // +kubebuilder:printcolumn:JSONPath=".status.hostname",type="string",name="HOST",description="Virtual Machine Hostname",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.vmHost",type="string",name="VMHOST",description="Hyper-V host of the Virtual Machine",priority=1
 // +kubebuilder:printcolumn:JSONPath=".status.addresses[].address",type="string",name="IP",description="Virtual Machine IP Address"
 // +kubebuilder:printcolumn:JSONPath=".spec.providerID",type="string",name="ID",description="Virtual Machine ProviderID",priority=1
 // +kubebuilder:printcolumn:JSONPath=".status.creationTime",type="date",name="AGE",description="Virtual Machine Creation Timestamp"
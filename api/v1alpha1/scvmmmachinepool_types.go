@@ -0,0 +1,110 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ScvmmMachinePoolSpec defines the desired state of ScvmmMachinePool
+type ScvmmMachinePoolSpec struct {
+	// ProviderIDList are the provider IDs of the VMs in the pool, will be filled in by controller
+	// +optional
+	ProviderIDList []string `json:"providerIDList,omitempty"`
+	// Template for the ScvmmMachines of the pool
+	// The vmNameFromPool and addressesFromPools settings work the same as on a ScvmmMachine
+	// Cloud, hostGroup and providerRef are taken from the failure domain when not set
+	// The machines have no Machine, they are labelled with the cluster name and failure domain instead,
+	// which their node gets as topology labels. With autoAvailabilitySet, the pool has one availability set.
+	Template ScvmmMachineTemplateResource `json:"template"`
+	// Strategy for replacing the machines when the template changes
+	// +optional
+	Strategy *ScvmmMachinePoolStrategy `json:"strategy,omitempty"`
+}
+
+type ScvmmMachinePoolStrategy struct {
+	// Number of machines that can be created above the desired replicas
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSurge int32 `json:"maxSurge"`
+	// Number of machines that can be unavailable during a replacement
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailable int32 `json:"maxUnavailable"`
+}
+
+// ScvmmMachinePoolStatus defines the observed state of ScvmmMachinePool
+type ScvmmMachinePoolStatus struct {
+	// Are enough machines in the pool ready
+	// +optional
+	Ready bool `json:"ready"`
+	// Number of machines in the pool
+	// +optional
+	Replicas int32 `json:"replicas"`
+	// Number of ready machines in the pool
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Number of machines created from the current template
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// Hash of the template the machines are created from
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+	// Conditions defines current service state of the ScvmmMachinePool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.replicas",type="integer",name="REPLICAS",description="Machines in the pool"
+// +kubebuilder:printcolumn:JSONPath=".status.readyReplicas",type="integer",name="READY",description="Ready machines in the pool"
+// +kubebuilder:printcolumn:JSONPath=".status.updatedReplicas",type="integer",name="UPDATED",description="Machines created from the current template"
+// +kubebuilder:printcolumn:JSONPath=".spec.template.spec.vmTemplate",type="string",name="VMTEMPLATE",description="VM template of the machines",priority=1
+
+// ScvmmMachinePool is the Schema for the scvmmmachinepools API
+type ScvmmMachinePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmMachinePoolSpec   `json:"spec,omitempty"`
+	Status ScvmmMachinePoolStatus `json:"status,omitempty"`
+}
+
+func (c *ScvmmMachinePool) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *ScvmmMachinePool) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmMachinePoolList contains a list of ScvmmMachinePool
+type ScvmmMachinePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmMachinePool `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ScvmmMachinePool{}, &ScvmmMachinePoolList{})
+}
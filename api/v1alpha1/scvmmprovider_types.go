@@ -83,6 +83,42 @@ type ScvmmCloudInitSpec struct {
 	// +optional
 	// +kubebuilder:validation:Enum=dvd;floppy;scsi;ide
 	DeviceType string `json:"deviceType,omitempty"`
+	// Layout of the cloud-init data
+	// NoCloud puts meta-data, user-data and network-config in the root of a volume labeled cidata,
+	// ConfigDrive puts meta_data.json, user_data and network_data.json in openstack/latest on a volume labeled config-2
+	// Defaults to NoCloud
+	// Ignition and Windows machines always get a ConfigDrive
+	// +optional
+	// +kubebuilder:validation:Enum=NoCloud;ConfigDrive
+	Datasource string `json:"datasource,omitempty"`
+	// Detach the cloud-init device and delete it from the library share
+	// once the VM is running and reports its addresses.
+	// The cloud-init data contains secrets like the cluster join token
+	// +optional
+	DetachAfterBoot bool `json:"detachAfterBoot,omitempty"`
+	// Periodically clean up cloud-init files on the library share
+	// that don't belong to any ScvmmMachine
+	// +optional
+	Janitor *CloudInitJanitor `json:"janitor,omitempty"`
+}
+
+const (
+	DatasourceNoCloud     = "NoCloud"
+	DatasourceConfigDrive = "ConfigDrive"
+)
+
+type CloudInitJanitor struct {
+	// How often to check the library share
+	// Defaults to 1 hour
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Only remove files that are at least this old
+	// Defaults to 24 hours
+	// +optional
+	MinAge *metav1.Duration `json:"minAge,omitempty"`
+	// Only report orphaned files (in the log and metrics) instead of removing them
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // ScvmmProviderStatus defines the observed state of ScvmmProvider
@@ -22,8 +22,10 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/errors"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -46,6 +48,31 @@ func (in *ActiveDirectory) DeepCopy() *ActiveDirectory {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudInitJanitor) DeepCopyInto(out *CloudInitJanitor) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudInitJanitor.
+func (in *CloudInitJanitor) DeepCopy() *CloudInitJanitor {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudInitJanitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DynamicMemory) DeepCopyInto(out *DynamicMemory) {
 	*out = *in
@@ -92,7 +119,52 @@ func (in *FibreChannel) DeepCopy() *FibreChannel {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkDevice) DeepCopyInto(out *NetworkDevice) {
+func (in *MigrationStorage) DeepCopyInto(out *MigrationStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStorage.
+func (in *MigrationStorage) DeepCopy() *MigrationStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAdapterStatus) DeepCopyInto(out *NetworkAdapterStatus) {
+	*out = *in
+	if in.StaticIPAddresses != nil {
+		in, out := &in.StaticIPAddresses, &out.StaticIPAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaticNameservers != nil {
+		in, out := &in.StaticNameservers, &out.StaticNameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaticSearchDomains != nil {
+		in, out := &in.StaticSearchDomains, &out.StaticSearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAdapterStatus.
+func (in *NetworkAdapterStatus) DeepCopy() *NetworkAdapterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAdapterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAddressing) DeepCopyInto(out *NetworkAddressing) {
 	*out = *in
 	if in.IPAddresses != nil {
 		in, out := &in.IPAddresses, &out.IPAddresses
@@ -109,6 +181,60 @@ func (in *NetworkDevice) DeepCopyInto(out *NetworkDevice) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]NetworkRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAddressing.
+func (in *NetworkAddressing) DeepCopy() *NetworkAddressing {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAddressing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkBond) DeepCopyInto(out *NetworkBond) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MIIMonitorInterval != nil {
+		in, out := &in.MIIMonitorInterval, &out.MIIMonitorInterval
+		*out = new(int)
+		**out = **in
+	}
+	in.NetworkAddressing.DeepCopyInto(&out.NetworkAddressing)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkBond.
+func (in *NetworkBond) DeepCopy() *NetworkBond {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkBond)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkDevice) DeepCopyInto(out *NetworkDevice) {
+	*out = *in
+	if in.VLanID != nil {
+		in, out := &in.VLanID, &out.VLanID
+		*out = new(int)
+		**out = **in
+	}
+	in.NetworkAddressing.DeepCopyInto(&out.NetworkAddressing)
 	if in.AddressesFromPools != nil {
 		in, out := &in.AddressesFromPools, &out.AddressesFromPools
 		*out = make([]v1.TypedLocalObjectReference, len(*in))
@@ -128,6 +254,42 @@ func (in *NetworkDevice) DeepCopy() *NetworkDevice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkRoute) DeepCopyInto(out *NetworkRoute) {
+	*out = *in
+	if in.Metric != nil {
+		in, out := &in.Metric, &out.Metric
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkRoute.
+func (in *NetworkRoute) DeepCopy() *NetworkRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkVLAN) DeepCopyInto(out *NetworkVLAN) {
+	*out = *in
+	in.NetworkAddressing.DeepCopyInto(&out.NetworkAddressing)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkVLAN.
+func (in *NetworkVLAN) DeepCopy() *NetworkVLAN {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkVLAN)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Networking) DeepCopyInto(out *Networking) {
 	*out = *in
@@ -138,6 +300,20 @@ func (in *Networking) DeepCopyInto(out *Networking) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Bonds != nil {
+		in, out := &in.Bonds, &out.Bonds
+		*out = make([]NetworkBond, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]NetworkVLAN, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Networking.
@@ -179,9 +355,44 @@ func (in *ObjectMeta) DeepCopy() *ObjectMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Placement) DeepCopyInto(out *Placement) {
+	*out = *in
+	if in.PreferredHosts != nil {
+		in, out := &in.PreferredHosts, &out.PreferredHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedHosts != nil {
+		in, out := &in.ExcludedHosts, &out.ExcludedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinimumFreeMemory != nil {
+		in, out := &in.MinimumFreeMemory, &out.MinimumFreeMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Placement.
+func (in *Placement) DeepCopy() *Placement {
+	if in == nil {
+		return nil
+	}
+	out := new(Placement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScvmmCloudInitSpec) DeepCopyInto(out *ScvmmCloudInitSpec) {
 	*out = *in
+	if in.Janitor != nil {
+		in, out := &in.Janitor, &out.Janitor
+		*out = new(CloudInitJanitor)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmCloudInitSpec.
@@ -298,6 +509,13 @@ func (in *ScvmmClusterStatus) DeepCopyInto(out *ScvmmClusterStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.FailureDomainStatus != nil {
+		in, out := &in.FailureDomainStatus, &out.FailureDomainStatus
+		*out = make(map[string]ScvmmFailureDomainStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmClusterStatus.
@@ -404,6 +622,11 @@ func (in *ScvmmClusterTemplateSpec) DeepCopy() *ScvmmClusterTemplateSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScvmmFailureDomainSpec) DeepCopyInto(out *ScvmmFailureDomainSpec) {
 	*out = *in
+	if in.ProviderRef != nil {
+		in, out := &in.ProviderRef, &out.ProviderRef
+		*out = new(ScvmmProviderReference)
+		**out = **in
+	}
 	if in.Networking != nil {
 		in, out := &in.Networking, &out.Networking
 		*out = new(Networking)
@@ -421,6 +644,27 @@ func (in *ScvmmFailureDomainSpec) DeepCopy() *ScvmmFailureDomainSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmFailureDomainStatus) DeepCopyInto(out *ScvmmFailureDomainStatus) {
+	*out = *in
+	if in.ProviderRef != nil {
+		in, out := &in.ProviderRef, &out.ProviderRef
+		*out = new(ScvmmProviderReference)
+		**out = **in
+	}
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmFailureDomainStatus.
+func (in *ScvmmFailureDomainStatus) DeepCopy() *ScvmmFailureDomainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmFailureDomainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScvmmMachine) DeepCopyInto(out *ScvmmMachine) {
 	*out = *in
@@ -448,6 +692,26 @@ func (in *ScvmmMachine) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineCloudInit) DeepCopyInto(out *ScvmmMachineCloudInit) {
+	*out = *in
+	if in.DetachAfterBoot != nil {
+		in, out := &in.DetachAfterBoot, &out.DetachAfterBoot
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineCloudInit.
+func (in *ScvmmMachineCloudInit) DeepCopy() *ScvmmMachineCloudInit {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineCloudInit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScvmmMachineList) DeepCopyInto(out *ScvmmMachineList) {
 	*out = *in
@@ -480,6 +744,340 @@ func (in *ScvmmMachineList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineMigration) DeepCopyInto(out *ScvmmMachineMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineMigration.
+func (in *ScvmmMachineMigration) DeepCopy() *ScvmmMachineMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachineMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineMigrationList) DeepCopyInto(out *ScvmmMachineMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScvmmMachineMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineMigrationList.
+func (in *ScvmmMachineMigrationList) DeepCopy() *ScvmmMachineMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachineMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineMigrationSpec) DeepCopyInto(out *ScvmmMachineMigrationSpec) {
+	*out = *in
+	out.MachineRef = in.MachineRef
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(MigrationStorage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineMigrationSpec.
+func (in *ScvmmMachineMigrationSpec) DeepCopy() *ScvmmMachineMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineMigrationStatus) DeepCopyInto(out *ScvmmMachineMigrationStatus) {
+	*out = *in
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(VmJob)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineMigrationStatus.
+func (in *ScvmmMachineMigrationStatus) DeepCopy() *ScvmmMachineMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachinePool) DeepCopyInto(out *ScvmmMachinePool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachinePool.
+func (in *ScvmmMachinePool) DeepCopy() *ScvmmMachinePool {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachinePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachinePool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachinePoolList) DeepCopyInto(out *ScvmmMachinePoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScvmmMachinePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachinePoolList.
+func (in *ScvmmMachinePoolList) DeepCopy() *ScvmmMachinePoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachinePoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachinePoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachinePoolSpec) DeepCopyInto(out *ScvmmMachinePoolSpec) {
+	*out = *in
+	if in.ProviderIDList != nil {
+		in, out := &in.ProviderIDList, &out.ProviderIDList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(ScvmmMachinePoolStrategy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachinePoolSpec.
+func (in *ScvmmMachinePoolSpec) DeepCopy() *ScvmmMachinePoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachinePoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachinePoolStatus) DeepCopyInto(out *ScvmmMachinePoolStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachinePoolStatus.
+func (in *ScvmmMachinePoolStatus) DeepCopy() *ScvmmMachinePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachinePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachinePoolStrategy) DeepCopyInto(out *ScvmmMachinePoolStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachinePoolStrategy.
+func (in *ScvmmMachinePoolStrategy) DeepCopy() *ScvmmMachinePoolStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachinePoolStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineSnapshot) DeepCopyInto(out *ScvmmMachineSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineSnapshot.
+func (in *ScvmmMachineSnapshot) DeepCopy() *ScvmmMachineSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachineSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineSnapshotList) DeepCopyInto(out *ScvmmMachineSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScvmmMachineSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineSnapshotList.
+func (in *ScvmmMachineSnapshotList) DeepCopy() *ScvmmMachineSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScvmmMachineSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineSnapshotSpec) DeepCopyInto(out *ScvmmMachineSnapshotSpec) {
+	*out = *in
+	out.MachineRef = in.MachineRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineSnapshotSpec.
+func (in *ScvmmMachineSnapshotSpec) DeepCopy() *ScvmmMachineSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScvmmMachineSnapshotStatus) DeepCopyInto(out *ScvmmMachineSnapshotStatus) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+	in.LastRestoreTime.DeepCopyInto(&out.LastRestoreTime)
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(VmJob)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScvmmMachineSnapshotStatus.
+func (in *ScvmmMachineSnapshotStatus) DeepCopy() *ScvmmMachineSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScvmmMachineSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScvmmMachineSpec) DeepCopyInto(out *ScvmmMachineSpec) {
 	*out = *in
@@ -520,6 +1118,11 @@ func (in *ScvmmMachineSpec) DeepCopyInto(out *ScvmmMachineSpec) {
 		*out = new(ActiveDirectory)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(Placement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.VMOptions != nil {
 		in, out := &in.VMOptions, &out.VMOptions
 		*out = new(VmOptions)
@@ -537,6 +1140,11 @@ func (in *ScvmmMachineSpec) DeepCopyInto(out *ScvmmMachineSpec) {
 		*out = new(ScvmmProviderReference)
 		**out = **in
 	}
+	if in.CloudInit != nil {
+		in, out := &in.CloudInit, &out.CloudInit
+		*out = new(ScvmmMachineCloudInit)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Bootstrap != nil {
 		in, out := &in.Bootstrap, &out.Bootstrap
 		*out = new(v1beta1.Bootstrap)
@@ -564,6 +1172,28 @@ func (in *ScvmmMachineStatus) DeepCopyInto(out *ScvmmMachineStatus) {
 		*out = make([]v1beta1.MachineAddress, len(*in))
 		copy(*out, *in)
 	}
+	if in.NetworkAdapters != nil {
+		in, out := &in.NetworkAdapters, &out.NetworkAdapters
+		*out = make([]NetworkAdapterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(VmJob)
+		**out = **in
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(v1beta1.Conditions, len(*in))
@@ -884,7 +1514,7 @@ func (in *ScvmmProviderSpec) DeepCopyInto(out *ScvmmProviderSpec) {
 		*out = new(v1.SecretReference)
 		**out = **in
 	}
-	out.CloudInit = in.CloudInit
+	in.CloudInit.DeepCopyInto(&out.CloudInit)
 	if in.ADSecret != nil {
 		in, out := &in.ADSecret, &out.ADSecret
 		*out = new(v1.SecretReference)
@@ -958,6 +1588,21 @@ func (in *VmDisk) DeepCopy() *VmDisk {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VmJob) DeepCopyInto(out *VmJob) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VmJob.
+func (in *VmJob) DeepCopy() *VmJob {
+	if in == nil {
+		return nil
+	}
+	out := new(VmJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VmNameRange) DeepCopyInto(out *VmNameRange) {
 	*out = *in
@@ -0,0 +1,29 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+// v1beta1 is the hub that the other versions convert to and from
+
+func (*ScvmmCluster) Hub()          {}
+func (*ScvmmClusterTemplate) Hub()  {}
+func (*ScvmmMachine) Hub()          {}
+func (*ScvmmMachineMigration) Hub() {}
+func (*ScvmmMachinePool) Hub()      {}
+func (*ScvmmMachineSnapshot) Hub()  {}
+func (*ScvmmMachineTemplate) Hub()  {}
+func (*ScvmmNamePool) Hub()         {}
+func (*ScvmmProvider) Hub()         {}
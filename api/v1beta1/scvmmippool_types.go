@@ -0,0 +1,98 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ScvmmIPPoolSpec defines the desired state of ScvmmIPPool
+type ScvmmIPPoolSpec struct {
+	// ProviderRef points to the ScvmmProvider of the SCVMM server with the pool
+	// +optional
+	ProviderRef *ScvmmProviderReference `json:"providerRef,omitempty"`
+	// Name of the static IP address pool in SCVMM
+	StaticIPAddressPool string `json:"staticIPAddressPool"`
+}
+
+// ScvmmIPPoolStatus defines the observed state of ScvmmIPPool
+type ScvmmIPPoolStatus struct {
+	// Is the SCVMM pool found
+	// +optional
+	Ready bool `json:"ready"`
+	// Subnet of the pool in CIDR notation, as given by SCVMM
+	// +optional
+	Subnet string `json:"subnet,omitempty"`
+	// Default gateway of the pool
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+	// DNS servers of the pool
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+	// DNS search suffixes of the pool
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	// Info about the pool counts
+	// +optional
+	Counts *ScvmmPoolCounts `json:"counts,omitempty"`
+	// Number of IPAddressClaims that got an address from the pool
+	// +optional
+	Claimed int `json:"claimed"`
+	// Conditions defines current service state of the ScvmmIPPool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:JSONPath=".spec.staticIPAddressPool",type="string",name="POOL",description="SCVMM static IP address pool"
+// +kubebuilder:printcolumn:JSONPath=".status.subnet",type="string",name="SUBNET",description="Subnet of the pool"
+// +kubebuilder:printcolumn:JSONPath=".status.counts.free",type="integer",name="FREE",description="Number of free addresses"
+// +kubebuilder:printcolumn:JSONPath=".status.claimed",type="integer",name="CLAIMED",description="Number of claimed addresses"
+
+// ScvmmIPPool is the Schema for the scvmmippools API
+// It is a cluster-api IPAM provider that fulfils IPAddressClaims from a static IP address pool in SCVMM
+type ScvmmIPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmIPPoolSpec   `json:"spec,omitempty"`
+	Status ScvmmIPPoolStatus `json:"status,omitempty"`
+}
+
+func (c *ScvmmIPPool) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *ScvmmIPPool) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmIPPoolList contains a list of ScvmmIPPool
+type ScvmmIPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmIPPool `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ScvmmIPPool{}, &ScvmmIPPoolList{})
+}
@@ -0,0 +1,624 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// ScvmmMachineSpec defines the desired state of ScvmmMachine
+type ScvmmMachineSpec struct {
+	// ProviderID is scvmm plus vm-guid
+	// +optional
+	ProviderID string `json:"providerID,omitempty"`
+	// ID is scvmm object ID, will be filled in by controller
+	// +optional
+	Id string `json:"id,omitempty"`
+	// VMM cloud to run VM on
+	// +optional
+	Cloud string `json:"cloud,omitempty"`
+	// Host Group to run VM in
+	// +optional
+	HostGroup string `json:"hostGroup,omitempty"`
+	// Name of the VM
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+	// Pool to get VM name from
+	// +optional
+	VMNameFromPool *corev1.LocalObjectReference `json:"vmNameFromPool,omitempty"`
+	// VM template to use
+	// +optional
+	VMTemplate string `json:"vmTemplate,omitempty"`
+	// Extra disks (after the VHDisk) to connect to the VM
+	// +optional
+	Disks []VmDisk `json:"disks,omitempty"`
+	// Virtual Fibrechannel device
+	// +optional
+	FibreChannel []FibreChannel `json:"fibreChannel,omitempty"`
+	// Number of CPU's
+	CPUCount int `json:"cpuCount"`
+	// Allocated memory
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Dynamic Memory
+	// +optional
+	DynamicMemory *DynamicMemory `json:"dynamicMemory,omitempty"`
+	// Hardware profile
+	HardwareProfile string `json:"hardwareProfile"`
+	// OperatingSystem
+	// +optional
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+	// Guest operating system family
+	// Windows guests get a config drive for cloudbase-init instead of NoCloud data,
+	// and join the activeDirectory domain offline when that is set
+	// +kubebuilder:validation:Enum=Linux;Windows
+	// +optional
+	OSType string `json:"osType,omitempty"`
+	// Network settings
+	// +optional
+	Networking *Networking `json:"networking,omitempty"`
+	// Active Directory entry
+	// +optional
+	ActiveDirectory *ActiveDirectory `json:"activeDirectory,omitempty"`
+	// DNS registration settings, overriding the ones from the provider
+	// +optional
+	DNS *DNSRegistration `json:"dns,omitempty"`
+	// AvailabilitySet
+	// +optional
+	AvailabilitySet string `json:"availabilitySet,omitempty"`
+	// Manage the availability set automatically, overriding availabilitySet.
+	// Control plane machines get one per cluster, workers one per MachineDeployment.
+	// The availability set is created when needed, and removed with its last VM.
+	// +optional
+	AutoAvailabilitySet bool `json:"autoAvailabilitySet,omitempty"`
+	// Placement picks the host to create the VM on from the SCVMM host ratings
+	// Without it, SCVMM places the VM in the host group itself
+	// +optional
+	Placement *Placement `json:"placement,omitempty"`
+	// Options for New-SCVirtualMachine
+	// +optional
+	VMOptions *VmOptions `json:"vmOptions,omitempty"`
+	// Custom VirtualMachine Properties
+	// Named CustomProperty because that's what it's named in SCVMM virtual machines
+	// +optional
+	CustomProperty map[string]string `json:"customProperty,omitempty"`
+	// VirtualMachine tag
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// ProviderRef points to an ScvmmProvider instance that defines the provider settings for this cluster.
+	// Will be copied from scvmmcluster if not using local bootstrap
+	// +optional
+	ProviderRef *ScvmmProviderReference `json:"providerRef,omitempty"`
+	// Cloud-init settings, overriding the ones from the provider
+	// +optional
+	CloudInit *ScvmmMachineCloudInit `json:"cloudInit,omitempty"`
+	// Custom bootstrap secret ref
+	// This triggers the controller to create the machine without a (cluster-api) cluster
+	// For testing purposes, or just for creating VMs
+	// +optional
+	Bootstrap *clusterv1.Bootstrap `json:"bootstrap,omitempty"`
+}
+
+type ScvmmMachineCloudInit struct {
+	// Detach the cloud-init device and delete it from the library share
+	// once the VM is running and reports its addresses.
+	// Defaults to the setting of the provider
+	// +optional
+	DetachAfterBoot *bool `json:"detachAfterBoot,omitempty"`
+}
+
+type VmOptions struct {
+	// Description
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Start Action
+	// +optional
+	// +kubebuilder:validation:Enum=NeverAutoTurnOnVM;AlwaysAutoTurnOnVM;TurnOnVMIfRunningWhenVSStopped
+	StartAction string `json:"startAction,omitempty"`
+	// Stop Action
+	// +optional
+	// +kubebuilder:validation:Enum=ShutdownGuestOS;TurnOffVM;SaveVM
+	StopAction string `json:"stopAction,omitempty"`
+	// CPULimitForMigration
+	// +optional
+	CPULimitForMigration *bool `json:"cpuLimitForMigration,omitempty"`
+	// CPULimitFunctionality
+	// +optional
+	CPULimitFunctionality *bool `json:"cpuLimitFunctionality,omitempty"`
+	// EnableNestedVirtualization
+	// +optional
+	EnableNestedVirtualization *bool `json:"enableNestedVirtualization,omitempty"`
+	// CheckpointType
+	// +kubebuilder:default:=Standard
+	// +kubebuilder:validation:Enum=Disabled;Production;ProductionOnly;Standard
+	CheckpointType string `json:"checkpointType,omitempty"`
+}
+
+type Placement struct {
+	// Hosts to prefer over the other hosts, if they have capacity
+	// +optional
+	PreferredHosts []string `json:"preferredHosts,omitempty"`
+	// Hosts never to place the VM on
+	// +optional
+	ExcludedHosts []string `json:"excludedHosts,omitempty"`
+	// Memory that has to be left free on the host after placing the VM
+	// +optional
+	MinimumFreeMemory *resource.Quantity `json:"minimumFreeMemory,omitempty"`
+}
+
+type VmDisk struct {
+	// Size of the virtual disk
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+	// Specify that the virtual disk can expand dynamically (default: true)
+	// +optional
+	Dynamic bool `json:"dynamic,omitempty"`
+	// Virtual Harddisk to couple
+	// +optional
+	VHDisk string `json:"vhDisk,omitempty"`
+}
+
+type NetworkDevice struct {
+	// Network device name
+	// +kubebuilder:default:=eth0
+	DeviceName string `json:"deviceName,omitempty"`
+	// Virtual Network identifier
+	VMNetwork string `json:"vmNetwork"`
+	// Static MAC address of the network adapter
+	// Without it, the MAC address that SCVMM assigns is used, if it is known before the first boot.
+	// The guest network configuration matches on the MAC address when it is known, and on the device name otherwise
+	// +kubebuilder:validation:Pattern=`^([0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}$`
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// Let SCVMM assign a static MAC address from the MAC address pool of the host group
+	// Ignored when macAddress is set
+	// +optional
+	MACAddressFromPool bool `json:"macAddressFromPool,omitempty"`
+	// VM subnet of the VM network to connect to
+	// Defaults to the first subnet of the VM network
+	// +optional
+	VMSubnet string `json:"vmSubnet,omitempty"`
+	// VLAN ID of the virtual network adapter
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLanID *int `json:"vlanID,omitempty"`
+	// SCVMM port classification of the virtual network adapter
+	// +optional
+	PortClassification string `json:"portClassification,omitempty"`
+	// SCVMM static IP address pool to assign an IPv4 address from
+	// The assigned address, the gateway and the dns servers of the pool are used
+	// for the guest network configuration when ipAddresses is not set
+	// +optional
+	StaticIPAddressPool string `json:"staticIPAddressPool,omitempty"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
+	// List of IPAddressPools that should be assigned
+	// to IPAddressClaims. The machine's cloud-init metadata will be populated
+	// with IPAddresses fulfilled by an IPAM provider.
+	// +optional
+	AddressesFromPools []corev1.TypedLocalObjectReference `json:"addressesFromPools,omitempty"`
+	// Name of the pool in addressesFromPools whose gateway is used for the default route of its address family
+	// Defaults to the first pool of each address family.  Addresses from pools with another gateway
+	// get source-based routing through their own gateway, which only the NoCloud network-config and
+	// ignition support.  A gateway set in the spec takes precedence.
+	// +optional
+	DefaultRoutePool string `json:"defaultRoutePool,omitempty"`
+}
+
+type NetworkAddressing struct {
+	// IP Addresses (IPv4 and/or IPv6) in CIDR notation
+	// +optional
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+	// IPv4 Gateway
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+	// IPv6 Gateway
+	// +optional
+	Gateway6 string `json:"gateway6,omitempty"`
+	// Nameservers
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+	// List of search domains used when resolving with DNS
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	// Static routes
+	// +optional
+	Routes []NetworkRoute `json:"routes,omitempty"`
+	// MTU
+	// +kubebuilder:validation:Minimum=576
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+}
+
+type NetworkRoute struct {
+	// Destination network in CIDR notation
+	To string `json:"to"`
+	// Gateway
+	// Without it, the destination is directly reachable on the link
+	// +optional
+	Via string `json:"via,omitempty"`
+	// Route metric
+	// +optional
+	Metric *int `json:"metric,omitempty"`
+	// Source address (or network in CIDR notation) for source-based routing
+	// The route is put in the given routing table, which is used for traffic from this source
+	// Only supported by the NoCloud network-config and ignition
+	// +optional
+	From string `json:"from,omitempty"`
+	// Routing table of the route, required with from
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=252
+	// +optional
+	Table int `json:"table,omitempty"`
+}
+
+// VLAN subinterface
+type NetworkVLAN struct {
+	// Interface name
+	Name string `json:"name"`
+	// VLAN ID
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	ID int `json:"id"`
+	// Parent interface, a device or bond name
+	Link string `json:"link"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
+}
+
+// Bond over multiple network devices
+type NetworkBond struct {
+	// Interface name
+	Name string `json:"name"`
+	// Names of the devices in the bond
+	// +kubebuilder:validation:MinItems=1
+	Interfaces []string `json:"interfaces"`
+	// Bonding mode
+	// Modes other than active-backup need MAC address spoofing on the virtual network adapters
+	// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;"802.3ad";balance-tlb;balance-alb
+	// +kubebuilder:default:=active-backup
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// Link monitoring interval in milliseconds
+	// +optional
+	MIIMonitorInterval *int `json:"miiMonitorInterval,omitempty"`
+	// Addresses, gateways, routes and dns settings
+	NetworkAddressing `json:",inline"`
+}
+
+type Networking struct {
+	// Network devices
+	// +optional
+	// +listType=map
+	// +listMapKey=deviceName
+	Devices []NetworkDevice `json:"devices,omitempty"`
+	// Bonds over network devices
+	// Not supported on windows guests
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Bonds []NetworkBond `json:"bonds,omitempty"`
+	// VLAN subinterfaces of network devices or bonds
+	// Not supported on windows guests
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	VLANs []NetworkVLAN `json:"vlans,omitempty"`
+	// Host domain
+	// +optional
+	Domain string `json:"domain,omitempty"`
+}
+
+type FibreChannel struct {
+	// Storage Fabric Classification
+	// +optional
+	StorageFabricClassification string `json:"storageFabricClassification,omitempty"`
+	// Virtual SAN
+	// +optional
+	VirtualSAN string `json:"virtualSAN,omitempty"`
+}
+
+const (
+	OSTypeLinux   = "Linux"
+	OSTypeWindows = "Windows"
+)
+
+type ActiveDirectory struct {
+	// Domain Controller
+	// +optional
+	DomainController string `json:"domainController,omitempty"`
+	// OU Path
+	OUPath string `json:"ouPath"`
+	// Description
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Group memberships
+	// +optional
+	MemberOf []string `json:"memberOf,omitempty"`
+}
+
+type DynamicMemory struct {
+	// Minimum
+	Minimum *resource.Quantity `json:"minimum"`
+	// Maximum
+	Maximum *resource.Quantity `json:"maximum"`
+	// BufferPercentage
+	// +optional
+	BufferPercentage *int `json:"bufferPercentage,omitempty"`
+}
+
+type NetworkAdapterStatus struct {
+	// Network device name from the spec, eth<slot> if it has none
+	// +optional
+	DeviceName string `json:"deviceName,omitempty"`
+	// MAC address
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// Static or Dynamic
+	// +optional
+	MACAddressType string `json:"macAddressType,omitempty"`
+	// VM network
+	// +optional
+	VMNetwork string `json:"vmNetwork,omitempty"`
+	// VM subnet
+	// +optional
+	VMSubnet string `json:"vmSubnet,omitempty"`
+	// VLAN ID, if VLAN is enabled
+	// +optional
+	VLanID int `json:"vlanID,omitempty"`
+	// Port classification
+	// +optional
+	PortClassification string `json:"portClassification,omitempty"`
+	// IPv4 addresses assigned from SCVMM static IP address pools
+	// +optional
+	StaticIPAddresses []string `json:"staticIPAddresses,omitempty"`
+	// Default gateway of the SCVMM static IP address pool
+	// +optional
+	StaticGateway string `json:"staticGateway,omitempty"`
+	// DNS servers of the SCVMM static IP address pool
+	// +optional
+	StaticNameservers []string `json:"staticNameservers,omitempty"`
+	// DNS search suffixes of the SCVMM static IP address pool
+	// +optional
+	StaticSearchDomains []string `json:"staticSearchDomains,omitempty"`
+}
+
+// Address claimed from an IPAM pool for a network device
+type IPAddressStatus struct {
+	// Network device the address is for
+	DeviceName string `json:"deviceName"`
+	// IPAddressClaim the address was claimed with
+	ClaimName string `json:"claimName"`
+	// IP address, without prefix
+	Address string `json:"address"`
+	// Prefix length of the subnet of the address
+	Prefix int `json:"prefix"`
+	// Gateway of the subnet of the address
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+	// Pool the address was claimed from
+	PoolRef corev1.TypedLocalObjectReference `json:"poolRef"`
+	// DNS servers of the pool, when the pool provides them
+	// Used when the device has no nameservers
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+	// DNS search domains of the pool, when the pool provides them
+	// Used when the device has no search domains
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// DNS records registered for a machine
+type DNSRecordsStatus struct {
+	// DNS server the records are registered on
+	// +optional
+	Server string `json:"server,omitempty"`
+	// Forward lookup zone of the A and AAAA records
+	Zone string `json:"zone"`
+	// Record name in the zone
+	Name string `json:"name"`
+	// Addresses registered for the name
+	Addresses []string `json:"addresses"`
+}
+
+// ScvmmMachineStatus defines the observed state of ScvmmMachine
+type ScvmmMachineStatus struct {
+	// Mandatory field, is machine ready
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+	// Status string as given by SCVMM
+	// +optional
+	VMStatus string `json:"vmStatus,omitempty"`
+	// BiosGuid as reported by SVCMM
+	// +optional
+	BiosGuid string `json:"biosGuid,omitempty"`
+	// Creation time as given by SCVMM
+	// +optional
+	CreationTime metav1.Time `json:"creationTime,omitempty"`
+	// Modification time as given by SCVMM
+	// +optional
+	ModifiedTime metav1.Time `json:"modifiedTime,omitempty"`
+	// Host name of the VM
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// Hyper-V host the VM is running on
+	// When using placement, this is filled in with the selected host before creating the VM
+	// +optional
+	VMHost string `json:"vmHost,omitempty"`
+	// SCVMM cloud the VM is in
+	// +optional
+	Cloud string `json:"cloud,omitempty"`
+	// Path of the host group of the Hyper-V host the VM is running on
+	// +optional
+	HostGroup string `json:"hostGroup,omitempty"`
+	// Addresses contains the associated addresses for the virtual machine
+	// +optional
+	Addresses []clusterv1.MachineAddress `json:"addresses,omitempty"`
+	// The virtual network adapters as configured in SCVMM
+	// +optional
+	NetworkAdapters []NetworkAdapterStatus `json:"networkAdapters,omitempty"`
+	// Addresses claimed from the addressesFromPools of the network devices
+	// These are used for the guest network configuration on top of the ipAddresses in the spec
+	// +optional
+	IPAddresses []IPAddressStatus `json:"ipAddresses,omitempty"`
+	// DNS records registered for the machine, so they can be removed when it is deleted
+	// +optional
+	DNS *DNSRecordsStatus `json:"dns,omitempty"`
+	// SCVMM job the controller is waiting on
+	// +optional
+	Job *VmJob `json:"job,omitempty"`
+	// FailureReason is set when SCVMM returned an error that will not go away by retrying,
+	// like a missing template or an exceeded quota.  The controller stops reconciling the
+	// machine, which has to be deleted and recreated.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+	// FailureMessage is the error message that goes with FailureReason
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+	// Conditions defines current service state of the ScvmmMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+type VmJob struct {
+	// SCVMM job ID
+	Id string `json:"id"`
+	// Name of the job as given by SCVMM
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Status of the job as given by SCVMM
+	// +optional
+	Status string `json:"status,omitempty"`
+	// Progress of the job as given by SCVMM
+	// +optional
+	Progress string `json:"progress,omitempty"`
+	// Condition of the machine that the job is reported on while it runs, or when it fails
+	// +optional
+	Condition clusterv1.ConditionType `json:"condition,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:JSONPath=".status.vmStatus",type="string",name="STATUS",description="Virtual Machine Status"
+// +kubebuilder:printcolumn:JSONPath=".status.hostname",type="string",name="HOST",description="Virtual Machine Hostname",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.vmHost",type="string",name="VMHOST",description="Hyper-V host of the Virtual Machine",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.addresses[].address",type="string",name="IP",description="Virtual Machine IP Address"
+// +kubebuilder:printcolumn:JSONPath=".spec.providerID",type="string",name="ID",description="Virtual Machine ProviderID",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.creationTime",type="date",name="AGE",description="Virtual Machine Creation Timestamp"
+
+// ScvmmMachine is the Schema for the scvmmmachines API
+type ScvmmMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmMachineSpec   `json:"spec,omitempty"`
+	Status ScvmmMachineStatus `json:"status,omitempty"`
+}
+
+func (c *ScvmmMachine) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *ScvmmMachine) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmMachineList contains a list of ScvmmMachine
+type ScvmmMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmMachine `json:"items"`
+}
+
+func init() {
+	//SchemeBuilder.Register(&ScvmmMachine{}, &ScvmmMachineList{})
+	objectTypes = append(objectTypes, &ScvmmMachine{}, &ScvmmMachineList{})
+}
+
+func (in *ScvmmMachineSpec) CopyNonZeroTo(out *ScvmmMachineSpec) bool {
+	changed := false
+	if in.Cloud != "" && in.Cloud != out.Cloud {
+		changed = true
+		out.Cloud = in.Cloud
+	}
+	if in.HostGroup != "" && in.HostGroup != out.HostGroup {
+		changed = true
+		out.HostGroup = in.HostGroup
+	}
+	if in.VMName != "" && in.VMName != out.VMName {
+		changed = true
+		out.VMName = in.VMName
+	}
+	if in.VMTemplate != "" && in.VMTemplate != out.VMTemplate {
+		changed = true
+		out.VMTemplate = in.VMTemplate
+	}
+	if in.Disks != nil && VmDiskEquals(in.Disks, out.Disks) {
+		changed = true
+		out.Disks = in.Disks
+	}
+	if in.CPUCount != 0 && in.CPUCount != out.CPUCount {
+		changed = true
+		out.CPUCount = in.CPUCount
+	}
+	if in.Memory != nil && in.Memory != out.Memory {
+		changed = true
+		out.Memory = in.Memory
+	}
+	if in.HardwareProfile != "" && in.HardwareProfile != out.HardwareProfile {
+		changed = true
+		out.HardwareProfile = in.HardwareProfile
+	}
+	if in.Networking != nil && !reflect.DeepEqual(in.Networking, out.Networking) {
+		changed = true
+		out.Networking = in.Networking
+	}
+	if in.AvailabilitySet != "" && in.AvailabilitySet != out.AvailabilitySet {
+		changed = true
+		out.AvailabilitySet = in.AvailabilitySet
+	}
+	if in.VMOptions != nil && !reflect.DeepEqual(in.VMOptions, out.VMOptions) {
+		changed = true
+		out.VMOptions = in.VMOptions
+	}
+	return changed
+}
+
+func VmDiskEquals(left []VmDisk, right []VmDisk) bool {
+	if left == nil {
+		return right == nil
+	}
+	if len(left) != len(right) {
+		return false
+	}
+	for i, v := range left {
+		if v != right[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ScvmmMachineMigrationSpec defines the desired state of ScvmmMachineMigration
+// +kubebuilder:validation:XValidation:rule="has(self.vmHost) || has(self.hostGroup) || has(self.cloud)",message="one of vmHost, hostGroup or cloud is required"
+type ScvmmMachineMigrationSpec struct {
+	// ScvmmMachine (in the same namespace) to migrate
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="machineRef is immutable"
+	MachineRef corev1.LocalObjectReference `json:"machineRef"`
+	// Hyper-V host to migrate the VM to
+	// +optional
+	VMHost string `json:"vmHost,omitempty"`
+	// Host group to migrate the VM to, the best rated host in the group is picked
+	// +optional
+	HostGroup string `json:"hostGroup,omitempty"`
+	// VMM cloud to migrate the VM to, the best rated host in the cloud is picked
+	// +optional
+	Cloud string `json:"cloud,omitempty"`
+	// Storage options for the migration
+	// +optional
+	Storage *MigrationStorage `json:"storage,omitempty"`
+	// Migrate even if the target is outside of the failure domain of the machine
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+type MigrationStorage struct {
+	// Path on the target host to move the VM storage to
+	// Without a path only the VM is moved, and the storage stays where it is
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Transfer the storage over the network instead of through the SAN
+	// +optional
+	UseLAN bool `json:"useLAN,omitempty"`
+}
+
+// ScvmmMachineMigrationStatus defines the observed state of ScvmmMachineMigration
+type ScvmmMachineMigrationStatus struct {
+	// Is the migration done
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+	// Hyper-V host the VM was on before the migration
+	// +optional
+	SourceHost string `json:"sourceHost,omitempty"`
+	// Hyper-V host the VM is on after the migration
+	// +optional
+	TargetHost string `json:"targetHost,omitempty"`
+	// Time the migration finished
+	// +optional
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+	// SCVMM job the controller is waiting on
+	// +optional
+	Job *VmJob `json:"job,omitempty"`
+	// Conditions defines current service state of the ScvmmMachineMigration.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:JSONPath=".spec.machineRef.name",type="string",name="MACHINE",description="ScvmmMachine to migrate"
+// +kubebuilder:printcolumn:JSONPath=".status.ready",type="boolean",name="READY",description="Migration is done"
+// +kubebuilder:printcolumn:JSONPath=".status.sourceHost",type="string",name="SOURCE",description="Host before migration",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.targetHost",type="string",name="TARGET",description="Host after migration"
+// +kubebuilder:printcolumn:JSONPath=".status.completionTime",type="date",name="COMPLETED",description="Migration completion time"
+
+// ScvmmMachineMigration is the Schema for the scvmmmachinemigrations API
+type ScvmmMachineMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmMachineMigrationSpec   `json:"spec,omitempty"`
+	Status ScvmmMachineMigrationStatus `json:"status,omitempty"`
+}
+
+func (c *ScvmmMachineMigration) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *ScvmmMachineMigration) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmMachineMigrationList contains a list of ScvmmMachineMigration
+type ScvmmMachineMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmMachineMigration `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ScvmmMachineMigration{}, &ScvmmMachineMigrationList{})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ScvmmMachineSnapshotSpec defines the desired state of ScvmmMachineSnapshot
+type ScvmmMachineSnapshotSpec struct {
+	// ScvmmMachine (in the same namespace) to take a checkpoint of
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="machineRef is immutable"
+	MachineRef corev1.LocalObjectReference `json:"machineRef"`
+	// Description of the checkpoint
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Revert the VM to the checkpoint (turning it off and on again)
+	// Set it to a new value, like the current time, for every restore.
+	// The restore is done once per value, which is then recorded in status.lastRestoreRequest.
+	// A request made before the checkpoint is ready is refused.
+	// +optional
+	RestoreRequest string `json:"restoreRequest,omitempty"`
+	// Allow checkpoints of machines that are managed by cluster-api
+	// Restoring those can confuse the workload cluster, so it has to be explicitly allowed
+	// +optional
+	AllowManagedMachine bool `json:"allowManagedMachine,omitempty"`
+}
+
+// ScvmmMachineSnapshotStatus defines the observed state of ScvmmMachineSnapshot
+type ScvmmMachineSnapshotStatus struct {
+	// Is the checkpoint created
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+	// SCVMM ID of the checkpoint
+	// +optional
+	CheckpointId string `json:"checkpointId,omitempty"`
+	// Creation time of the checkpoint as given by SCVMM
+	// +optional
+	CreationTime metav1.Time `json:"creationTime,omitempty"`
+	// Time of the last restore of the checkpoint
+	// +optional
+	LastRestoreTime metav1.Time `json:"lastRestoreTime,omitempty"`
+	// The last restoreRequest that was handled, whether the restore was done, refused or failed
+	// +optional
+	LastRestoreRequest string `json:"lastRestoreRequest,omitempty"`
+	// SCVMM job the controller is waiting on
+	// +optional
+	Job *VmJob `json:"job,omitempty"`
+	// Conditions defines current service state of the ScvmmMachineSnapshot.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:JSONPath=".spec.machineRef.name",type="string",name="MACHINE",description="ScvmmMachine of the checkpoint"
+// +kubebuilder:printcolumn:JSONPath=".status.ready",type="boolean",name="READY",description="Checkpoint is created"
+// +kubebuilder:printcolumn:JSONPath=".status.checkpointId",type="string",name="ID",description="SCVMM checkpoint ID",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.lastRestoreTime",type="date",name="RESTORED",description="Time of last restore",priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.creationTime",type="date",name="AGE",description="Checkpoint Creation Timestamp"
+
+// ScvmmMachineSnapshot is the Schema for the scvmmmachinesnapshots API
+type ScvmmMachineSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmMachineSnapshotSpec   `json:"spec,omitempty"`
+	Status ScvmmMachineSnapshotStatus `json:"status,omitempty"`
+}
+
+func (c *ScvmmMachineSnapshot) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *ScvmmMachineSnapshot) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmMachineSnapshotList contains a list of ScvmmMachineSnapshot
+type ScvmmMachineSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmMachineSnapshot `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ScvmmMachineSnapshot{}, &ScvmmMachineSnapshotList{})
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ScvmmProviderReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// ScvmmProviderSpec defines the desired state of ScvmmProvider
+type ScvmmProviderSpec struct {
+	// Hostname of scvmm server
+	ScvmmHost string `json:"scvmmHost"`
+	// Reference to secret containing user and password for scvmm
+	ScvmmSecret *corev1.SecretReference `json:"scvmmSecret,omitempty"`
+	// Jumphost to run scvmm scripts on, instead of directly on the scvmm server
+	// +optional
+	ExecHost string `json:"execHost,omitempty"`
+	// How long to keep winrm connections to scvmm alive
+	// Default 20 seconds
+	KeepAliveSeconds int `json:"keepAliveSeconds,omitempty"`
+	// Settings that define how to pass cloud-init data
+	// +optional
+	CloudInit ScvmmCloudInitSpec `json:"cloudInit,omitempty"`
+	// Active Directory server
+	// +optional
+	ADServer string `json:"adServer,omitempty"`
+	// Reference to secret containing user and password for activediractory
+	// +optional
+	ADSecret *corev1.SecretReference `json:"adSecret,omitempty"`
+	// Register DNS records for the machines on a Windows DNS server
+	// Can be overridden per machine
+	// +optional
+	DNS *DNSRegistration `json:"dns,omitempty"`
+	// Extra functions to run when provisioning machines
+	// +optional
+	ExtraFunctions map[string]string `json:"extraFunctions,omitempty"`
+
+	// Scvmm/AD username and Password (not serialized)
+	ScvmmUsername string `json:"-"`
+	ScvmmPassword string `json:"-"`
+	ADUsername    string `json:"-"`
+	ADPassword    string `json:"-"`
+
+	// Environment variables to set for scripts
+	// Will be supplemented with scvmm and ad credentials
+	// +optional
+	Env map[string]string `json:"env,omitempty"`
+
+	// Sensitive env variables
+	SensitiveEnv map[string]string `json:"-"`
+}
+
+type ScvmmCloudInitSpec struct {
+	// Library share where ISOs can be placed for cloud-init
+	// Defaults to \\<Get-SCLibraryShare.Path>\ISOs\cloud-init
+	// +optional
+	LibraryShare string `json:"libraryShare,omitempty"`
+	// Filesystem to use for cloud-init
+	// vfat or iso9660
+	// Defaults to vfat
+	// +optional
+	// +kubebuilder:validation:Enum=vfat;iso9660
+	FileSystem string `json:"fileSystem,omitempty"`
+	// Device type to use for cloud-init
+	// dvd floppy scsi ide
+	// Defaults to dvd
+	// +optional
+	// +kubebuilder:validation:Enum=dvd;floppy;scsi;ide
+	DeviceType string `json:"deviceType,omitempty"`
+	// Layout of the cloud-init data
+	// NoCloud puts meta-data, user-data and network-config in the root of a volume labeled cidata,
+	// ConfigDrive puts meta_data.json, user_data and network_data.json in openstack/latest on a volume labeled config-2
+	// Defaults to NoCloud
+	// Ignition and Windows machines always get a ConfigDrive
+	// +optional
+	// +kubebuilder:validation:Enum=NoCloud;ConfigDrive
+	Datasource string `json:"datasource,omitempty"`
+	// Detach the cloud-init device and delete it from the library share
+	// once the VM is running and reports its addresses.
+	// The cloud-init data contains secrets like the cluster join token
+	// +optional
+	DetachAfterBoot bool `json:"detachAfterBoot,omitempty"`
+	// Periodically clean up cloud-init files on the library share
+	// that don't belong to any ScvmmMachine
+	// +optional
+	Janitor *CloudInitJanitor `json:"janitor,omitempty"`
+}
+
+// Settings for registering A/AAAA and PTR records of machines
+// The records are managed with the DnsServer powershell module, using the AD credentials
+type DNSRegistration struct {
+	// Don't register DNS records, to opt out machines when the provider has dns settings
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// DNS server to manage the records on
+	// Defaults to the adServer of the provider
+	// +optional
+	Server string `json:"server,omitempty"`
+	// Forward lookup zone to register the A and AAAA records in
+	// Defaults to the networking domain of the machine
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Time to live of the records
+	// Defaults to the default TTL of the zone
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// Don't register PTR records in the reverse lookup zones
+	// Skipped when set on either the provider or the machine
+	// +optional
+	SkipPTR bool `json:"skipPTR,omitempty"`
+}
+
+const (
+	DatasourceNoCloud     = "NoCloud"
+	DatasourceConfigDrive = "ConfigDrive"
+)
+
+type CloudInitJanitor struct {
+	// How often to check the library share
+	// Defaults to 1 hour
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Only remove files that are at least this old
+	// Defaults to 24 hours
+	// +optional
+	MinAge *metav1.Duration `json:"minAge,omitempty"`
+	// Only report orphaned files (in the log and metrics) instead of removing them
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ScvmmProviderStatus defines the observed state of ScvmmProvider
+type ScvmmProviderStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// ScvmmProvider is the Schema for the scvmmproviders API
+type ScvmmProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScvmmProviderSpec   `json:"spec,omitempty"`
+	Status ScvmmProviderStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScvmmProviderList contains a list of ScvmmProvider
+type ScvmmProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScvmmProvider `json:"items"`
+}
+
+func init() {
+	//SchemeBuilder.Register(&ScvmmProvider{}, &ScvmmProviderList{})
+	objectTypes = append(objectTypes, &ScvmmProvider{}, &ScvmmProviderList{})
+}
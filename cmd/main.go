@@ -29,6 +29,8 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/flags"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -38,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrastructurev1alpha1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrastructurev1beta1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 	"github.com/willemm/cluster-api-provider-scvmm/internal/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -51,7 +54,9 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(infrastructurev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1beta1.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(expv1.AddToScheme(scheme))
 	utilruntime.Must(ipamv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -131,6 +136,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Cached clients of the workload clusters, to label their nodes
+	trackerLog := ctrl.Log.WithName("remote").WithName("ClusterCacheTracker")
+	tracker, err := remote.NewClusterCacheTracker(mgr, remote.ClusterCacheTrackerOptions{
+		ControllerName: "caps-controller",
+		Log:            &trackerLog,
+		Indexes:        []remote.Index{remote.NodeProviderIDIndex},
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create cluster cache tracker")
+		os.Exit(1)
+	}
+	if err = (&remote.ClusterCacheReconciler{
+		Client:  mgr.GetClient(),
+		Tracker: tracker,
+	}).SetupWithManager(ctx, mgr, concurrency(clusterConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterCacheReconciler")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ScvmmClusterReconciler{
 		Client: mgr.GetClient(),
 	}).SetupWithManager(ctx, mgr, concurrency(clusterConcurrency)); err != nil {
@@ -138,17 +162,81 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.ScvmmMachineReconciler{
-		Client: mgr.GetClient(),
+		Client:  mgr.GetClient(),
+		Tracker: tracker,
 	}).SetupWithManager(ctx, mgr, concurrency(machineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ScvmmMachine")
 		os.Exit(1)
 	}
+	if err = (&controllers.ScvmmMachineSnapshotReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(ctx, mgr, concurrency(machineConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScvmmMachineSnapshot")
+		os.Exit(1)
+	}
+	if err = (&controllers.ScvmmMachineMigrationReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(ctx, mgr, concurrency(machineConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScvmmMachineMigration")
+		os.Exit(1)
+	}
+	if err = (&controllers.ScvmmMachinePoolReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(ctx, mgr, concurrency(machineConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScvmmMachinePool")
+		os.Exit(1)
+	}
+	if err = (&controllers.ScvmmIPPoolReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(ctx, mgr, concurrency(clusterConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScvmmIPPool")
+		os.Exit(1)
+	}
 	if err = (&controllers.ScvmmProviderReconciler{
 		Client: mgr.GetClient(),
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ScvmmProvider")
 		os.Exit(1)
 	}
+	// Conversion webhooks between the API versions
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&infrastructurev1beta1.ScvmmCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmCluster")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmClusterTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmClusterTemplate")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmMachine{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmMachine")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmMachineMigration{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmMachineMigration")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmMachinePool{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmMachinePool")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmMachineSnapshot{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmMachineSnapshot")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmMachineTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmMachineTemplate")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmNamePool{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmNamePool")
+			os.Exit(1)
+		}
+		if err = (&infrastructurev1beta1.ScvmmProvider{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScvmmProvider")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	// Keep to 1 until ntlm concurrency issue is fixed:
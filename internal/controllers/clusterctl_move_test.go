@@ -0,0 +1,202 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// The objects of a cluster with two running machines with names from a pool,
+// as they arrive in the new management cluster after clusterctl move: without their status
+func clusterctlMoveSetup(t *testing.T, withPool bool) (*fakeEnv, *ScvmmMachineReconciler, *infrav1.ScvmmNamePool) {
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "moved"},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Namespace: "default", Name: "moved"},
+		},
+		Status: clusterv1.ClusterStatus{InfrastructureReady: true},
+	}
+	pool := &infrav1.ScvmmNamePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "names"},
+		Spec: infrav1.ScvmmNamePoolSpec{
+			VMNameRanges: []infrav1.VmNameRange{{From: "node01", To: "node09"}},
+		},
+	}
+	objects := []client.Object{cluster}
+	vms := map[string]VMResult{}
+	if withPool {
+		objects = append(objects, pool)
+	}
+	for vmName, address := range map[string]string{"node03": "10.0.0.3", "node01": "10.0.0.1"} {
+		machine := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "machine-" + vmName,
+				Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+			},
+			Spec: clusterv1.MachineSpec{ClusterName: cluster.Name},
+		}
+		scvmmMachine := &infrav1.ScvmmMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "default",
+				Name:       "scvmm-" + vmName,
+				Finalizers: []string{MachineFinalizer},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       "Machine",
+					Name:       machine.Name,
+				}},
+			},
+			Spec: infrav1.ScvmmMachineSpec{
+				ProviderRef:    testProviderRef(),
+				Cloud:          "cloud",
+				HostGroup:      "hostgroup",
+				VMNameFromPool: &corev1.LocalObjectReference{Name: pool.Name},
+				VMName:         vmName,
+				Id:             "id-" + vmName,
+				ProviderID:     "scvmm://guid-" + vmName,
+				Networking: &infrav1.Networking{
+					Domain: "example.com",
+					Devices: []infrav1.NetworkDevice{{
+						DeviceName: "eth0",
+						VMNetwork:  "net",
+						NetworkAddressing: infrav1.NetworkAddressing{
+							IPAddresses: []string{address + "/24"},
+							Gateway:     "10.0.0.254",
+						},
+					}},
+				},
+			},
+		}
+		vms[scvmmMachine.Spec.Id] = VMResult{
+			Id:            scvmmMachine.Spec.Id,
+			VMId:          "guid-" + vmName,
+			Name:          vmName,
+			Status:        "Running",
+			Hostname:      vmName + ".example.com",
+			VMHost:        "hv01",
+			IPv4Addresses: []string{address},
+		}
+		objects = append(objects, machine, scvmmMachine)
+	}
+
+	env := newFakeEnv(t, objects...)
+	env.scvmm.vms = vms
+	r := &ScvmmMachineReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+	return env, r, pool
+}
+
+// Reconciling the moved objects must find the existing VMs, and not create or rename any.
+func TestReconcileAfterClusterctlMove(t *testing.T) {
+	ctx := context.Background()
+	env, r, pool := clusterctlMoveSetup(t, true)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "scvmm-node03"}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	for _, call := range env.scvmm.called() {
+		if call != "GetVM" {
+			t.Errorf("unexpected call to %s after move, calls: %v", call, env.scvmm.called())
+		}
+	}
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "scvmm-node03"}, scvmmMachine); err != nil {
+		t.Fatal(err)
+	}
+	if scvmmMachine.Spec.VMName != "node03" || scvmmMachine.Spec.Id != "id-node03" {
+		t.Errorf("vm was renamed or replaced: vmName %s, id %s", scvmmMachine.Spec.VMName, scvmmMachine.Spec.Id)
+	}
+	if !conditions.IsTrue(scvmmMachine, VmCreated) || !conditions.IsTrue(scvmmMachine, VmRunning) {
+		t.Errorf("conditions not rebuilt: %v", scvmmMachine.Status.Conditions)
+	}
+	if !scvmmMachine.Status.Ready {
+		t.Errorf("machine is not ready")
+	}
+
+	if err := env.client.Get(ctx, client.ObjectKeyFromObject(pool), pool); err != nil {
+		t.Fatal(err)
+	}
+	wantOwners := map[string]string{"node01": "scvmm-node01", "node03": "scvmm-node03"}
+	if len(pool.Status.VMNameOwners) != len(wantOwners) {
+		t.Errorf("pool owners = %v, want %v", pool.Status.VMNameOwners, wantOwners)
+	}
+	for vmName, owner := range wantOwners {
+		if pool.Status.VMNameOwners[vmName] != owner {
+			t.Errorf("pool owner of %s = %q, want %q", vmName, pool.Status.VMNameOwners[vmName], owner)
+		}
+	}
+	if counts := pool.Status.Counts; counts == nil || counts.Used != 2 || counts.Free != 7 {
+		t.Errorf("pool counts = %+v, want 2 used and 7 free", counts)
+	}
+}
+
+// A name pool that is not moved (yet) does not keep the machines from being reconciled
+func TestReconcileAfterClusterctlMoveWithoutPool(t *testing.T) {
+	ctx := context.Background()
+	env, r, _ := clusterctlMoveSetup(t, false)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "scvmm-node03"}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if count := env.scvmm.calledCount("GetVM"); count == 0 {
+		t.Errorf("vm not reconciled without the name pool, calls: %v", env.scvmm.called())
+	}
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "scvmm-node03"}, scvmmMachine); err != nil {
+		t.Fatal(err)
+	}
+	if scvmmMachine.Spec.VMName != "node03" || !scvmmMachine.Status.Ready {
+		t.Errorf("machine not reconciled: vmName %s, ready %v", scvmmMachine.Spec.VMName, scvmmMachine.Status.Ready)
+	}
+}
+
+// clusterctl move follows the owner references, so the claims move along with their machine
+func TestIPAddressClaimOwnedByMachine(t *testing.T) {
+	ctx := context.Background()
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "scvmm-node01", UID: "machine-uid"},
+		Spec: infrav1.ScvmmMachineSpec{
+			VMName:     "node01",
+			Networking: &infrav1.Networking{Domain: "example.com"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme(t)).Build()
+	apiGroup := "ipam.cluster.x-k8s.io"
+	claim, _, err := createOrPatchIPAddressClaim(ctx, fakeClient, scvmmMachine, "scvmm-node01-0-0",
+		corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "InClusterIPPool", Name: "v4"})
+	if err != nil {
+		t.Fatalf("createOrPatchIPAddressClaim() error = %v", err)
+	}
+	owners := claim.GetOwnerReferences()
+	if len(owners) != 1 || owners[0].Kind != "ScvmmMachine" || owners[0].Name != scvmmMachine.Name || owners[0].UID != scvmmMachine.UID {
+		t.Errorf("claim owners = %v, want the ScvmmMachine", owners)
+	}
+}
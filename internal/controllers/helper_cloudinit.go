@@ -17,15 +17,19 @@ limitations under the License.
 package controllers
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/go-logr/logr"
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"net"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/hirochachacha/go-smb2"
@@ -37,7 +41,55 @@ type CloudInitFile struct {
 }
 
 type CloudInitFilesystemHandler struct {
-	Writer func(*smb2.File, []CloudInitFile) (int, error)
+	// Writes the files to a filesystem image with the given volume label
+	// Filenames can contain slashes to put them in subdirectories
+	Writer func(fh io.Writer, label string, files []CloudInitFile) (int, error)
+}
+
+// A directory in the cloud-init filesystem image
+type cloudInitDir struct {
+	Name   string
+	Parent *cloudInitDir
+	Dirs   []*cloudInitDir
+	Files  []*CloudInitFile
+}
+
+// Build the directory tree of the cloud-init files
+// Returns all directories, the root first and every directory before its subdirectories
+func cloudInitTree(files []CloudInitFile) []*cloudInitDir {
+	root := &cloudInitDir{}
+	dirs := []*cloudInitDir{root}
+	for i := range files {
+		parts := strings.Split(files[i].Filename, "/")
+		cur := root
+		for _, name := range parts[:len(parts)-1] {
+			var sub *cloudInitDir
+			for _, d := range cur.Dirs {
+				if d.Name == name {
+					sub = d
+					break
+				}
+			}
+			if sub == nil {
+				sub = &cloudInitDir{Name: name, Parent: cur}
+				cur.Dirs = append(cur.Dirs, sub)
+			}
+			cur = sub
+		}
+		cur.Files = append(cur.Files, &files[i])
+	}
+	// Breadth first, so parents come before their children
+	// Subdirectories are sorted by name, in the order of the iso9660 path table
+	for i := 0; i < len(dirs); i++ {
+		sort.Slice(dirs[i].Dirs, func(a, b int) bool { return dirs[i].Dirs[a].Name < dirs[i].Dirs[b].Name })
+		dirs = append(dirs, dirs[i].Dirs...)
+	}
+	return dirs
+}
+
+// The name of the file within its directory
+func (cif *CloudInitFile) baseName() string {
+	return cif.Filename[strings.LastIndex(cif.Filename, "/")+1:]
 }
 
 var cloudInitDeviceTypeExtensions = map[string]string{
@@ -50,14 +102,26 @@ var cloudInitDeviceTypeExtensions = map[string]string{
 
 var FilesystemHandlers = make(map[string]CloudInitFilesystemHandler)
 
+// Cloud-init files are named <vmname>_cloud-init.<extension>
+const cloudInitSuffix = "_cloud-init."
+
 func cloudInitPath(ctx context.Context, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) (string, error) {
 	extension, ok := cloudInitDeviceTypeExtensions[provider.CloudInit.DeviceType]
 	if !ok {
 		return "", fmt.Errorf("Unknown devicetype " + provider.CloudInit.DeviceType)
 	}
+	share, err := cloudInitShare(ctx, scvmmMachine.Spec.ProviderRef, provider)
+	if err != nil {
+		return "", err
+	}
+	return share + "\\" + scvmmMachine.Spec.VMName + cloudInitSuffix + extension, nil
+}
+
+// The directory on the library share where the cloud-init files are put
+func cloudInitShare(ctx context.Context, providerRef *infrav1.ScvmmProviderReference, provider *infrav1.ScvmmProviderSpec) (string, error) {
 	share := provider.CloudInit.LibraryShare
 	if !strings.HasPrefix(share, "\\\\") {
-		res, err := sendWinrmCommand(ctrl.LoggerFrom(ctx), scvmmMachine.Spec.ProviderRef, "GetLibraryShare")
+		res, err := sendWinrmCommand(ctrl.LoggerFrom(ctx), providerRef, "GetLibraryShare")
 		if err != nil {
 			return "", err
 		}
@@ -66,26 +130,22 @@ func cloudInitPath(ctx context.Context, provider *infrav1.ScvmmProviderSpec, scv
 		}
 		share = res.Result + "\\" + share
 	}
-	return share + "\\" + scvmmMachine.Spec.VMName + "_cloud-init." + extension, nil
+	return share, nil
 }
 
-func writeCloudInit(log logr.Logger, scvmmMachine *infrav1.ScvmmMachine, provider *infrav1.ScvmmProviderSpec, machineid string, sharePath string, bootstrapData, metaData, networkConfig []byte) error {
-	log.V(1).Info("Writing cloud-init", "sharePath", sharePath)
-	// Parse share path into hostname, sharename, path
-	shareParts := strings.Split(sharePath, "\\")
-	if len(shareParts) < 5 || shareParts[0] != "" || shareParts[1] != "" {
-		return fmt.Errorf("malformed library share path " + sharePath)
+// Connect to the library share over smb
+// Returns the mounted share, the path of the file within the share, and a function to close the connection
+func mountLibraryShare(log logr.Logger, provider *infrav1.ScvmmProviderSpec, sharePath string) (*smb2.Share, string, func(), error) {
+	host, share, path, err := parseLibrarySharePath(sharePath)
+	if err != nil {
+		return nil, "", nil, err
 	}
-	host := shareParts[2]
-	share := shareParts[3]
-	path := strings.Join(shareParts[4:], "/")
 
 	log.V(1).Info("smb2 Connecting", "host", host, "port", 445)
 	conn, err := net.Dial("tcp", host+":445")
 	if err != nil {
-		return err
+		return nil, "", nil, err
 	}
-	defer conn.Close()
 	userParts := strings.Split(provider.ScvmmUsername, "\\")
 
 	smbCreds := &smb2.NTLMInitiator{
@@ -101,66 +161,209 @@ func writeCloudInit(log logr.Logger, scvmmMachine *infrav1.ScvmmMachine, provide
 	log.V(1).Info("smb2 Dialing", "user", provider.ScvmmUsername)
 	s, err := d.Dial(conn)
 	if err != nil {
-		return err
+		conn.Close()
+		return nil, "", nil, err
 	}
-	defer s.Logoff()
 
 	log.V(1).Info("smb2 Mounting share", "share", share)
 	fs, err := s.Mount(share)
+	if err != nil {
+		s.Logoff()
+		conn.Close()
+		return nil, "", nil, err
+	}
+	return fs, path, func() {
+		fs.Umount()
+		s.Logoff()
+		conn.Close()
+	}, nil
+}
+
+// Parse share path into hostname, sharename, path
+// The path is empty for the root of the share, like \\host\share
+func parseLibrarySharePath(sharePath string) (string, string, string, error) {
+	shareParts := strings.Split(sharePath, "\\")
+	if len(shareParts) < 4 || shareParts[0] != "" || shareParts[1] != "" || shareParts[2] == "" || shareParts[3] == "" {
+		return "", "", "", fmt.Errorf("malformed library share path " + sharePath)
+	}
+	return shareParts[2], shareParts[3], strings.Trim(strings.Join(shareParts[4:], "/"), "/"), nil
+}
+
+// Path of a file in a directory of the share, which is empty for the root
+func sharePathJoin(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// Delete the cloud-init file from the library share, if it is there
+func removeCloudInit(log logr.Logger, provider *infrav1.ScvmmProviderSpec, sharePath string) error {
+	log.V(1).Info("Removing cloud-init", "sharePath", sharePath)
+	fs, path, closeShare, err := mountLibraryShare(log, provider, sharePath)
 	if err != nil {
 		return err
 	}
-	defer fs.Umount()
+	defer closeShare()
+	log.V(1).Info("smb2 Removing file", "path", path)
+	if err := fs.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func writeCloudInit(log logr.Logger, scvmmMachine *infrav1.ScvmmMachine, provider *infrav1.ScvmmProviderSpec, vm VMResult, sharePath string, format string, bootstrapData, metaData, networkConfig []byte, domainJoin string) error {
+	log.V(1).Info("Writing cloud-init", "sharePath", sharePath, "format", format)
+	var label string
+	var files []CloudInitFile
+	switch {
+	case scvmmMachine.Spec.OSType == infrav1.OSTypeWindows:
+		if format != BootstrapFormatCloudConfig {
+			return fmt.Errorf("unsupported bootstrap data format %s for windows", format)
+		}
+		var err error
+		label = configDriveLabel
+		files, err = cloudbaseInitFiles(scvmmMachine, vm.VMId, bootstrapData, domainJoin)
+		if err != nil {
+			return err
+		}
+	case format == BootstrapFormatCloudConfig && provider.CloudInit.Datasource == infrav1.DatasourceConfigDrive:
+		var err error
+		label = configDriveLabel
+		files, err = configDriveFiles(scvmmMachine, vm, bootstrapData)
+		if err != nil {
+			return err
+		}
+	case format == BootstrapFormatCloudConfig:
+		var err error
+		label = "cidata"
+		files, err = noCloudFiles(scvmmMachine, vm.VMId, vm.MACAddresses, bootstrapData, metaData, networkConfig)
+		if err != nil {
+			return err
+		}
+	case format == BootstrapFormatIgnition:
+		config, err := ignitionConfig(scvmmMachine, vm.MACAddresses, bootstrapData)
+		if err != nil {
+			return err
+		}
+		label = configDriveLabel
+		files = []CloudInitFile{{configDriveDir + "user_data", config}}
+	default:
+		return fmt.Errorf("unsupported bootstrap data format %s", format)
+	}
+	handler, ok := FilesystemHandlers[provider.CloudInit.FileSystem]
+	if !ok {
+		return fmt.Errorf("Unknown filesystem " + provider.CloudInit.FileSystem)
+	}
+
+	fs, path, closeShare, err := mountLibraryShare(log, provider, sharePath)
+	if err != nil {
+		return err
+	}
+	defer closeShare()
 	log.V(1).Info("smb2 Creating file", "path", path)
 	fh, err := fs.Create(path)
 	if err != nil {
 		return err
 	}
-	networking := scvmmMachine.Spec.Networking
+	log.V(1).Info("smb2 Writing cloud-init", "path", path)
+	size, err := handler.Writer(fh, label, files)
+	if err != nil {
+		log.Error(err, "Writing cloud-init file", "sharePath", sharePath)
+		fh.Close()
+		fs.Remove(path)
+		return err
+	}
+	if provider.CloudInit.DeviceType == "scsi" || provider.CloudInit.DeviceType == "ide" {
+		log.V(1).Info("smb2 add vhd footer")
+		if err := writeVHDFooter(fh, size); err != nil {
+			log.Error(err, "Writing cloud-init file", "sharePath", sharePath)
+			fh.Close()
+			fs.Remove(path)
+			return err
+		}
+	}
+	log.V(1).Info("smb2 Closing file")
+	fh.Close()
+	return nil
+}
+
+// The hostname of the machine, with the networking domain when there is networking
+func machineHostname(scvmmMachine *infrav1.ScvmmMachine) (string, error) {
+	hostname := scvmmMachine.Spec.VMName
+	if networking := scvmmMachine.Spec.Networking; networking != nil {
+		if networking.Domain == "" {
+			return "", fmt.Errorf("missing required parameter networking.Domain")
+		}
+		hostname = hostname + "." + networking.Domain
+	}
+	return hostname, nil
+}
+
+// Whether an ip address (with or without prefix) is an IPv6 address
+func isIPv6(address string) bool {
+	return strings.Contains(address, ":")
+}
+
+// A rule that sends the traffic from a source address through a routing table
+type routingPolicy struct {
+	From  string `json:"from"`
+	Table int    `json:"table"`
+}
+
+// The source-based routing rules that the routes with a source address need
+func routingPolicies(routes []infrav1.NetworkRoute) ([]routingPolicy, error) {
+	var policies []routingPolicy
+	for _, route := range routes {
+		if route.From == "" {
+			continue
+		}
+		if route.Table == 0 {
+			return nil, fmt.Errorf("route to %s from %s has no routing table", route.To, route.From)
+		}
+		policy := routingPolicy{From: route.From, Table: route.Table}
+		if !slices.Contains(policies, policy) {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// The MAC address of a network device, from the spec or else as assigned by SCVMM
+// Returns nothing when it is not known yet (dynamic MAC addresses are assigned at first boot)
+func deviceMACAddress(nwd infrav1.NetworkDevice, slot int, macAddresses []string) string {
+	mac := nwd.MACAddress
+	if mac == "" && slot < len(macAddresses) {
+		mac = macAddresses[slot]
+	}
+	mac = strings.ToLower(strings.ReplaceAll(mac, "-", ":"))
+	if mac == "00:00:00:00:00:00" {
+		return ""
+	}
+	return mac
+}
+
+// The NoCloud meta-data, user-data and network-config files
+func noCloudFiles(scvmmMachine *infrav1.ScvmmMachine, machineid string, macAddresses []string, bootstrapData, metaData, networkConfig []byte) ([]CloudInitFile, error) {
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
 	if metaData == nil {
-		hostname := scvmmMachine.Spec.VMName
-		domainname := ""
-		if networking != nil {
-			if networking.Domain == "" {
-				return fmt.Errorf("missing required parameter networking.Domain")
-			}
-			domainname = "." + networking.Domain
+		hostname, err := machineHostname(scvmmMachine)
+		if err != nil {
+			return nil, err
 		}
 		data := "instance-id: " + machineid + "\n" +
-			"hostname: " + hostname + domainname + "\n" +
-			"local-hostname: " + hostname + domainname + "\n"
+			"hostname: " + hostname + "\n" +
+			"local-hostname: " + hostname + "\n"
 		metaData = []byte(data)
 	}
-	if networkConfig == nil {
-		if networking != nil && networking.Devices != nil {
-			var data bytes.Buffer
-			data.WriteString("version: 2\n" +
-				"ethernets:\n")
-			for slot, nwd := range networking.Devices {
-				devicename := nwd.DeviceName
-				if devicename == "" {
-					devicename = fmt.Sprintf("eth%d", slot)
-				}
-				data.WriteString("  " + devicename + ":\n")
-				if len(nwd.IPAddresses) > 0 {
-					data.WriteString("    addresses:\n" +
-						"    - " + strings.Join(nwd.IPAddresses, "\n    - ") + "\n")
-				}
-				if nwd.Gateway != "" {
-					data.WriteString("    gateway4: " + nwd.Gateway + "\n")
-				}
-				if len(nwd.Nameservers) > 0 {
-					data.WriteString("    nameservers:\n" +
-						"      addresses:\n" +
-						"      - " + strings.Join(nwd.Nameservers, "\n      - ") + "\n")
-					if len(nwd.SearchDomains) > 0 {
-						data.WriteString("      search:\n" +
-							"      - " + strings.Join(nwd.SearchDomains, "\n      -") + "\n")
-					}
-				}
-			}
-
-			networkConfig = data.Bytes()
+	if networkConfig == nil && networking != nil && networking.Devices != nil {
+		var err error
+		networkConfig, err = netplanNetworkConfig(networking, macAddresses)
+		if err != nil {
+			return nil, err
 		}
 	}
 	numFiles := 2
@@ -182,28 +385,5 @@ func writeCloudInit(log logr.Logger, scvmmMachine *infrav1.ScvmmMachine, provide
 			networkConfig,
 		}
 	}
-	log.V(1).Info("smb2 Writing cloud-init", "path", path)
-	handler, ok := FilesystemHandlers[provider.CloudInit.FileSystem]
-	if !ok {
-		return fmt.Errorf("Unknown filesystem " + provider.CloudInit.FileSystem)
-	}
-	size, err := handler.Writer(fh, files)
-	if err != nil {
-		log.Error(err, "Writing cloud-init file", "host", host, "share", share, "path", path)
-		fh.Close()
-		fs.Remove(path)
-		return err
-	}
-	if provider.CloudInit.DeviceType == "scsi" || provider.CloudInit.DeviceType == "ide" {
-		log.V(1).Info("smb2 add vhd footer")
-		if err := writeVHDFooter(fh, size); err != nil {
-			log.Error(err, "Writing cloud-init file", "host", host, "share", share, "path", path)
-			fh.Close()
-			fs.Remove(path)
-			return err
-		}
-	}
-	log.V(1).Info("smb2 Closing file")
-	fh.Close()
-	return nil
+	return files, nil
 }
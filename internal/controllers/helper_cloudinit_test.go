@@ -0,0 +1,60 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+)
+
+func TestParseLibrarySharePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		sharePath string
+		host      string
+		share     string
+		path      string
+		wantErr   bool
+	}{
+		{name: "file in share", sharePath: `\\lib01\MSSCVMMLibrary\ISOs\cloud-init\vm01-cloud-init.iso`, host: "lib01", share: "MSSCVMMLibrary", path: "ISOs/cloud-init/vm01-cloud-init.iso"},
+		{name: "directory", sharePath: `\\lib01\MSSCVMMLibrary\cloud-init`, host: "lib01", share: "MSSCVMMLibrary", path: "cloud-init"},
+		{name: "share root", sharePath: `\\lib01\cloud-init`, host: "lib01", share: "cloud-init", path: ""},
+		{name: "share root with trailing slash", sharePath: `\\lib01\cloud-init\`, host: "lib01", share: "cloud-init", path: ""},
+		{name: "no share", sharePath: `\\lib01`, wantErr: true},
+		{name: "empty share", sharePath: `\\lib01\`, wantErr: true},
+		{name: "not unc", sharePath: `ISOs\cloud-init`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, share, path, err := parseLibrarySharePath(tt.sharePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLibrarySharePath(%q) error = %v, wantErr %v", tt.sharePath, err, tt.wantErr)
+			}
+			if host != tt.host || share != tt.share || path != tt.path {
+				t.Errorf("parseLibrarySharePath(%q) = %q, %q, %q, want %q, %q, %q", tt.sharePath, host, share, path, tt.host, tt.share, tt.path)
+			}
+		})
+	}
+}
+
+func TestSharePathJoin(t *testing.T) {
+	if got := sharePathJoin("", "vm01-cloud-init.iso"); got != "vm01-cloud-init.iso" {
+		t.Errorf("sharePathJoin in root = %q", got)
+	}
+	if got := sharePathJoin("ISOs/cloud-init", "vm01-cloud-init.iso"); got != "ISOs/cloud-init/vm01-cloud-init.iso" {
+		t.Errorf("sharePathJoin in directory = %q", got)
+	}
+}
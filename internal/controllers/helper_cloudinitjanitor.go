@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	defaultJanitorInterval = time.Hour
+	defaultJanitorMinAge   = time.Hour * 24
+)
+
+var (
+	janitorFiles = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "scvmm",
+			Subsystem: "cloudinit_janitor",
+			Name:      "files",
+			Help:      "Number of cloud-init files on the library share, by state (owned, orphaned, recent)",
+		},
+		[]string{"provider", "state"},
+	)
+	janitorRemoved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "scvmm",
+			Subsystem: "cloudinit_janitor",
+			Name:      "removed_total",
+			Help:      "Number of orphaned cloud-init files removed from the library share",
+		},
+		[]string{"provider"},
+	)
+	janitorErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "scvmm",
+			Subsystem: "cloudinit_janitor",
+			Name:      "errors_total",
+			Help:      "Number of failed janitor runs",
+		},
+		[]string{"provider"},
+	)
+
+	// When the janitor last ran per provider, kept in memory so the provider itself doesn't change
+	janitorLastRun = make(map[infrav1.ScvmmProviderReference]time.Time)
+)
+
+func init() {
+	metrics.Registry.MustRegister(janitorFiles, janitorRemoved, janitorErrors)
+}
+
+func janitorInterval(janitor *infrav1.CloudInitJanitor) time.Duration {
+	if janitor.Interval == nil || janitor.Interval.Duration <= 0 {
+		return defaultJanitorInterval
+	}
+	return janitor.Interval.Duration
+}
+
+// Remove cloud-init files from the library share that don't belong to any ScvmmMachine
+// All machines are checked, not just the ones of this provider, because providers could share a library
+func runCloudInitJanitor(ctx context.Context, c client.Client, providerRef infrav1.ScvmmProviderReference, provider *infrav1.ScvmmProviderSpec) error {
+	log := ctrl.LoggerFrom(ctx)
+	janitor := provider.CloudInit.Janitor
+	minAge := defaultJanitorMinAge
+	if janitor.MinAge != nil {
+		minAge = janitor.MinAge.Duration
+	}
+	providerName := providerRef.Namespace + "/" + providerRef.Name
+
+	share, err := cloudInitShare(ctx, &providerRef, provider)
+	if err != nil {
+		return err
+	}
+	scvmmMachines := &infrav1.ScvmmMachineList{}
+	if err := c.List(ctx, scvmmMachines); err != nil {
+		return errors.Wrap(err, "list scvmmmachines")
+	}
+	owned := make(map[string]bool)
+	for _, m := range scvmmMachines.Items {
+		if m.Spec.VMName != "" {
+			owned[strings.ToLower(m.Spec.VMName)] = true
+		}
+	}
+
+	fs, path, closeShare, err := mountLibraryShare(log, provider, share)
+	if err != nil {
+		return err
+	}
+	defer closeShare()
+	entries, err := fs.ReadDir(path)
+	if err != nil {
+		return errors.Wrap(err, "read cloud-init directory")
+	}
+	counts := map[string]int{"owned": 0, "orphaned": 0, "recent": 0}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		vmName, _, found := strings.Cut(strings.ToLower(entry.Name()), cloudInitSuffix)
+		if !found {
+			continue
+		}
+		if owned[vmName] {
+			counts["owned"]++
+			continue
+		}
+		if time.Since(entry.ModTime()) < minAge {
+			counts["recent"]++
+			continue
+		}
+		counts["orphaned"]++
+		if janitor.DryRun {
+			log.Info("Orphaned cloud-init file", "file", entry.Name(), "modified", entry.ModTime())
+			continue
+		}
+		log.Info("Removing orphaned cloud-init file", "file", entry.Name(), "modified", entry.ModTime())
+		if err := fs.Remove(sharePathJoin(path, entry.Name())); err != nil {
+			log.Error(err, "Failed to remove orphaned cloud-init file", "file", entry.Name())
+			continue
+		}
+		janitorRemoved.WithLabelValues(providerName).Inc()
+	}
+	for state, count := range counts {
+		janitorFiles.WithLabelValues(providerName, state).Set(float64(count))
+	}
+	log.V(1).Info("Janitor done", "share", share, "counts", counts, "dryrun", janitor.DryRun)
+	return nil
+}
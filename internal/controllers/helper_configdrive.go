@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Openstack style config drive, read by ignition and cloudbase-init
+const (
+	configDriveLabel = "config-2"
+	configDriveDir   = "openstack/latest/"
+)
+
+// The cloud-init config drive files: meta_data.json, user_data and network_data.json
+func configDriveFiles(scvmmMachine *infrav1.ScvmmMachine, vm VMResult, bootstrapData []byte) ([]CloudInitFile, error) {
+	metaData, err := configDriveMetaData(scvmmMachine, vm.VMId)
+	if err != nil {
+		return nil, err
+	}
+	files := []CloudInitFile{
+		{configDriveDir + "meta_data.json", metaData},
+		{configDriveDir + "user_data", bootstrapData},
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	if networking != nil && len(networking.Devices) > 0 {
+		networkData, err := configDriveNetworkData(networking, vm.MACAddresses)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, CloudInitFile{configDriveDir + "network_data.json", networkData})
+	}
+	return files, nil
+}
+
+// Render the meta_data.json of the config drive
+func configDriveMetaData(scvmmMachine *infrav1.ScvmmMachine, machineid string) ([]byte, error) {
+	hostname, err := machineHostname(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"uuid":         machineid,
+		"name":         scvmmMachine.Spec.VMName,
+		"hostname":     hostname,
+		"launch_index": 0,
+	})
+}
+
+// Render the network_data.json of the config drive
+// Links get the mac address of the network adapter, when it is known
+// Bonds get the mac address of their first device, and vlans that of their link
+// Search domains are not part of the format, so they are left out
+func configDriveNetworkData(networking *infrav1.Networking, macAddresses []string) ([]byte, error) {
+	data := &configDriveNetwork{
+		Links:    []map[string]interface{}{},
+		Networks: []map[string]interface{}{},
+		Services: []map[string]interface{}{},
+		seenDNS:  map[string]bool{},
+	}
+	linkMACs := make(map[string]string)
+	devices := make(map[string]bool)
+	bonds := make(map[string]bool)
+	bondMembers := make(map[string]bool)
+	for _, bond := range networking.Bonds {
+		for _, member := range bond.Interfaces {
+			bondMembers[member] = true
+		}
+	}
+	for slot, nwd := range networking.Devices {
+		devicename := networkDeviceName(nwd, slot)
+		link := map[string]interface{}{
+			"id":   devicename,
+			"name": devicename,
+			"type": "phy",
+		}
+		if mac := deviceMACAddress(nwd, slot, macAddresses); mac != "" {
+			link["ethernet_mac_address"] = mac
+			linkMACs[devicename] = mac
+		}
+		if nwd.MTU > 0 {
+			link["mtu"] = nwd.MTU
+		}
+		data.Links = append(data.Links, link)
+		devices[devicename] = true
+		if bondMembers[devicename] {
+			if len(nwd.IPAddresses) > 0 || len(nwd.Routes) > 0 || nwd.Gateway != "" || nwd.Gateway6 != "" {
+				return nil, fmt.Errorf("network device %s is in a bond, so its addresses and routes belong on the bond", devicename)
+			}
+			continue
+		}
+		if err := data.addNetworks(devicename, nwd.NetworkAddressing); err != nil {
+			return nil, err
+		}
+	}
+	for _, bond := range networking.Bonds {
+		for _, member := range bond.Interfaces {
+			if !devices[member] {
+				return nil, fmt.Errorf("bond %s has unknown interface %s", bond.Name, member)
+			}
+		}
+		link := map[string]interface{}{
+			"id":         bond.Name,
+			"name":       bond.Name,
+			"type":       "bond",
+			"bond_links": bond.Interfaces,
+		}
+		if bond.Mode != "" {
+			link["bond_mode"] = bond.Mode
+		}
+		if bond.MIIMonitorInterval != nil {
+			link["bond_miimon"] = *bond.MIIMonitorInterval
+		}
+		if mac := linkMACs[bond.Interfaces[0]]; mac != "" {
+			link["ethernet_mac_address"] = mac
+			linkMACs[bond.Name] = mac
+		}
+		if bond.MTU > 0 {
+			link["mtu"] = bond.MTU
+		}
+		data.Links = append(data.Links, link)
+		bonds[bond.Name] = true
+		if err := data.addNetworks(bond.Name, bond.NetworkAddressing); err != nil {
+			return nil, err
+		}
+	}
+	for _, vlan := range networking.VLANs {
+		if !devices[vlan.Link] && !bonds[vlan.Link] {
+			return nil, fmt.Errorf("vlan %s has unknown link %s", vlan.Name, vlan.Link)
+		}
+		link := map[string]interface{}{
+			"id":        vlan.Name,
+			"name":      vlan.Name,
+			"type":      "vlan",
+			"vlan_link": vlan.Link,
+			"vlan_id":   vlan.ID,
+		}
+		if mac := linkMACs[vlan.Link]; mac != "" {
+			link["vlan_mac_address"] = mac
+		}
+		if vlan.MTU > 0 {
+			link["mtu"] = vlan.MTU
+		}
+		data.Links = append(data.Links, link)
+		if err := data.addNetworks(vlan.Name, vlan.NetworkAddressing); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// The links, networks and services of the network_data.json
+type configDriveNetwork struct {
+	Links    []map[string]interface{} `json:"links"`
+	Networks []map[string]interface{} `json:"networks"`
+	Services []map[string]interface{} `json:"services"`
+	seenDNS  map[string]bool
+}
+
+// Add the networks of the addressing of a link, and its nameservers to the services
+func (data *configDriveNetwork) addNetworks(linkname string, addressing infrav1.NetworkAddressing) error {
+	for _, route := range addressing.Routes {
+		if route.From != "" {
+			return fmt.Errorf("route to %s from %s of %s: source-based routing is not supported by the config drive", route.To, route.From, linkname)
+		}
+		if route.Via == "" {
+			return fmt.Errorf("route to %s of %s has no gateway, which the config drive requires", route.To, linkname)
+		}
+	}
+	if len(addressing.IPAddresses) == 0 {
+		data.Networks = append(data.Networks, map[string]interface{}{
+			"id":   fmt.Sprintf("network%d", len(data.Networks)),
+			"type": "ipv4_dhcp",
+			"link": linkname,
+		})
+	}
+	// The routes go with the first address of their family
+	gateways := map[string]string{"ipv4": addressing.Gateway, "ipv6": addressing.Gateway6}
+	routedFamily := map[string]bool{}
+	for _, address := range addressing.IPAddresses {
+		ip, ipnet, err := net.ParseCIDR(address)
+		if err != nil {
+			return fmt.Errorf("ip address %s of %s is not in CIDR notation: %w", address, linkname, err)
+		}
+		family, anyNetwork := "ipv4", "0.0.0.0"
+		if isIPv6(address) {
+			family, anyNetwork = "ipv6", "::"
+		}
+		network := map[string]interface{}{
+			"id":         fmt.Sprintf("network%d", len(data.Networks)),
+			"type":       family,
+			"link":       linkname,
+			"ip_address": ip.String(),
+			"netmask":    net.IP(ipnet.Mask).String(),
+		}
+		if !routedFamily[family] {
+			routedFamily[family] = true
+			routes := []map[string]interface{}{}
+			if gateways[family] != "" {
+				routes = append(routes, map[string]interface{}{
+					"network": anyNetwork,
+					"netmask": anyNetwork,
+					"gateway": gateways[family],
+				})
+			}
+			for _, route := range addressing.Routes {
+				if isIPv6(route.To) != (family == "ipv6") {
+					continue
+				}
+				_, routenet, err := net.ParseCIDR(route.To)
+				if err != nil {
+					return fmt.Errorf("route %s of %s is not in CIDR notation: %w", route.To, linkname, err)
+				}
+				routes = append(routes, map[string]interface{}{
+					"network": routenet.IP.String(),
+					"netmask": net.IP(routenet.Mask).String(),
+					"gateway": route.Via,
+				})
+			}
+			if len(routes) > 0 {
+				network["routes"] = routes
+			}
+		}
+		if len(addressing.Nameservers) > 0 {
+			network["dns_nameservers"] = addressing.Nameservers
+		}
+		data.Networks = append(data.Networks, network)
+	}
+	for _, nameserver := range addressing.Nameservers {
+		if !data.seenDNS[nameserver] {
+			data.seenDNS[nameserver] = true
+			data.Services = append(data.Services, map[string]interface{}{
+				"type":    "dns",
+				"address": nameserver,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestConfigDriveNetworkData(t *testing.T) {
+	tests := []struct {
+		name         string
+		networking   *infrav1.Networking
+		macAddresses []string
+		want         string
+	}{
+		{
+			name: "dhcp",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+			},
+			want: `{
+				"links": [{"id": "eth0", "name": "eth0", "type": "phy"}],
+				"networks": [{"id": "network0", "type": "ipv4_dhcp", "link": "eth0"}],
+				"services": []
+			}`,
+		},
+		{
+			name: "static",
+			networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.0.10/24", "10.0.0.11/24"},
+						Gateway:     "10.0.0.1",
+						Nameservers: []string{"10.0.0.53"},
+					},
+				}, {
+					DeviceName: "eth1",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.1.0.10/16"},
+						Nameservers: []string{"10.0.0.53"},
+					},
+				}},
+			},
+			macAddresses: []string{"00:15:5D:01:02:03", "00:00:00:00:00:00"},
+			want: `{
+				"links": [
+					{"id": "eth0", "name": "eth0", "type": "phy", "ethernet_mac_address": "00:15:5d:01:02:03"},
+					{"id": "eth1", "name": "eth1", "type": "phy"}
+				],
+				"networks": [
+					{"id": "network0", "type": "ipv4", "link": "eth0", "ip_address": "10.0.0.10", "netmask": "255.255.255.0",
+						"routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.0.0.1"}],
+						"dns_nameservers": ["10.0.0.53"]},
+					{"id": "network1", "type": "ipv4", "link": "eth0", "ip_address": "10.0.0.11", "netmask": "255.255.255.0",
+						"dns_nameservers": ["10.0.0.53"]},
+					{"id": "network2", "type": "ipv4", "link": "eth1", "ip_address": "10.1.0.10", "netmask": "255.255.0.0",
+						"dns_nameservers": ["10.0.0.53"]}
+				],
+				"services": [{"type": "dns", "address": "10.0.0.53"}]
+			}`,
+		},
+		{
+			name: "bond and vlan",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{
+					{DeviceName: "eth0", MACAddress: "00:15:5d:01:02:03"},
+					{DeviceName: "eth1"},
+				},
+				Bonds: []infrav1.NetworkBond{{
+					Name:       "bond0",
+					Interfaces: []string{"eth0", "eth1"},
+					Mode:       "active-backup",
+				}},
+				VLANs: []infrav1.NetworkVLAN{{
+					Name: "vlan10",
+					ID:   10,
+					Link: "bond0",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.10.0.10/24"},
+						Gateway:     "10.10.0.1",
+					},
+				}},
+			},
+			want: `{
+				"links": [
+					{"id": "eth0", "name": "eth0", "type": "phy", "ethernet_mac_address": "00:15:5d:01:02:03"},
+					{"id": "eth1", "name": "eth1", "type": "phy"},
+					{"id": "bond0", "name": "bond0", "type": "bond", "bond_links": ["eth0", "eth1"],
+						"bond_mode": "active-backup", "ethernet_mac_address": "00:15:5d:01:02:03"},
+					{"id": "vlan10", "name": "vlan10", "type": "vlan", "vlan_link": "bond0", "vlan_id": 10,
+						"vlan_mac_address": "00:15:5d:01:02:03"}
+				],
+				"networks": [
+					{"id": "network0", "type": "ipv4_dhcp", "link": "bond0"},
+					{"id": "network1", "type": "ipv4", "link": "vlan10", "ip_address": "10.10.0.10", "netmask": "255.255.255.0",
+						"routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.10.0.1"}]}
+				],
+				"services": []
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := configDriveNetworkData(tt.networking, tt.macAddresses)
+			if err != nil {
+				t.Fatalf("configDriveNetworkData() error: %v", err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("network_data.json is not valid json: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("configDriveNetworkData() = %s", data)
+			}
+		})
+	}
+}
+
+func TestConfigDriveNetworkDataErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		networking *infrav1.Networking
+	}{
+		{
+			name: "address without prefix",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName:        "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{IPAddresses: []string{"10.0.0.10"}},
+				}},
+			},
+		},
+		{
+			name: "unknown bond interface",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				Bonds:   []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0", "eth1"}}},
+			},
+		},
+		{
+			name: "unknown vlan link",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				VLANs:   []infrav1.NetworkVLAN{{Name: "vlan10", ID: 10, Link: "bond0"}},
+			},
+		},
+		{
+			name: "addresses on a bond member",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName:        "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{IPAddresses: []string{"10.0.0.10/24"}},
+				}},
+				Bonds: []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0"}}},
+			},
+		},
+		{
+			name: "route without gateway",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.0.10/24"},
+						Routes:      []infrav1.NetworkRoute{{To: "10.1.0.0/16"}},
+					},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := configDriveNetworkData(tt.networking, nil); err == nil {
+				t.Errorf("configDriveNetworkData() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	// A/AAAA and PTR records of the machine are registered
+	DNSRegistered clusterv1.ConditionType = "DNSRegistered"
+
+	DNSRegistrationFailedReason = "DNSRegistrationFailed"
+	DNSRecordsRemovedReason     = "DNSRecordsRemoved"
+)
+
+// The dns settings for the machine: the provider settings, overridden by the ones of the machine
+// Returns nil when the machine should not get dns records
+func dnsRegistration(provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) *infrav1.DNSRegistration {
+	var dns *infrav1.DNSRegistration
+	if provider.DNS != nil {
+		dns = provider.DNS.DeepCopy()
+	}
+	if override := scvmmMachine.Spec.DNS; override != nil {
+		if dns == nil {
+			dns = &infrav1.DNSRegistration{}
+		}
+		dns.Disabled = override.Disabled
+		if override.Server != "" {
+			dns.Server = override.Server
+		}
+		if override.Zone != "" {
+			dns.Zone = override.Zone
+		}
+		if override.TTL != nil {
+			dns.TTL = override.TTL
+		}
+		dns.SkipPTR = dns.SkipPTR || override.SkipPTR
+	}
+	if dns == nil || dns.Disabled {
+		return nil
+	}
+	if dns.Server == "" {
+		dns.Server = provider.ADServer
+	}
+	return dns
+}
+
+// The records to register for the machine
+// The addresses are the configured ones (from the spec and the ip address claims),
+// or the ones the VM reports when it has no static addresses.
+func dnsRecords(dns *infrav1.DNSRegistration, scvmmMachine *infrav1.ScvmmMachine) (*infrav1.DNSRecordsStatus, error) {
+	if dns == nil {
+		return nil, nil
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	zone := dns.Zone
+	if zone == "" && networking != nil {
+		zone = networking.Domain
+	}
+	if zone == "" {
+		return nil, errors.New("no dns zone, set dns.zone or networking.domain")
+	}
+	records := &infrav1.DNSRecordsStatus{
+		Server:    dns.Server,
+		Zone:      strings.TrimSuffix(zone, "."),
+		Name:      scvmmMachine.Spec.VMName,
+		Addresses: []string{},
+	}
+	add := func(address string) error {
+		address, _, _ = strings.Cut(address, "/")
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return errors.Wrapf(err, "invalid address %s", address)
+		}
+		if addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+			return nil
+		}
+		if !slices.Contains(records.Addresses, addr.String()) {
+			records.Addresses = append(records.Addresses, addr.String())
+		}
+		return nil
+	}
+	if networking != nil {
+		addressings := []infrav1.NetworkAddressing{}
+		for _, device := range networking.Devices {
+			addressings = append(addressings, device.NetworkAddressing)
+		}
+		for _, bond := range networking.Bonds {
+			addressings = append(addressings, bond.NetworkAddressing)
+		}
+		for _, vlan := range networking.VLANs {
+			addressings = append(addressings, vlan.NetworkAddressing)
+		}
+		for _, addressing := range addressings {
+			for _, address := range addressing.IPAddresses {
+				if err := add(address); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if len(records.Addresses) == 0 {
+		for _, address := range scvmmMachine.Status.Addresses {
+			if address.Type == clusterv1.MachineInternalIP {
+				if err := add(address.Address); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	// The VM does not always report its addresses in the same order
+	slices.Sort(records.Addresses)
+	return records, nil
+}
+
+// Register the addresses of the machine in dns, when the provider or the machine has dns settings
+// Records that were registered before under another name, zone or server are removed
+func (r *ScvmmMachineReconciler) reconcileDNS(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	provider, err := getProvider(scvmmMachine.Spec.ProviderRef)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	dns := dnsRegistration(provider, scvmmMachine)
+	records, err := dnsRecords(dns, scvmmMachine)
+	if err != nil {
+		// Retrying will not help until the spec changes
+		conditions.MarkFalse(scvmmMachine, DNSRegistered, DNSRegistrationFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, DNSRegistrationFailedReason, "%v", err)
+		return ctrl.Result{}, patchScvmmMachine(ctx, patchHelper, scvmmMachine)
+	}
+	registered := scvmmMachine.Status.DNS
+	if registered != nil && (records == nil || registered.Server != records.Server || registered.Zone != records.Zone || registered.Name != records.Name) {
+		log.Info("Removing DNS records", "name", registered.Name, "zone", registered.Zone)
+		if err := removeDNSRecords(ctx, scvmmMachine); err != nil {
+			return r.dnsFailed(ctx, patchHelper, scvmmMachine, err)
+		}
+		scvmmMachine.Status.DNS = nil
+		if records == nil {
+			conditions.Delete(scvmmMachine, DNSRegistered)
+			r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, DNSRecordsRemovedReason, "Removed DNS records %s.%s", registered.Name, registered.Zone)
+			return ctrl.Result{}, patchScvmmMachine(ctx, patchHelper, scvmmMachine)
+		}
+	}
+	if records == nil || len(records.Addresses) == 0 {
+		return ctrl.Result{}, nil
+	}
+	if equality.Semantic.DeepEqual(records, scvmmMachine.Status.DNS) && conditions.IsTrue(scvmmMachine, DNSRegistered) {
+		return ctrl.Result{}, nil
+	}
+	ttl := 0
+	if dns.TTL != nil {
+		ttl = int(dns.TTL.Seconds())
+	}
+	log.Info("Registering DNS records", "name", records.Name, "zone", records.Zone, "addresses", records.Addresses)
+	res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "AddDNSRecords -Server '%s' -Zone '%s' -Name '%s' -Addresses @(%s) -TTL %d -PTR $%t",
+		escapeSingleQuotes(records.Server),
+		escapeSingleQuotes(records.Zone),
+		escapeSingleQuotes(records.Name),
+		escapeSingleQuotesArray(records.Addresses),
+		ttl,
+		!dns.SkipPTR)
+	if err != nil {
+		return r.dnsFailed(ctx, patchHelper, scvmmMachine, err)
+	}
+	scvmmMachine.Status.DNS = records
+	conditions.MarkTrue(scvmmMachine, DNSRegistered)
+	r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, string(DNSRegistered), "%s", res.Message)
+	return ctrl.Result{}, patchScvmmMachine(ctx, patchHelper, scvmmMachine)
+}
+
+// Failing dns does not make the machine unusable, so it only gets a warning and is retried
+func (r *ScvmmMachineReconciler) dnsFailed(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine, err error) (ctrl.Result, error) {
+	conditions.MarkFalse(scvmmMachine, DNSRegistered, DNSRegistrationFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+	r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, DNSRegistrationFailedReason, "%v", err)
+	if perr := patchScvmmMachine(ctx, patchHelper, scvmmMachine); perr != nil {
+		return ctrl.Result{}, perr
+	}
+	scriptError := &ScriptError{}
+	if !errors.As(err, &scriptError) {
+		return ctrl.Result{}, err
+	}
+	// Requeue script errors after 60 seconds to give the dns server a breather
+	return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+}
+
+// Remove the dns records that were registered for the machine
+func removeDNSRecords(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) error {
+	registered := scvmmMachine.Status.DNS
+	if registered == nil {
+		return nil
+	}
+	_, err := sendWinrmCommand(ctrl.LoggerFrom(ctx), scvmmMachine.Spec.ProviderRef, "RemoveDNSRecords -Server '%s' -Zone '%s' -Name '%s' -Addresses @(%s)",
+		escapeSingleQuotes(registered.Server),
+		escapeSingleQuotes(registered.Zone),
+		escapeSingleQuotes(registered.Name),
+		escapeSingleQuotesArray(registered.Addresses))
+	return err
+}
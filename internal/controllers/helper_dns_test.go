@@ -0,0 +1,129 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestDNSRegistration(t *testing.T) {
+	ttl := &metav1.Duration{Duration: time.Hour}
+	tests := []struct {
+		name     string
+		provider *infrav1.DNSRegistration
+		machine  *infrav1.DNSRegistration
+		want     *infrav1.DNSRegistration
+	}{
+		{
+			name: "no dns settings",
+		},
+		{
+			name:     "provider settings with ad server",
+			provider: &infrav1.DNSRegistration{Zone: "example.com"},
+			want:     &infrav1.DNSRegistration{Server: "dc01", Zone: "example.com"},
+		},
+		{
+			name:     "machine overrides provider",
+			provider: &infrav1.DNSRegistration{Server: "dns01", Zone: "example.com", SkipPTR: true},
+			machine:  &infrav1.DNSRegistration{Zone: "lab.example.com", TTL: ttl},
+			want:     &infrav1.DNSRegistration{Server: "dns01", Zone: "lab.example.com", TTL: ttl, SkipPTR: true},
+		},
+		{
+			name:     "machine opts out",
+			provider: &infrav1.DNSRegistration{Zone: "example.com"},
+			machine:  &infrav1.DNSRegistration{Disabled: true},
+		},
+		{
+			name:    "machine only",
+			machine: &infrav1.DNSRegistration{Server: "dns01"},
+			want:    &infrav1.DNSRegistration{Server: "dns01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &infrav1.ScvmmProviderSpec{ADServer: "dc01", DNS: tt.provider}
+			scvmmMachine := &infrav1.ScvmmMachine{Spec: infrav1.ScvmmMachineSpec{DNS: tt.machine}}
+			if got := dnsRegistration(provider, scvmmMachine); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dnsRegistration() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSRecords(t *testing.T) {
+	dns := &infrav1.DNSRegistration{Server: "dns01"}
+	reported := []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineInternalIP, Address: "fe80::1"},
+		{Type: clusterv1.MachineInternalIP, Address: "10.0.0.20"},
+		{Type: clusterv1.MachineInternalIP, Address: "2001:DB8::20"},
+	}
+
+	// Configured addresses win over the reported ones
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{
+			VMName: "web01",
+			Networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					VMNetwork:         "net",
+					NetworkAddressing: infrav1.NetworkAddressing{IPAddresses: []string{"10.0.0.10/24"}},
+				}},
+				VLANs: []infrav1.NetworkVLAN{{
+					Name: "vlan20", ID: 20, Link: "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{IPAddresses: []string{"10.0.20.10/24"}},
+				}},
+			},
+		},
+		Status: infrav1.ScvmmMachineStatus{Addresses: reported},
+	}
+	records, err := dnsRecords(dns, scvmmMachine)
+	if err != nil {
+		t.Fatalf("dnsRecords() error = %v", err)
+	}
+	want := &infrav1.DNSRecordsStatus{Server: "dns01", Zone: "example.com", Name: "web01", Addresses: []string{"10.0.0.10", "10.0.20.10"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("dnsRecords() = %+v, want %+v", records, want)
+	}
+
+	// Without static addresses, the reported ones are used, without link-local addresses
+	scvmmMachine.Spec.Networking.Devices[0].IPAddresses = nil
+	scvmmMachine.Spec.Networking.VLANs = nil
+	records, err = dnsRecords(dns, scvmmMachine)
+	if err != nil {
+		t.Fatalf("dnsRecords() error = %v", err)
+	}
+	want.Addresses = []string{"10.0.0.20", "2001:db8::20"}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("dnsRecords() = %+v, want %+v", records, want)
+	}
+
+	if records, err := dnsRecords(nil, scvmmMachine); records != nil || err != nil {
+		t.Errorf("dnsRecords(nil) = %+v, %v, want nothing", records, err)
+	}
+
+	scvmmMachine.Spec.Networking = nil
+	if _, err := dnsRecords(dns, scvmmMachine); err == nil {
+		t.Errorf("dnsRecords() without zone did not fail")
+	}
+}
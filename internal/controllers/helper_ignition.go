@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Bootstrap data formats, as set in the format key of the bootstrap data secret
+const (
+	BootstrapFormatCloudConfig = "cloud-config"
+	BootstrapFormatIgnition    = "ignition"
+)
+
+// Ignition reads its config from the user_data on an openstack style config drive
+// This is supported by both Flatcar and Fedora CoreOS
+//
+// Add the hostname and static network configuration of the machine to the ignition bootstrap data
+// The files are added to the config itself instead of merged, because merging needs a reachable url
+func ignitionConfig(scvmmMachine *infrav1.ScvmmMachine, macAddresses []string, bootstrapData []byte) ([]byte, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(bootstrapData, &config); err != nil {
+		return nil, fmt.Errorf("bootstrap data is not a valid ignition config: %w", err)
+	}
+	ignition, _ := config["ignition"].(map[string]interface{})
+	version, _ := ignition["version"].(string)
+	if version == "" {
+		return nil, fmt.Errorf("bootstrap data is missing ignition.version")
+	}
+	// Ignition 2.x needs the filesystem, 3.x refuses to overwrite existing files without overwrite
+	legacy := strings.HasPrefix(version, "2.")
+
+	hostname, err := machineHostname(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{
+		"/etc/hostname": hostname + "\n",
+	}
+	if networking != nil {
+		if err := ignitionNetworkFiles(files, networking, macAddresses); err != nil {
+			return nil, err
+		}
+	}
+
+	storage, _ := config["storage"].(map[string]interface{})
+	if storage == nil {
+		storage = make(map[string]interface{})
+		config["storage"] = storage
+	}
+	// Ignition 3.x refuses a config with two entries for one path, so ours replace those of the bootstrap data
+	var storageFiles []interface{}
+	existingFiles, _ := storage["files"].([]interface{})
+	for _, existing := range existingFiles {
+		file, _ := existing.(map[string]interface{})
+		if path, _ := file["path"].(string); path != "" {
+			if _, replaced := files[path]; replaced {
+				continue
+			}
+		}
+		storageFiles = append(storageFiles, existing)
+	}
+	// Sorted, to keep the generated config stable
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		file := map[string]interface{}{
+			"path": path,
+			"mode": 0644,
+			"contents": map[string]interface{}{
+				"source": "data:;base64," + base64.StdEncoding.EncodeToString([]byte(files[path])),
+			},
+		}
+		if legacy {
+			file["filesystem"] = "root"
+		} else {
+			file["overwrite"] = true
+		}
+		storageFiles = append(storageFiles, file)
+	}
+	storage["files"] = storageFiles
+	return json.Marshal(config)
+}
+
+// Add the systemd-networkd files for the networking spec
+// Devices are matched on mac address when it is known (with a link file that gives them their name), and on name otherwise
+// Bonds and vlans get a netdev file, and are added to the network file of their members or link
+func ignitionNetworkFiles(files map[string]string, networking *infrav1.Networking, macAddresses []string) error {
+	const networkDir = "/etc/systemd/network/"
+	bondOf := make(map[string]string)
+	vlansOf := make(map[string][]string)
+	names := make(map[string]bool)
+	for slot, nwd := range networking.Devices {
+		names[networkDeviceName(nwd, slot)] = true
+	}
+	for _, bond := range networking.Bonds {
+		for _, member := range bond.Interfaces {
+			if !names[member] {
+				return fmt.Errorf("bond %s has unknown interface %s", bond.Name, member)
+			}
+			bondOf[member] = bond.Name
+		}
+	}
+	for _, bond := range networking.Bonds {
+		names[bond.Name] = true
+	}
+	for _, vlan := range networking.VLANs {
+		if !names[vlan.Link] {
+			return fmt.Errorf("vlan %s has unknown link %s", vlan.Name, vlan.Link)
+		}
+		vlansOf[vlan.Link] = append(vlansOf[vlan.Link], vlan.Name)
+	}
+
+	for slot, nwd := range networking.Devices {
+		devicename := networkDeviceName(nwd, slot)
+		prefix := fmt.Sprintf("%s%02d-%s", networkDir, slot, devicename)
+		match := "Name=" + devicename
+		if mac := deviceMACAddress(nwd, slot, macAddresses); mac != "" {
+			match = "MACAddress=" + mac
+			files[prefix+".link"] = "[Match]\n" + match + "\n\n[Link]\nName=" + devicename + "\n"
+		}
+		unit, err := ignitionNetworkUnit(devicename, match, nwd.NetworkAddressing, bondOf[devicename], vlansOf[devicename])
+		if err != nil {
+			return err
+		}
+		files[prefix+".network"] = unit
+	}
+	unitNumber := len(networking.Devices)
+	for _, bond := range networking.Bonds {
+		prefix := fmt.Sprintf("%s%02d-%s", networkDir, unitNumber, bond.Name)
+		unitNumber++
+		netdev := "[NetDev]\nName=" + bond.Name + "\nKind=bond\n\n[Bond]\n"
+		if bond.Mode != "" {
+			netdev += "Mode=" + bond.Mode + "\n"
+		}
+		if bond.MIIMonitorInterval != nil {
+			netdev += fmt.Sprintf("MIIMonitorSec=%dms\n", *bond.MIIMonitorInterval)
+		}
+		files[prefix+".netdev"] = netdev
+		unit, err := ignitionNetworkUnit(bond.Name, "Name="+bond.Name, bond.NetworkAddressing, "", vlansOf[bond.Name])
+		if err != nil {
+			return err
+		}
+		files[prefix+".network"] = unit
+	}
+	for _, vlan := range networking.VLANs {
+		prefix := fmt.Sprintf("%s%02d-%s", networkDir, unitNumber, vlan.Name)
+		unitNumber++
+		files[prefix+".netdev"] = fmt.Sprintf("[NetDev]\nName=%s\nKind=vlan\n\n[VLAN]\nId=%d\n", vlan.Name, vlan.ID)
+		unit, err := ignitionNetworkUnit(vlan.Name, "Name="+vlan.Name, vlan.NetworkAddressing, "", nil)
+		if err != nil {
+			return err
+		}
+		files[prefix+".network"] = unit
+	}
+	return nil
+}
+
+// Render a systemd-networkd unit for an interface
+// Bond members only get their bond and mtu, because the addresses go on the bond
+// Routes with a source address go in their own routing table, with a rule for the source
+func ignitionNetworkUnit(name, match string, addressing infrav1.NetworkAddressing, bond string, vlans []string) (string, error) {
+	var unit strings.Builder
+	unit.WriteString("[Match]\n" + match + "\n\n[Network]\n")
+	if bond != "" {
+		if len(addressing.IPAddresses) > 0 || len(addressing.Routes) > 0 || addressing.Gateway != "" || addressing.Gateway6 != "" {
+			return "", fmt.Errorf("network device %s is in bond %s, so its addresses and routes belong on the bond", name, bond)
+		}
+		unit.WriteString("Bond=" + bond + "\n")
+	} else if len(addressing.IPAddresses) == 0 {
+		unit.WriteString("DHCP=yes\n")
+	}
+	for _, vlan := range vlans {
+		unit.WriteString("VLAN=" + vlan + "\n")
+	}
+	for _, address := range addressing.IPAddresses {
+		unit.WriteString("Address=" + address + "\n")
+	}
+	if addressing.Gateway != "" {
+		unit.WriteString("Gateway=" + addressing.Gateway + "\n")
+	}
+	if addressing.Gateway6 != "" {
+		unit.WriteString("Gateway=" + addressing.Gateway6 + "\n")
+	}
+	for _, nameserver := range addressing.Nameservers {
+		unit.WriteString("DNS=" + nameserver + "\n")
+	}
+	if len(addressing.SearchDomains) > 0 {
+		unit.WriteString("Domains=" + strings.Join(addressing.SearchDomains, " ") + "\n")
+	}
+	if addressing.MTU > 0 {
+		unit.WriteString(fmt.Sprintf("\n[Link]\nMTUBytes=%d\n", addressing.MTU))
+	}
+	policies, err := routingPolicies(addressing.Routes)
+	if err != nil {
+		return "", fmt.Errorf("network device %s: %w", name, err)
+	}
+	for _, route := range addressing.Routes {
+		unit.WriteString("\n[Route]\nDestination=" + route.To + "\n")
+		if route.Via != "" {
+			unit.WriteString("Gateway=" + route.Via + "\n")
+		}
+		if route.Metric != nil {
+			unit.WriteString(fmt.Sprintf("Metric=%d\n", *route.Metric))
+		}
+		if route.Table != 0 {
+			unit.WriteString(fmt.Sprintf("Table=%d\n", route.Table))
+		}
+	}
+	for _, policy := range policies {
+		unit.WriteString(fmt.Sprintf("\n[RoutingPolicyRule]\nFrom=%s\nTable=%d\n", policy.From, policy.Table))
+	}
+	return unit.String(), nil
+}
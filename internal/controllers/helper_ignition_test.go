@@ -0,0 +1,243 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// The storage.files of an ignition config, by path
+func ignitionFiles(t *testing.T, config []byte) map[string]map[string]interface{} {
+	t.Helper()
+	var parsed struct {
+		Storage struct {
+			Files []map[string]interface{} `json:"files"`
+		} `json:"storage"`
+	}
+	if err := json.Unmarshal(config, &parsed); err != nil {
+		t.Fatalf("ignition config is not valid json: %v", err)
+	}
+	files := make(map[string]map[string]interface{})
+	for _, file := range parsed.Storage.Files {
+		path, _ := file["path"].(string)
+		if _, exists := files[path]; exists {
+			t.Errorf("ignition config has more than one entry for %s", path)
+		}
+		files[path] = file
+	}
+	return files
+}
+
+// The decoded contents of an ignition file entry
+func ignitionFileContents(t *testing.T, file map[string]interface{}) string {
+	t.Helper()
+	contents, _ := file["contents"].(map[string]interface{})
+	source, _ := contents["source"].(string)
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(source, "data:;base64,"))
+	if err != nil {
+		t.Fatalf("contents of %s are not a base64 data url: %v", file["path"], err)
+	}
+	return string(data)
+}
+
+func TestIgnitionConfig(t *testing.T) {
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{VMName: "test-vm"},
+	}
+	tests := []struct {
+		name      string
+		bootstrap string
+		// Expected filesystem (2.x) or overwrite (3.x) key of the added files
+		key   string
+		value interface{}
+	}{
+		{
+			name:      "ignition 2.x",
+			bootstrap: `{"ignition":{"version":"2.3.0"}}`,
+			key:       "filesystem",
+			value:     "root",
+		},
+		{
+			name:      "ignition 3.x",
+			bootstrap: `{"ignition":{"version":"3.3.0"}}`,
+			key:       "overwrite",
+			value:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ignitionConfig(scvmmMachine, nil, []byte(tt.bootstrap))
+			if err != nil {
+				t.Fatalf("ignitionConfig() error: %v", err)
+			}
+			hostname := ignitionFiles(t, config)["/etc/hostname"]
+			if hostname == nil {
+				t.Fatalf("ignition config has no /etc/hostname: %s", config)
+			}
+			if got := ignitionFileContents(t, hostname); got != "test-vm\n" {
+				t.Errorf("/etc/hostname = %q, want %q", got, "test-vm\n")
+			}
+			if got := hostname[tt.key]; got != tt.value {
+				t.Errorf("/etc/hostname %s = %v, want %v", tt.key, got, tt.value)
+			}
+		})
+	}
+}
+
+func TestIgnitionConfigMerge(t *testing.T) {
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{VMName: "test-vm"},
+	}
+	bootstrap := `{
+		"ignition": {"version": "3.3.0"},
+		"passwd": {"users": [{"name": "core"}]},
+		"storage": {"files": [
+			{"path": "/etc/hostname", "contents": {"source": "data:,bootstrap"}},
+			{"path": "/etc/kubeadm.yml", "contents": {"source": "data:,kubeadm"}}
+		]}
+	}`
+	config, err := ignitionConfig(scvmmMachine, nil, []byte(bootstrap))
+	if err != nil {
+		t.Fatalf("ignitionConfig() error: %v", err)
+	}
+	files := ignitionFiles(t, config)
+	if len(files) != 2 {
+		t.Errorf("ignition config has %d files, want 2", len(files))
+	}
+	if got := ignitionFileContents(t, files["/etc/hostname"]); got != "test-vm\n" {
+		t.Errorf("/etc/hostname = %q, want the machine hostname", got)
+	}
+	if files["/etc/kubeadm.yml"] == nil {
+		t.Errorf("ignition config lost /etc/kubeadm.yml of the bootstrap data")
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(config, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed["passwd"] == nil {
+		t.Errorf("ignition config lost the passwd section of the bootstrap data")
+	}
+}
+
+func TestIgnitionConfigErrors(t *testing.T) {
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{VMName: "test-vm"},
+	}
+	for _, bootstrap := range []string{`#cloud-config`, `{"storage":{}}`} {
+		if _, err := ignitionConfig(scvmmMachine, nil, []byte(bootstrap)); err == nil {
+			t.Errorf("ignitionConfig(%q) expected an error", bootstrap)
+		}
+	}
+}
+
+func TestIgnitionNetworkFiles(t *testing.T) {
+	miiInterval := 100
+	networking := &infrav1.Networking{
+		Domain: "example.com",
+		Devices: []infrav1.NetworkDevice{
+			{DeviceName: "eth0", MACAddress: "00-15-5D-01-02-03"},
+			{DeviceName: "eth1", NetworkAddressing: infrav1.NetworkAddressing{MTU: 9000}},
+			{DeviceName: "eth2"},
+		},
+		Bonds: []infrav1.NetworkBond{{
+			Name:               "bond0",
+			Interfaces:         []string{"eth0", "eth1"},
+			Mode:               "active-backup",
+			MIIMonitorInterval: &miiInterval,
+			NetworkAddressing: infrav1.NetworkAddressing{
+				IPAddresses: []string{"10.0.0.10/24"},
+				Gateway:     "10.0.0.1",
+			},
+		}},
+		VLANs: []infrav1.NetworkVLAN{{
+			Name: "vlan10",
+			ID:   10,
+			Link: "bond0",
+			NetworkAddressing: infrav1.NetworkAddressing{
+				IPAddresses: []string{"10.10.0.10/24"},
+			},
+		}},
+	}
+	files := make(map[string]string)
+	if err := ignitionNetworkFiles(files, networking, []string{"", "00:15:5d:01:02:04"}); err != nil {
+		t.Fatalf("ignitionNetworkFiles() error: %v", err)
+	}
+	want := map[string]string{
+		"/etc/systemd/network/00-eth0.link":    "[Match]\nMACAddress=00:15:5d:01:02:03\n\n[Link]\nName=eth0\n",
+		"/etc/systemd/network/00-eth0.network": "[Match]\nMACAddress=00:15:5d:01:02:03\n\n[Network]\nBond=bond0\n",
+		"/etc/systemd/network/01-eth1.link":    "[Match]\nMACAddress=00:15:5d:01:02:04\n\n[Link]\nName=eth1\n",
+		"/etc/systemd/network/01-eth1.network": "[Match]\nMACAddress=00:15:5d:01:02:04\n\n[Network]\nBond=bond0\n\n[Link]\nMTUBytes=9000\n",
+		"/etc/systemd/network/02-eth2.network": "[Match]\nName=eth2\n\n[Network]\nDHCP=yes\n",
+		"/etc/systemd/network/03-bond0.netdev": "[NetDev]\nName=bond0\nKind=bond\n\n[Bond]\nMode=active-backup\nMIIMonitorSec=100ms\n",
+		"/etc/systemd/network/03-bond0.network": "[Match]\nName=bond0\n\n[Network]\nVLAN=vlan10\n" +
+			"Address=10.0.0.10/24\nGateway=10.0.0.1\n",
+		"/etc/systemd/network/04-vlan10.netdev":  "[NetDev]\nName=vlan10\nKind=vlan\n\n[VLAN]\nId=10\n",
+		"/etc/systemd/network/04-vlan10.network": "[Match]\nName=vlan10\n\n[Network]\nAddress=10.10.0.10/24\n",
+	}
+	if len(files) != len(want) {
+		t.Errorf("ignitionNetworkFiles() wrote %d files, want %d", len(files), len(want))
+	}
+	for path, contents := range want {
+		if files[path] != contents {
+			t.Errorf("%s = %q, want %q", path, files[path], contents)
+		}
+	}
+}
+
+func TestIgnitionNetworkFilesErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		networking *infrav1.Networking
+	}{
+		{
+			name: "unknown bond interface",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				Bonds:   []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0", "eth1"}}},
+			},
+		},
+		{
+			name: "unknown vlan link",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				VLANs:   []infrav1.NetworkVLAN{{Name: "vlan10", ID: 10, Link: "bond0"}},
+			},
+		},
+		{
+			name: "addresses on a bond member",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName:        "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{IPAddresses: []string{"10.0.0.10/24"}},
+				}},
+				Bonds: []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ignitionNetworkFiles(make(map[string]string), tt.networking, nil); err == nil {
+				t.Errorf("ignitionNetworkFiles() expected an error")
+			}
+		})
+	}
+}
@@ -19,7 +19,9 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
 	"reflect"
+	"slices"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -35,7 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 )
 
 const (
@@ -48,15 +50,18 @@ const (
 	IPAddressClaimFinalizer           = "scvmmmachine.finalizers.cluster.x-k8s.io/ip-claim-protection"
 
 	HostnameAnnotation = "infrastructure.x-k8s.io/hostname"
+
+	// Routing tables for the source-based routing of claimed addresses start here
+	sourceRoutingTableBase = 100
 )
 
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;create;patch;watch;list;update
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmippools,verbs=get;list;watch
 
 // reconcileIPAddressClaims ensures that ScvmmMachines that are configured with
 // .spec.networking.devices.addressFromPools have corresponding IPAddressClaims.
-// If claims are fulfilled, it also fills those in in the spec
-// TODO: Use the status to store fulfilled claims instead of amending the spec
+// Fulfilled claims are recorded in .status.ipAddresses
 func (r *ScvmmMachineReconciler) reconcileIPAddressClaims(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) error {
 	totalClaims, claimsCreated := 0, 0
 	claimsFulfilled := 0
@@ -67,9 +72,16 @@ func (r *ScvmmMachineReconciler) reconcileIPAddressClaims(ctx context.Context, s
 		errList []error
 	)
 
+	// Keep the addresses that were already recorded while a claim is (again) unfulfilled,
+	// like after a clusterctl move which does not keep the claim status
+	previous := map[string]infrav1.IPAddressStatus{}
+	for _, claimed := range scvmmMachine.Status.IPAddresses {
+		previous[claimed.ClaimName] = claimed
+	}
+	var ipAddresses []infrav1.IPAddressStatus
+
 	for devIdx, device := range scvmmMachine.Spec.Networking.Devices {
-		var gateway string
-		addresses := make([]string, len(device.AddressesFromPools))
+		deviceName := networkDeviceName(device, devIdx)
 		for poolRefIdx, poolRef := range device.AddressesFromPools {
 			totalClaims++
 			ipAddrClaimName := fmt.Sprintf("%s-%d-%d", scvmmMachine.Name, devIdx, poolRefIdx)
@@ -105,14 +117,28 @@ func (r *ScvmmMachineReconciler) reconcileIPAddressClaims(ctx context.Context, s
 					errList = append(errList, err)
 					continue
 				}
-				if gateway != "" && gateway != ipAddr.Spec.Gateway {
-					err := fmt.Errorf("Different gateways: %s <> %s", gateway, ipAddr.Spec.Gateway)
-					errList = append(errList, err)
-					continue
+				claimed := infrav1.IPAddressStatus{
+					DeviceName: deviceName,
+					ClaimName:  ipAddrClaimName,
+					Address:    ipAddr.Spec.Address,
+					Prefix:     ipAddr.Spec.Prefix,
+					Gateway:    ipAddr.Spec.Gateway,
+					PoolRef:    poolRef,
+				}
+				// IPAddresses have no dns settings, so take those from the pool when it is our own
+				if isScvmmIPPoolRef(poolRef) {
+					pool := &infrav1.ScvmmIPPool{}
+					if err := r.Client.Get(loopctx, client.ObjectKey{Namespace: scvmmMachine.Namespace, Name: poolRef.Name}, pool); err != nil {
+						errList = append(errList, err)
+						continue
+					}
+					claimed.Nameservers = pool.Status.Nameservers
+					claimed.SearchDomains = pool.Status.SearchDomains
 				}
-				gateway = ipAddr.Spec.Gateway
-				addresses[poolRefIdx] = fmt.Sprintf("%s/%d", ipAddr.Spec.Address, ipAddr.Spec.Prefix)
+				ipAddresses = append(ipAddresses, claimed)
 				claimsFulfilled++
+			} else if claimed, ok := previous[ipAddrClaimName]; ok && reflect.DeepEqual(claimed.PoolRef, poolRef) {
+				ipAddresses = append(ipAddresses, claimed)
 			}
 
 			// Since this is eventually used to calculate the status of the
@@ -121,16 +147,8 @@ func (r *ScvmmMachineReconciler) reconcileIPAddressClaims(ctx context.Context, s
 				claims = append(claims, ipAddrClaim)
 			}
 		}
-		log.V(1).Info("Reconciling addresses, got claims", "gateway", gateway, "addresses", addresses)
-		if gateway != "" {
-			if gateway != device.Gateway {
-				scvmmMachine.Spec.Networking.Devices[devIdx].Gateway = gateway
-			}
-			if !reflect.DeepEqual(device.IPAddresses, addresses) {
-				scvmmMachine.Spec.Networking.Devices[devIdx].IPAddresses = addresses
-			}
-		}
 	}
+	scvmmMachine.Status.IPAddresses = ipAddresses
 
 	if len(errList) > 0 {
 		aggregatedErr := kerrors.NewAggregate(errList)
@@ -262,15 +280,127 @@ func (r *ScvmmMachineReconciler) deleteIPAddressClaims(ctx context.Context, scvm
 	return nil
 }
 
-func hasAllIPAddresses(networking *infrav1.Networking) bool {
-	for _, device := range networking.Devices {
+// The name of a network device, defaulting to eth<slot>
+func networkDeviceName(device infrav1.NetworkDevice, slot int) string {
+	if device.DeviceName == "" {
+		return fmt.Sprintf("eth%d", slot)
+	}
+	return device.DeviceName
+}
+
+// The networking spec with the addresses that were claimed from IPAM pools
+// or granted from SCVMM static IP address pools filled in,
+// and the dns settings of the pools when the device has none
+// The default gateway of each address family comes from the spec, or else from the defaultRoutePool
+// or the first claimed address of that family.  Claimed addresses with another gateway get
+// source-based routing through their own gateway, in a routing table per address.
+func machineNetworking(scvmmMachine *infrav1.ScvmmMachine) (*infrav1.Networking, error) {
+	networking := scvmmMachine.Spec.Networking
+	if networking == nil || (len(scvmmMachine.Status.IPAddresses) == 0 && len(scvmmMachine.Status.NetworkAdapters) == 0) {
+		return networking, nil
+	}
+	networking = networking.DeepCopy()
+	for slot := range networking.Devices {
+		device := &networking.Devices[slot]
+		deviceName := networkDeviceName(*device, slot)
+		if device.StaticIPAddressPool != "" && len(device.IPAddresses) == 0 {
+			applyStaticIPAddresses(device, deviceName, scvmmMachine.Status.NetworkAdapters)
+		}
+		var claims []int
+		for i, claimed := range scvmmMachine.Status.IPAddresses {
+			if claimed.DeviceName == deviceName {
+				claims = append(claims, i)
+			}
+		}
+		if len(claims) == 0 {
+			continue
+		}
+		gateways := map[bool]*string{false: &device.Gateway, true: &device.Gateway6}
+		for _, defaultPool := range []bool{true, false} {
+			for _, i := range claims {
+				claimed := scvmmMachine.Status.IPAddresses[i]
+				if defaultPool && claimed.PoolRef.Name != device.DefaultRoutePool {
+					continue
+				}
+				if gateway := gateways[isIPv6(claimed.Address)]; *gateway == "" {
+					*gateway = claimed.Gateway
+				}
+			}
+		}
+		for _, i := range claims {
+			claimed := scvmmMachine.Status.IPAddresses[i]
+			// Machines from before the status was used have the claimed addresses in the spec
+			address := fmt.Sprintf("%s/%d", claimed.Address, claimed.Prefix)
+			if !slices.Contains(device.IPAddresses, address) {
+				device.IPAddresses = append(device.IPAddresses, address)
+			}
+			if len(device.Nameservers) == 0 {
+				device.Nameservers = claimed.Nameservers
+			}
+			if len(device.SearchDomains) == 0 {
+				device.SearchDomains = claimed.SearchDomains
+			}
+			if claimed.Gateway == "" || claimed.Gateway == *gateways[isIPv6(claimed.Address)] {
+				continue
+			}
+			_, subnet, err := net.ParseCIDR(address)
+			if err != nil {
+				return nil, fmt.Errorf("claimed address %s of %s is invalid: %w", address, deviceName, err)
+			}
+			anyNetwork := "0.0.0.0/0"
+			if isIPv6(claimed.Address) {
+				anyNetwork = "::/0"
+			}
+			table := sourceRoutingTableBase + i
+			device.Routes = append(device.Routes,
+				infrav1.NetworkRoute{To: subnet.String(), From: claimed.Address, Table: table},
+				infrav1.NetworkRoute{To: anyNetwork, Via: claimed.Gateway, From: claimed.Address, Table: table})
+		}
+	}
+	return networking, nil
+}
+
+// Fill in the addresses that SCVMM granted to the adapter of the device from its static IP address pool
+func applyStaticIPAddresses(device *infrav1.NetworkDevice, deviceName string, adapters []infrav1.NetworkAdapterStatus) {
+	for _, adapter := range adapters {
+		if adapter.DeviceName != deviceName || len(adapter.StaticIPAddresses) == 0 {
+			continue
+		}
+		device.IPAddresses = append([]string{}, adapter.StaticIPAddresses...)
 		if device.Gateway == "" {
+			device.Gateway = adapter.StaticGateway
+		}
+		if len(device.Nameservers) == 0 {
+			device.Nameservers = adapter.StaticNameservers
+		}
+		if len(device.SearchDomains) == 0 {
+			device.SearchDomains = adapter.StaticSearchDomains
+		}
+		return
+	}
+}
+
+// Whether all network devices have their addresses and a gateway, and all claims are fulfilled
+func hasAllIPAddresses(scvmmMachine *infrav1.ScvmmMachine) bool {
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return false
+	}
+	if networking == nil {
+		return true
+	}
+	claimed := map[string]int{}
+	for _, address := range scvmmMachine.Status.IPAddresses {
+		claimed[address.DeviceName]++
+	}
+	for slot, device := range networking.Devices {
+		if device.Gateway == "" && device.Gateway6 == "" {
 			return false
 		}
 		if len(device.IPAddresses) == 0 {
 			return false
 		}
-		if len(device.IPAddresses) < len(device.AddressesFromPools) {
+		if claimed[networkDeviceName(device, slot)] < len(device.AddressesFromPools) {
 			return false
 		}
 		for _, address := range device.IPAddresses {
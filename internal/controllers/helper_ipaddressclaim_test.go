@@ -0,0 +1,254 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestMachineNetworking(t *testing.T) {
+	apiGroup := "ipam.cluster.x-k8s.io"
+	pool4 := corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "InClusterIPPool", Name: "v4"}
+	pool6 := corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "InClusterIPPool", Name: "v6"}
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{
+			Networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{
+					{
+						VMNetwork:          "net",
+						AddressesFromPools: []corev1.TypedLocalObjectReference{pool4, pool6},
+					},
+					{
+						DeviceName: "storage",
+						VMNetwork:  "storage",
+						NetworkAddressing: infrav1.NetworkAddressing{
+							IPAddresses: []string{"10.1.0.10/24"},
+							Gateway:     "10.1.0.1",
+						},
+						AddressesFromPools: []corev1.TypedLocalObjectReference{pool4},
+					},
+				},
+			},
+		},
+	}
+	if hasAllIPAddresses(scvmmMachine) {
+		t.Errorf("hasAllIPAddresses() = true without claimed addresses")
+	}
+
+	scvmmMachine.Status.IPAddresses = []infrav1.IPAddressStatus{
+		{DeviceName: "eth0", ClaimName: "m-0-0", Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1", PoolRef: pool4},
+		{DeviceName: "eth0", ClaimName: "m-0-1", Address: "2001:db8::10", Prefix: 64, Gateway: "2001:db8::1", PoolRef: pool6},
+		{DeviceName: "storage", ClaimName: "m-1-0", Address: "10.1.0.10", Prefix: 24, Gateway: "10.1.0.254", PoolRef: pool4},
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		t.Fatalf("machineNetworking() error = %v", err)
+	}
+	want := []infrav1.NetworkAddressing{
+		{IPAddresses: []string{"10.0.0.10/24", "2001:db8::10/64"}, Gateway: "10.0.0.1", Gateway6: "2001:db8::1"},
+		// Addresses and gateways already in the spec are kept, the other gateway gets source-based routing
+		{IPAddresses: []string{"10.1.0.10/24"}, Gateway: "10.1.0.1", Routes: []infrav1.NetworkRoute{
+			{To: "10.1.0.0/24", From: "10.1.0.10", Table: 102},
+			{To: "0.0.0.0/0", Via: "10.1.0.254", From: "10.1.0.10", Table: 102},
+		}},
+	}
+	for i, device := range networking.Devices {
+		if !reflect.DeepEqual(device.NetworkAddressing, want[i]) {
+			t.Errorf("device %d addressing = %+v, want %+v", i, device.NetworkAddressing, want[i])
+		}
+	}
+	if len(scvmmMachine.Spec.Networking.Devices[0].IPAddresses) != 0 {
+		t.Errorf("spec was modified: %+v", scvmmMachine.Spec.Networking.Devices[0])
+	}
+	if !hasAllIPAddresses(scvmmMachine) {
+		t.Errorf("hasAllIPAddresses() = false with all claims fulfilled")
+	}
+
+	scvmmMachine.Status.IPAddresses = scvmmMachine.Status.IPAddresses[1:]
+	if hasAllIPAddresses(scvmmMachine) {
+		t.Errorf("hasAllIPAddresses() = true with an unfulfilled claim")
+	}
+}
+
+func TestMachineNetworkingDefaultRoutePool(t *testing.T) {
+	apiGroup := "ipam.cluster.x-k8s.io"
+	front := corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "InClusterIPPool", Name: "front"}
+	back := corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "InClusterIPPool", Name: "back"}
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{
+			Networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName:         "eth0",
+					VMNetwork:          "net",
+					AddressesFromPools: []corev1.TypedLocalObjectReference{back, front},
+					DefaultRoutePool:   "front",
+				}},
+			},
+		},
+		Status: infrav1.ScvmmMachineStatus{
+			IPAddresses: []infrav1.IPAddressStatus{
+				{DeviceName: "eth0", ClaimName: "m-0-0", Address: "10.1.0.10", Prefix: 24, Gateway: "10.1.0.1", PoolRef: back},
+				{DeviceName: "eth0", ClaimName: "m-0-1", Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1", PoolRef: front},
+			},
+		},
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		t.Fatalf("machineNetworking() error = %v", err)
+	}
+	want := infrav1.NetworkAddressing{
+		IPAddresses: []string{"10.1.0.10/24", "10.0.0.10/24"},
+		Gateway:     "10.0.0.1",
+		Routes: []infrav1.NetworkRoute{
+			{To: "10.1.0.0/24", From: "10.1.0.10", Table: 100},
+			{To: "0.0.0.0/0", Via: "10.1.0.1", From: "10.1.0.10", Table: 100},
+		},
+	}
+	if got := networking.Devices[0].NetworkAddressing; !reflect.DeepEqual(got, want) {
+		t.Errorf("addressing = %+v, want %+v", got, want)
+	}
+}
+
+func TestMachineNetworkingPoolDNS(t *testing.T) {
+	apiGroup := infrav1.GroupVersion.Group
+	pool := corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "ScvmmIPPool", Name: "servers"}
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{
+			Networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{
+					{VMNetwork: "net", AddressesFromPools: []corev1.TypedLocalObjectReference{pool}},
+					{
+						VMNetwork:          "other",
+						AddressesFromPools: []corev1.TypedLocalObjectReference{pool},
+						NetworkAddressing:  infrav1.NetworkAddressing{Nameservers: []string{"10.9.9.9"}},
+					},
+				},
+			},
+		},
+		Status: infrav1.ScvmmMachineStatus{
+			IPAddresses: []infrav1.IPAddressStatus{
+				{DeviceName: "eth0", ClaimName: "m-0-0", Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1", PoolRef: pool,
+					Nameservers: []string{"10.0.0.53"}, SearchDomains: []string{"example.com"}},
+				{DeviceName: "eth1", ClaimName: "m-1-0", Address: "10.0.0.11", Prefix: 24, Gateway: "10.0.0.1", PoolRef: pool,
+					Nameservers: []string{"10.0.0.53"}, SearchDomains: []string{"example.com"}},
+			},
+		},
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		t.Fatalf("machineNetworking() error = %v", err)
+	}
+	if got := networking.Devices[0]; !reflect.DeepEqual(got.Nameservers, []string{"10.0.0.53"}) || !reflect.DeepEqual(got.SearchDomains, []string{"example.com"}) {
+		t.Errorf("device 0 dns = %v %v, want pool dns", got.Nameservers, got.SearchDomains)
+	}
+	// Nameservers in the spec win over the ones from the pool
+	if got := networking.Devices[1].Nameservers; !reflect.DeepEqual(got, []string{"10.9.9.9"}) {
+		t.Errorf("device 1 nameservers = %v, want spec nameservers", got)
+	}
+}
+
+func TestMachineNetworkingStaticIPAddressPool(t *testing.T) {
+	scvmmMachine := &infrav1.ScvmmMachine{
+		Spec: infrav1.ScvmmMachineSpec{
+			Networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{
+					{VMNetwork: "net", StaticIPAddressPool: "servers"},
+					{
+						DeviceName:          "storage",
+						VMNetwork:           "storage",
+						StaticIPAddressPool: "storage",
+						NetworkAddressing:   infrav1.NetworkAddressing{IPAddresses: []string{"10.1.0.10/24"}, Gateway: "10.1.0.1"},
+					},
+				},
+			},
+		},
+	}
+	if hasAllIPAddresses(scvmmMachine) {
+		t.Errorf("hasAllIPAddresses() = true before scvmm granted an address")
+	}
+	scvmmMachine.Status.NetworkAdapters = []infrav1.NetworkAdapterStatus{
+		{DeviceName: "eth0", StaticIPAddresses: []string{"10.0.0.10/24"}, StaticGateway: "10.0.0.1",
+			StaticNameservers: []string{"10.0.0.53"}, StaticSearchDomains: []string{"example.com"}},
+		{DeviceName: "storage", StaticIPAddresses: []string{"10.1.0.99/24"}, StaticGateway: "10.1.0.254"},
+	}
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		t.Fatalf("machineNetworking() error = %v", err)
+	}
+	want := []infrav1.NetworkAddressing{
+		{IPAddresses: []string{"10.0.0.10/24"}, Gateway: "10.0.0.1", Nameservers: []string{"10.0.0.53"}, SearchDomains: []string{"example.com"}},
+		// Addresses in the spec win over the granted ones
+		{IPAddresses: []string{"10.1.0.10/24"}, Gateway: "10.1.0.1"},
+	}
+	for i, device := range networking.Devices {
+		if !reflect.DeepEqual(device.NetworkAddressing, want[i]) {
+			t.Errorf("device %d addressing = %+v, want %+v", i, device.NetworkAddressing, want[i])
+		}
+	}
+	if len(scvmmMachine.Spec.Networking.Devices[0].IPAddresses) != 0 {
+		t.Errorf("spec was modified: %+v", scvmmMachine.Spec.Networking.Devices[0])
+	}
+	if !hasAllIPAddresses(scvmmMachine) {
+		t.Errorf("hasAllIPAddresses() = false with granted addresses")
+	}
+}
+
+func TestClaimGrantID(t *testing.T) {
+	claim := func(namespace, name string) *ipamv1.IPAddressClaim {
+		return &ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	id := claimGrantID(claim("default", "m-0-0"))
+	if again := claimGrantID(claim("default", "m-0-0")); again != id {
+		t.Errorf("claimGrantID() not stable: %s != %s", id, again)
+	}
+	if other := claimGrantID(claim("other", "m-0-0")); other == id {
+		t.Errorf("claimGrantID() same for different namespaces: %s", id)
+	}
+	if other := claimGrantID(claim("default", "m-0-1")); other == id {
+		t.Errorf("claimGrantID() same for different names: %s", id)
+	}
+}
+
+func TestIsScvmmIPPoolRef(t *testing.T) {
+	infraGroup := infrav1.GroupVersion.Group
+	ipamGroup := "ipam.cluster.x-k8s.io"
+	tests := []struct {
+		ref  corev1.TypedLocalObjectReference
+		want bool
+	}{
+		{corev1.TypedLocalObjectReference{APIGroup: &infraGroup, Kind: "ScvmmIPPool", Name: "p"}, true},
+		{corev1.TypedLocalObjectReference{APIGroup: &ipamGroup, Kind: "ScvmmIPPool", Name: "p"}, false},
+		{corev1.TypedLocalObjectReference{APIGroup: &ipamGroup, Kind: "InClusterIPPool", Name: "p"}, false},
+		{corev1.TypedLocalObjectReference{Kind: "ScvmmIPPool", Name: "p"}, false},
+	}
+	for _, tt := range tests {
+		if got := isScvmmIPPoolRef(tt.ref); got != tt.want {
+			t.Errorf("isScvmmIPPoolRef(%+v) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
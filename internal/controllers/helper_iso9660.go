@@ -2,9 +2,10 @@ package controllers
 
 import (
 	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
 	"time"
-
-	"github.com/hirochachacha/go-smb2"
 )
 
 type isoSector []byte
@@ -76,19 +77,61 @@ func (sector isoSector) putDirent(offset int, dirent *isoDirent) int {
 	return offset + totlen
 }
 
-func writeISO9660(fh *smb2.File, files []CloudInitFile) (int, error) {
+// Path table record of a directory, little endian for the L table or big endian for the M table
+// Directories are numbered from 1 in path table order, the root is its own parent
+func (sector isoSector) putPathEntry(offset int, order binary.ByteOrder, location, parent int, identifier string) int {
+	identLen := len(identifier)
+	totlen := (8 + identLen + 1) &^ 1 // Pad to even length
+	if offset+totlen > 2048 {
+		return -1
+	}
+	sector[offset] = byte(identLen)
+	order.PutUint32(sector[offset+2:offset+6], uint32(location))
+	order.PutUint16(sector[offset+6:offset+8], uint16(parent))
+	copy(sector[offset+8:], []byte(identifier))
+	return offset + totlen
+}
+
+func writeISO9660(fh io.Writer, label string, files []CloudInitFile) (int, error) {
 	const sectorSize = 2048
 	sector := make(isoSector, sectorSize)
 	now := time.Now()
 
-	// Calculate the total size
-	// NB: Assumes all files are in the root and the dirent will not exceed one sector
-	// 16,17 = volume identifiers, 18 = directory
-	lastSector := 19
-	for cif := range files {
-		// Round up to sector size
-		fsz := ((len(files[cif].Contents) - 1) / sectorSize) + 1
-		lastSector = lastSector + fsz
+	// Calculate the total size and the location of everything
+	// NB: Assumes every directory and path table fits in one sector
+	// 16,17 = volume identifiers, 18,19 = L and M path tables, 20 = root directory, then the subdirectories
+	dirs := cloudInitTree(files)
+	dirSectors := make(map[*cloudInitDir]int)
+	dirNumbers := make(map[*cloudInitDir]int)
+	lastSector := 20
+	for i, dir := range dirs {
+		dirSectors[dir] = lastSector
+		dirNumbers[dir] = i + 1
+		lastSector = lastSector + 1
+	}
+	fileSectors := make(map[*CloudInitFile]int)
+	for _, dir := range dirs {
+		for _, cif := range dir.Files {
+			fileSectors[cif] = lastSector
+			// Round up to sector size
+			lastSector = lastSector + isoFileSectors(len(cif.Contents), sectorSize)
+		}
+	}
+
+	// The path tables list the directories in the order of cloudInitTree
+	lPathTable := make(isoSector, sectorSize)
+	mPathTable := make(isoSector, sectorSize)
+	pathTableSize := 0
+	for _, dir := range dirs {
+		parent, identifier := dir, string([]byte{0})
+		if dir.Parent != nil {
+			parent, identifier = dir.Parent, dir.Name
+		}
+		lPathTable.putPathEntry(pathTableSize, binary.LittleEndian, dirSectors[dir], dirNumbers[parent], identifier)
+		pathTableSize = mPathTable.putPathEntry(pathTableSize, binary.BigEndian, dirSectors[dir], dirNumbers[parent], identifier)
+		if pathTableSize < 0 {
+			return 0, fmt.Errorf("too many directories")
+		}
 	}
 
 	// Start with 32K of zeroes
@@ -102,12 +145,15 @@ func writeISO9660(fh *smb2.File, files []CloudInitFile) (int, error) {
 	sector.putString(1, 5, "CD001")
 	sector[7] = 1
 	sector.putString(8, 32, "LINUX")                                             // System identifier
-	sector.putString(40, 32, "cidata")                                           // Volume identifier
+	sector.putString(40, 32, label)                                              // Volume identifier
 	sector.putU32(80, uint32(lastSector))                                        // Volume Space Size
 	sector.putU16(120, 1)                                                        // Volume Set Size
 	sector.putU16(124, 1)                                                        // Sequence Number
 	sector.putU16(128, sectorSize)                                               // Logical Block Size
-	sector.putDirent(156, &isoDirent{18, sectorSize, now, 2, string([]byte{0})}) // Root directory entry
+	sector.putU32(132, uint32(pathTableSize))                                    // Path Table Size
+	binary.LittleEndian.PutUint32(sector[140:144], 18)                           // L Path Table
+	binary.BigEndian.PutUint32(sector[148:152], 19)                              // M Path Table
+	sector.putDirent(156, &isoDirent{20, sectorSize, now, 2, string([]byte{0})}) // Root directory entry
 	sector.putString(190, 128, "")                                               // Volume Set
 	sector.putString(318, 128, "")                                               // Publisher
 	sector.putString(446, 128, "")                                               // Data Preparer
@@ -134,42 +180,76 @@ func writeISO9660(fh *smb2.File, files []CloudInitFile) (int, error) {
 	if _, err := fh.Write(sector); err != nil {
 		return 0, err
 	}
-	for i := range sector {
-		sector[i] = 0
-	}
-
-	// Write directory (sector 18)
-	curOff := 0
-	curOff = sector.putDirent(curOff, &isoDirent{18, sectorSize, now, 2, string([]byte{0})}) // Own directory entry
-	curOff = sector.putDirent(curOff, &isoDirent{18, sectorSize, now, 2, string([]byte{1})}) // Parent directory entry
 
-	// Write directory entries
-	fileSector := 19
-	for cif := range files {
-		flen := len(files[cif].Contents)
-		curOff = sector.putDirent(curOff, &isoDirent{fileSector, flen, now, 0, files[cif].Filename + ";1"})
-		fileSector = fileSector + ((flen - 1) / sectorSize) + 1
+	// Write path tables (sectors 18 and 19)
+	if _, err := fh.Write(lPathTable); err != nil {
+		return 0, err
 	}
-	if _, err := fh.Write(sector); err != nil {
+	if _, err := fh.Write(mPathTable); err != nil {
 		return 0, err
 	}
+
+	// Write directories (sector 20 onwards)
+	for _, dir := range dirs {
+		for i := range sector {
+			sector[i] = 0
+		}
+		parent := dir
+		if dir.Parent != nil {
+			parent = dir.Parent
+		}
+		curOff := 0
+		curOff = sector.putDirent(curOff, &isoDirent{dirSectors[dir], sectorSize, now, 2, string([]byte{0})})    // Own directory entry
+		curOff = sector.putDirent(curOff, &isoDirent{dirSectors[parent], sectorSize, now, 2, string([]byte{1})}) // Parent directory entry
+		// The other entries are sorted by identifier
+		var dirents []*isoDirent
+		for _, sub := range dir.Dirs {
+			dirents = append(dirents, &isoDirent{dirSectors[sub], sectorSize, now, 2, sub.Name})
+		}
+		for _, cif := range dir.Files {
+			dirents = append(dirents, &isoDirent{fileSectors[cif], len(cif.Contents), now, 0, cif.baseName() + ";1"})
+		}
+		sort.Slice(dirents, func(a, b int) bool { return dirents[a].Identifier < dirents[b].Identifier })
+		for _, dirent := range dirents {
+			if curOff >= 0 {
+				curOff = sector.putDirent(curOff, dirent)
+			}
+		}
+		if curOff < 0 {
+			return 0, fmt.Errorf("too many files in directory '%s'", dir.Name)
+		}
+		if _, err := fh.Write(sector); err != nil {
+			return 0, err
+		}
+	}
+
 	for i := range sector {
 		sector[i] = 0
 	}
-	for cif := range files {
-		if _, err := fh.Write(files[cif].Contents); err != nil {
-			return 0, err
-		}
-		padlen := (sectorSize - (len(files[cif].Contents) % sectorSize)) % sectorSize
-		if padlen > 0 {
-			if _, err := fh.Write(sector[:padlen]); err != nil {
+	for _, dir := range dirs {
+		for _, cif := range dir.Files {
+			if _, err := fh.Write(cif.Contents); err != nil {
 				return 0, err
 			}
+			padlen := isoFileSectors(len(cif.Contents), sectorSize)*sectorSize - len(cif.Contents)
+			if padlen > 0 {
+				if _, err := fh.Write(sector[:padlen]); err != nil {
+					return 0, err
+				}
+			}
 		}
 	}
 	return sectorSize * lastSector, nil
 }
 
+// Number of sectors taken by a file, at least one
+func isoFileSectors(length, sectorSize int) int {
+	if length == 0 {
+		return 1
+	}
+	return ((length - 1) / sectorSize) + 1
+}
+
 func init() {
 	FilesystemHandlers["iso9660"] = CloudInitFilesystemHandler{
 		Writer: writeISO9660,
@@ -0,0 +1,158 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Cloud-init files in nested directories, with names that are not in sorted order
+var nestedCloudInitFiles = []CloudInitFile{
+	{"openstack/latest/user_data", []byte("#cloud-config\nhostname: test-vm\n")},
+	{"openstack/latest/meta_data.json", []byte(`{"uuid":"1234"}`)},
+	{"openstack/content/0000", bytes.Repeat([]byte("0123456789"), 500)},
+	{"ec2/latest/meta-data.json", []byte(`{}`)},
+	{"empty", []byte{}},
+}
+
+// Check that an image holds exactly the nested cloud-init files
+func checkImageFiles(t *testing.T, got map[string]string) {
+	t.Helper()
+	if len(got) != len(nestedCloudInitFiles) {
+		t.Errorf("image has %d files, want %d: %v", len(got), len(nestedCloudInitFiles), got)
+	}
+	for _, cif := range nestedCloudInitFiles {
+		contents, ok := got[cif.Filename]
+		if !ok {
+			t.Errorf("image is missing %s", cif.Filename)
+		} else if contents != string(cif.Contents) {
+			t.Errorf("contents of %s = %q, want %q", cif.Filename, contents, cif.Contents)
+		}
+	}
+}
+
+type isoPathEntry struct {
+	Location   int
+	Parent     int
+	Identifier string
+}
+
+// Parse an iso9660 path table with the given byte order
+func readISOPathTable(image []byte, order binary.ByteOrder, location, size int) []isoPathEntry {
+	table := image[location*2048 : location*2048+size]
+	var entries []isoPathEntry
+	for off := 0; off < len(table); {
+		identLen := int(table[off])
+		entries = append(entries, isoPathEntry{
+			Location:   int(order.Uint32(table[off+2 : off+6])),
+			Parent:     int(order.Uint16(table[off+6 : off+8])),
+			Identifier: string(table[off+8 : off+8+identLen]),
+		})
+		off += (8 + identLen + 1) &^ 1
+	}
+	return entries
+}
+
+// Read the files of an iso9660 image by path, and the directory locations by path
+func readISO9660(t *testing.T, image []byte, location int, prefix string, files map[string]string, dirs map[string]int) {
+	t.Helper()
+	dirs[prefix] = location
+	sector := image[location*2048 : (location+1)*2048]
+	var identifiers []string
+	for off, record := 0, 0; off < len(sector) && sector[off] != 0; off, record = off+int(sector[off]), record+1 {
+		extent := int(binary.LittleEndian.Uint32(sector[off+2 : off+6]))
+		size := int(binary.LittleEndian.Uint32(sector[off+10 : off+14]))
+		identifier := string(sector[off+33 : off+33+int(sector[off+32])])
+		if record < 2 {
+			// Own and parent directory
+			continue
+		}
+		identifiers = append(identifiers, identifier)
+		if sector[off+25]&2 != 0 {
+			readISO9660(t, image, extent, prefix+identifier+"/", files, dirs)
+		} else {
+			files[prefix+strings.TrimSuffix(identifier, ";1")] = string(image[extent*2048 : extent*2048+size])
+		}
+	}
+	if !sort.StringsAreSorted(identifiers) {
+		t.Errorf("directory records of /%s are not sorted: %v", prefix, identifiers)
+	}
+}
+
+func TestWriteISO9660(t *testing.T) {
+	var buf bytes.Buffer
+	size, err := writeISO9660(&buf, "config-2", nestedCloudInitFiles)
+	if err != nil {
+		t.Fatalf("writeISO9660() error: %v", err)
+	}
+	image := buf.Bytes()
+	if size != len(image) {
+		t.Errorf("writeISO9660() size = %d, wrote %d bytes", size, len(image))
+	}
+	pvd := image[16*2048 : 17*2048]
+	if pvd[0] != 1 || string(pvd[1:6]) != "CD001" {
+		t.Fatalf("sector 16 is not a primary volume descriptor")
+	}
+	if label := strings.TrimSpace(string(pvd[40:72])); label != "config-2" {
+		t.Errorf("volume identifier = %q, want config-2", label)
+	}
+	if blocks := int(binary.LittleEndian.Uint32(pvd[80:84])); blocks*2048 != len(image) {
+		t.Errorf("volume space size = %d sectors, image has %d bytes", blocks, len(image))
+	}
+
+	files := make(map[string]string)
+	dirs := make(map[string]int)
+	readISO9660(t, image, int(binary.LittleEndian.Uint32(pvd[158:162])), "", files, dirs)
+	checkImageFiles(t, files)
+
+	pathTableSize := int(binary.LittleEndian.Uint32(pvd[132:136]))
+	lTable := readISOPathTable(image, binary.LittleEndian, int(binary.LittleEndian.Uint32(pvd[140:144])), pathTableSize)
+	mTable := readISOPathTable(image, binary.BigEndian, int(binary.BigEndian.Uint32(pvd[148:152])), pathTableSize)
+	if len(lTable) != len(dirs) {
+		t.Fatalf("L path table has %d directories, want %d: %v", len(lTable), len(dirs), lTable)
+	}
+	if len(mTable) != len(lTable) {
+		t.Fatalf("M path table has %d directories, L path table %d", len(mTable), len(lTable))
+	}
+	paths := make([]string, len(lTable))
+	for i, entry := range lTable {
+		if entry != mTable[i] {
+			t.Errorf("path table entry %d: L = %v, M = %v", i+1, entry, mTable[i])
+		}
+		if i == 0 {
+			if entry.Parent != 1 || entry.Identifier != "\x00" {
+				t.Errorf("path table entry 1 = %v, want the root directory", entry)
+			}
+			continue
+		}
+		if entry.Parent < 1 || entry.Parent > i {
+			t.Fatalf("path table entry %d = %v, its parent does not come before it", i+1, entry)
+		}
+		previous := lTable[i-1]
+		if i > 1 && (entry.Parent < previous.Parent || (entry.Parent == previous.Parent && entry.Identifier <= previous.Identifier)) {
+			t.Errorf("path table entry %d = %v is not sorted after %v", i+1, entry, previous)
+		}
+		paths[i] = paths[entry.Parent-1] + entry.Identifier + "/"
+		if location, ok := dirs[paths[i]]; !ok || location != entry.Location {
+			t.Errorf("path table has /%s at sector %d, directory records at %d", paths[i], entry.Location, location)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// The hash of everything that makes a machine of the pool outdated when it changes
+func machinePoolTemplateHash(template *infrav1.ScvmmMachineTemplateResource, version, dataSecretName string) (string, error) {
+	templatejson, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New32a()
+	hasher.Write(templatejson)
+	fmt.Fprintf(hasher, "\x00%s\x00%s", version, dataSecretName)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
+
+// Plan one step of scaling and rolling replacement of the machines of a pool
+// Returns the number of machines to create, and the machines to delete
+// Failed machines go first, as they never recover and there is no MachineHealthCheck to replace them.
+// Then outdated machines, then machines that are not ready, then the newest ones.
+// Ready machines are only deleted while enough other machines are ready.
+func planMachinePool(machines []infrav1.ScvmmMachine, templateHash string, desired int, strategy infrav1.ScvmmMachinePoolStrategy) (int, []infrav1.ScvmmMachine) {
+	total, updated, ready := 0, 0, 0
+	for _, m := range machines {
+		if m.Status.FailureReason != nil {
+			continue
+		}
+		total++
+		if m.Labels[MachinePoolTemplateHashLabel] == templateHash {
+			updated++
+		}
+		if m.Status.Ready {
+			ready++
+		}
+	}
+	create := min(desired-updated, desired+int(strategy.MaxSurge)-total)
+	if create < 0 {
+		create = 0
+	}
+
+	candidates := append([]infrav1.ScvmmMachine{}, machines...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if failedA, failedB := a.Status.FailureReason != nil, b.Status.FailureReason != nil; failedA != failedB {
+			return failedA
+		}
+		if outdatedA, outdatedB := a.Labels[MachinePoolTemplateHashLabel] != templateHash, b.Labels[MachinePoolTemplateHashLabel] != templateHash; outdatedA != outdatedB {
+			return outdatedA
+		}
+		if a.Status.Ready != b.Status.Ready {
+			return !a.Status.Ready
+		}
+		return b.CreationTimestamp.Before(&a.CreationTimestamp)
+	})
+	minReady := desired - int(strategy.MaxUnavailable)
+	remove := []infrav1.ScvmmMachine{}
+	for _, m := range candidates {
+		if m.Status.FailureReason != nil {
+			remove = append(remove, m)
+			continue
+		}
+		outdated := m.Labels[MachinePoolTemplateHashLabel] != templateHash
+		// Up-to-date machines are only removed when there are too many of them
+		if !outdated && updated <= desired {
+			break
+		}
+		if m.Status.Ready {
+			if ready-1 < minReady {
+				continue
+			}
+			ready--
+		}
+		if !outdated {
+			updated--
+		}
+		remove = append(remove, m)
+	}
+	return create, remove
+}
+
+// Pick the failure domain with the fewest machines of the pool
+func pickFailureDomain(failureDomains []string, machines []infrav1.ScvmmMachine) string {
+	if len(failureDomains) == 0 {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, m := range machines {
+		counts[m.Labels[FailureDomainLabel]]++
+	}
+	sorted := append([]string{}, failureDomains...)
+	sort.Strings(sorted)
+	picked := sorted[0]
+	for _, fd := range sorted[1:] {
+		if counts[fd] < counts[picked] {
+			picked = fd
+		}
+	}
+	return picked
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func poolMachine(name, hash string, ready bool, age int) infrav1.ScvmmMachine {
+	return infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            map[string]string{MachinePoolTemplateHashLabel: hash},
+			CreationTimestamp: metav1.NewTime(time.Unix(1700000000, 0).Add(-time.Duration(age) * time.Minute)),
+		},
+		Status: infrav1.ScvmmMachineStatus{Ready: ready},
+	}
+}
+
+func failedPoolMachine(name, hash string, age int) infrav1.ScvmmMachine {
+	m := poolMachine(name, hash, true, age)
+	reason := capierrors.CreateMachineError
+	m.Status.FailureReason = &reason
+	return m
+}
+
+func TestPlanMachinePool(t *testing.T) {
+	surge := infrav1.ScvmmMachinePoolStrategy{MaxSurge: 1}
+	tests := []struct {
+		name       string
+		machines   []infrav1.ScvmmMachine
+		desired    int
+		strategy   infrav1.ScvmmMachinePoolStrategy
+		wantCreate int
+		wantRemove []string
+	}{
+		{
+			name:       "scale up from nothing",
+			desired:    3,
+			strategy:   surge,
+			wantCreate: 3,
+			wantRemove: []string{},
+		},
+		{
+			name: "steady state",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "new", true, 3),
+				poolMachine("b", "new", true, 2),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantRemove: []string{},
+		},
+		{
+			name: "scale down removes not ready and newest first",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "new", true, 4),
+				poolMachine("b", "new", true, 3),
+				poolMachine("c", "new", false, 5),
+				poolMachine("d", "new", true, 1),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantRemove: []string{"c", "d"},
+		},
+		{
+			name: "rolling update surges one machine",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", true, 3),
+				poolMachine("b", "old", true, 2),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantCreate: 1,
+			wantRemove: []string{},
+		},
+		{
+			name: "rolling update waits for the new machine",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", true, 3),
+				poolMachine("b", "old", true, 2),
+				poolMachine("c", "new", false, 1),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantRemove: []string{},
+		},
+		{
+			name: "rolling update removes an old machine when the new one is ready",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", true, 3),
+				poolMachine("b", "old", true, 2),
+				poolMachine("c", "new", true, 1),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantRemove: []string{"b"},
+		},
+		{
+			name: "rolling update without surge",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", true, 3),
+				poolMachine("b", "old", true, 2),
+			},
+			desired:    2,
+			strategy:   infrav1.ScvmmMachinePoolStrategy{MaxUnavailable: 1},
+			wantRemove: []string{"b"},
+		},
+		{
+			name: "outdated machines that are not ready go right away",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", false, 3),
+				poolMachine("b", "old", true, 2),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantCreate: 1,
+			wantRemove: []string{"a"},
+		},
+		{
+			name: "failed machines are replaced",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "new", true, 3),
+				failedPoolMachine("b", "new", 2),
+			},
+			desired:    2,
+			strategy:   surge,
+			wantCreate: 1,
+			wantRemove: []string{"b"},
+		},
+		{
+			name: "failed machines go before a rolling update",
+			machines: []infrav1.ScvmmMachine{
+				poolMachine("a", "old", true, 3),
+				failedPoolMachine("b", "old", 2),
+			},
+			desired:    2,
+			strategy:   infrav1.ScvmmMachinePoolStrategy{MaxUnavailable: 1},
+			wantCreate: 1,
+			wantRemove: []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create, remove := planMachinePool(tt.machines, "new", tt.desired, tt.strategy)
+			names := []string{}
+			for _, m := range remove {
+				names = append(names, m.Name)
+			}
+			if create != tt.wantCreate || !reflect.DeepEqual(names, tt.wantRemove) {
+				t.Errorf("planMachinePool() = %d, %v, want %d, %v", create, names, tt.wantCreate, tt.wantRemove)
+			}
+		})
+	}
+}
+
+func TestPickFailureDomain(t *testing.T) {
+	machines := []infrav1.ScvmmMachine{
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{FailureDomainLabel: "fd1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{FailureDomainLabel: "fd2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{FailureDomainLabel: "fd1"}}},
+	}
+	if got := pickFailureDomain([]string{"fd2", "fd1", "fd3"}, machines); got != "fd3" {
+		t.Errorf("pickFailureDomain() = %q, want fd3", got)
+	}
+	if got := pickFailureDomain([]string{"fd1", "fd2"}, machines); got != "fd2" {
+		t.Errorf("pickFailureDomain() = %q, want fd2", got)
+	}
+	if got := pickFailureDomain(nil, machines); got != "" {
+		t.Errorf("pickFailureDomain() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"fmt"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// Netplan v2 network-config, as read by cloud-init
+type netplanConfig struct {
+	Version   int                          `json:"version"`
+	Ethernets map[string]*netplanInterface `json:"ethernets,omitempty"`
+	Bonds     map[string]*netplanInterface `json:"bonds,omitempty"`
+	VLANs     map[string]*netplanInterface `json:"vlans,omitempty"`
+}
+
+type netplanInterface struct {
+	Match       *netplanMatch          `json:"match,omitempty"`
+	SetName     string                 `json:"set-name,omitempty"`
+	Interfaces  []string               `json:"interfaces,omitempty"`
+	Parameters  *netplanBondParameters `json:"parameters,omitempty"`
+	ID          *int                   `json:"id,omitempty"`
+	Link        string                 `json:"link,omitempty"`
+	Addresses   []string               `json:"addresses,omitempty"`
+	Routes      []netplanRoute         `json:"routes,omitempty"`
+	Policies    []routingPolicy        `json:"routing-policy,omitempty"`
+	Nameservers *netplanNameservers    `json:"nameservers,omitempty"`
+	MTU         int                    `json:"mtu,omitempty"`
+}
+
+type netplanMatch struct {
+	MACAddress string `json:"macaddress"`
+}
+
+type netplanBondParameters struct {
+	Mode               string `json:"mode,omitempty"`
+	MIIMonitorInterval *int   `json:"mii-monitor-interval,omitempty"`
+}
+
+type netplanRoute struct {
+	To     string `json:"to"`
+	Via    string `json:"via,omitempty"`
+	Scope  string `json:"scope,omitempty"`
+	Metric *int   `json:"metric,omitempty"`
+	Table  int    `json:"table,omitempty"`
+}
+
+type netplanNameservers struct {
+	Addresses []string `json:"addresses,omitempty"`
+	Search    []string `json:"search,omitempty"`
+}
+
+// Render the network-config for the networking spec
+// Devices are matched on mac address when it is known (from the spec or from SCVMM), and on name otherwise
+func netplanNetworkConfig(networking *infrav1.Networking, macAddresses []string) ([]byte, error) {
+	config := netplanConfig{
+		Version:   2,
+		Ethernets: make(map[string]*netplanInterface),
+	}
+	for slot, nwd := range networking.Devices {
+		devicename := networkDeviceName(nwd, slot)
+		if _, exists := config.Ethernets[devicename]; exists {
+			return nil, fmt.Errorf("duplicate network device name %s", devicename)
+		}
+		iface, err := netplanAddressing(devicename, nwd.NetworkAddressing)
+		if err != nil {
+			return nil, err
+		}
+		if mac := deviceMACAddress(nwd, slot, macAddresses); mac != "" {
+			iface.Match = &netplanMatch{MACAddress: mac}
+			iface.SetName = devicename
+		}
+		config.Ethernets[devicename] = iface
+	}
+	for _, bond := range networking.Bonds {
+		for _, member := range bond.Interfaces {
+			if config.Ethernets[member] == nil {
+				return nil, fmt.Errorf("bond %s has unknown interface %s", bond.Name, member)
+			}
+		}
+		if config.Bonds == nil {
+			config.Bonds = make(map[string]*netplanInterface)
+		}
+		iface, err := netplanAddressing(bond.Name, bond.NetworkAddressing)
+		if err != nil {
+			return nil, err
+		}
+		iface.Interfaces = bond.Interfaces
+		iface.Parameters = &netplanBondParameters{
+			Mode:               bond.Mode,
+			MIIMonitorInterval: bond.MIIMonitorInterval,
+		}
+		config.Bonds[bond.Name] = iface
+	}
+	for _, vlan := range networking.VLANs {
+		if config.Ethernets[vlan.Link] == nil && config.Bonds[vlan.Link] == nil {
+			return nil, fmt.Errorf("vlan %s has unknown link %s", vlan.Name, vlan.Link)
+		}
+		if config.VLANs == nil {
+			config.VLANs = make(map[string]*netplanInterface)
+		}
+		iface, err := netplanAddressing(vlan.Name, vlan.NetworkAddressing)
+		if err != nil {
+			return nil, err
+		}
+		id := vlan.ID
+		iface.ID = &id
+		iface.Link = vlan.Link
+		config.VLANs[vlan.Name] = iface
+	}
+	return yaml.Marshal(config)
+}
+
+// The addresses, routes, nameservers and mtu of a netplan interface
+// The gateways are rendered as default routes for their address family
+// Routes with a source address go in their own routing table, with a routing policy for the source
+func netplanAddressing(name string, addressing infrav1.NetworkAddressing) (*netplanInterface, error) {
+	iface := &netplanInterface{
+		Addresses: addressing.IPAddresses,
+		MTU:       addressing.MTU,
+	}
+	if addressing.Gateway != "" {
+		iface.Routes = append(iface.Routes, netplanRoute{To: "0.0.0.0/0", Via: addressing.Gateway})
+	}
+	if addressing.Gateway6 != "" {
+		iface.Routes = append(iface.Routes, netplanRoute{To: "::/0", Via: addressing.Gateway6})
+	}
+	for _, route := range addressing.Routes {
+		nproute := netplanRoute{To: route.To, Via: route.Via, Metric: route.Metric, Table: route.Table}
+		if route.Via == "" {
+			nproute.Scope = "link"
+		}
+		iface.Routes = append(iface.Routes, nproute)
+	}
+	policies, err := routingPolicies(addressing.Routes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	iface.Policies = policies
+	if len(addressing.Nameservers) > 0 || len(addressing.SearchDomains) > 0 {
+		iface.Nameservers = &netplanNameservers{
+			Addresses: addressing.Nameservers,
+			Search:    addressing.SearchDomains,
+		}
+	}
+	return iface, nil
+}
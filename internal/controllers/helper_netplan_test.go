@@ -0,0 +1,189 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestNetplanNetworkConfig(t *testing.T) {
+	metric := 200
+	miiInterval := 100
+	tests := []struct {
+		name         string
+		networking   *infrav1.Networking
+		macAddresses []string
+	}{
+		{
+			name: "static",
+			networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					VMNetwork:  "net1",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses:   []string{"10.0.0.10/24"},
+						Gateway:       "10.0.0.1",
+						Nameservers:   []string{"10.0.0.53", "10.0.1.53"},
+						SearchDomains: []string{"example.com", "corp.example.com"},
+					},
+				}},
+			},
+		},
+		{
+			name: "dualstack",
+			networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					VMNetwork:  "net1",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.0.10/24", "2001:db8::10/64"},
+						Gateway:     "10.0.0.1",
+						Gateway6:    "2001:db8::1",
+						MTU:         9000,
+						Routes: []infrav1.NetworkRoute{
+							{To: "192.168.0.0/16", Via: "10.0.0.254", Metric: &metric},
+							{To: "2001:db8:1::/48", Via: "2001:db8::fe"},
+						},
+					},
+				}, {
+					DeviceName: "eth1",
+					VMNetwork:  "net2",
+					MACAddress: "00-15-5D-AA-BB-CC",
+				}},
+			},
+			macAddresses: []string{"00:15:5D:01:02:03", "00:00:00:00:00:00"},
+		},
+		{
+			name: "bond-vlan",
+			networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{
+					{DeviceName: "eth0", VMNetwork: "net1"},
+					{DeviceName: "eth1", VMNetwork: "net1"},
+				},
+				Bonds: []infrav1.NetworkBond{{
+					Name:               "bond0",
+					Interfaces:         []string{"eth0", "eth1"},
+					Mode:               "active-backup",
+					MIIMonitorInterval: &miiInterval,
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.0.10/24"},
+						Gateway:     "10.0.0.1",
+					},
+				}},
+				VLANs: []infrav1.NetworkVLAN{{
+					Name: "bond0.20",
+					ID:   20,
+					Link: "bond0",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.20.10/24"},
+						MTU:         1400,
+					},
+				}},
+			},
+			macAddresses: []string{"00:15:5D:01:02:03", "00:15:5D:01:02:04"},
+		},
+		{
+			name: "source-routing",
+			networking: &infrav1.Networking{
+				Domain: "example.com",
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					VMNetwork:  "net1",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						IPAddresses: []string{"10.0.0.10/24", "10.1.0.10/24"},
+						Gateway:     "10.0.0.1",
+						Routes: []infrav1.NetworkRoute{
+							{To: "10.1.0.0/24", From: "10.1.0.10", Table: 101},
+							{To: "0.0.0.0/0", Via: "10.1.0.1", From: "10.1.0.10", Table: 101},
+						},
+					},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := netplanNetworkConfig(tt.networking, tt.macAddresses)
+			if err != nil {
+				t.Fatalf("netplanNetworkConfig() error = %v", err)
+			}
+			golden := filepath.Join("testdata", "netplan", tt.name+".yaml")
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("netplanNetworkConfig() mismatch with %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestNetplanNetworkConfigErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		networking *infrav1.Networking
+	}{
+		{
+			name: "unknown bond interface",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				Bonds:   []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0", "eth1"}}},
+			},
+		},
+		{
+			name: "unknown vlan link",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				VLANs:   []infrav1.NetworkVLAN{{Name: "vlan10", ID: 10, Link: "bond0"}},
+			},
+		},
+		{
+			name: "source route without table",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{
+					DeviceName: "eth0",
+					NetworkAddressing: infrav1.NetworkAddressing{
+						Routes: []infrav1.NetworkRoute{{To: "0.0.0.0/0", Via: "10.1.0.1", From: "10.1.0.10"}},
+					},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := netplanNetworkConfig(tt.networking, nil); err == nil {
+				t.Errorf("netplanNetworkConfig() expected an error")
+			}
+		})
+	}
+}
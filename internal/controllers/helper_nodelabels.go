@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/api/v1beta1/index"
+	"sigs.k8s.io/cluster-api/util"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Labels put on the workload cluster node, so workloads can be spread over zones and hyper-v hosts
+const (
+	NodeZoneLabel      = corev1.LabelTopologyZone
+	NodeVMHostLabel    = "scvmm.cluster.x-k8s.io/vmhost"
+	NodeCloudLabel     = "scvmm.cluster.x-k8s.io/cloud"
+	NodeHostGroupLabel = "scvmm.cluster.x-k8s.io/hostgroup"
+)
+
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Make a label value out of an SCVMM name, like a host group path with backslashes and spaces
+func labelValue(value string) string {
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+// The topology labels for the node of the machine
+// The zone is the failure domain of the Machine, or the failure domain label for machines without one
+// Labels for unknown values are left out
+func nodeTopologyLabels(machine *clusterv1.Machine, scvmmMachine *infrav1.ScvmmMachine) map[string]string {
+	labels := map[string]string{}
+	values := map[string]string{
+		NodeZoneLabel:      scvmmMachine.Labels[FailureDomainLabel],
+		NodeVMHostLabel:    scvmmMachine.Status.VMHost,
+		NodeCloudLabel:     scvmmMachine.Status.Cloud,
+		NodeHostGroupLabel: scvmmMachine.Status.HostGroup,
+	}
+	if machine != nil && machine.Spec.FailureDomain != nil {
+		values[NodeZoneLabel] = *machine.Spec.FailureDomain
+	}
+	for key, value := range values {
+		if value := labelValue(value); value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// Put the topology labels on the workload cluster node of the machine
+// Machines without a cluster and machines whose node has not registered yet are skipped
+// Machines without a Machine, like the ones of a ScvmmMachinePool, find their node by provider id
+func (r *ScvmmMachineReconciler) reconcileNodeLabels(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	if cluster == nil {
+		return ctrl.Result{}, nil
+	}
+	if machine != nil && machine.Status.NodeRef == nil {
+		return ctrl.Result{}, nil
+	}
+	if machine == nil && scvmmMachine.Spec.ProviderID == "" {
+		return ctrl.Result{}, nil
+	}
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to get workload cluster client")
+	}
+	return labelMachineNode(ctx, remoteClient, machine, scvmmMachine)
+}
+
+// Put the topology labels on the node of the machine in the workload cluster
+func labelMachineNode(ctx context.Context, remoteClient client.Client, machine *clusterv1.Machine, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	node, err := machineNode(ctx, remoteClient, machine, scvmmMachine)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if node == nil {
+		// There is no Machine whose nodeRef changes when the node registers, so keep looking
+		log.V(1).Info("Waiting for node to register, requeue in 60 seconds", "providerID", scvmmMachine.Spec.ProviderID)
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	patched := node.DeepCopy()
+	if patched.Labels == nil {
+		patched.Labels = map[string]string{}
+	}
+	changed := false
+	for key, value := range nodeTopologyLabels(machine, scvmmMachine) {
+		if patched.Labels[key] != value {
+			patched.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+	log.V(1).Info("Setting node topology labels", "node", node.Name, "labels", patched.Labels)
+	if err := remoteClient.Patch(ctx, patched, client.MergeFrom(node)); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to patch node %s", node.Name)
+	}
+	return ctrl.Result{}, nil
+}
+
+// The workload cluster node of the machine
+// Returns nil when a machine without a Machine has no node (yet)
+func machineNode(ctx context.Context, remoteClient client.Client, machine *clusterv1.Machine, scvmmMachine *infrav1.ScvmmMachine) (*corev1.Node, error) {
+	if machine != nil {
+		node := &corev1.Node{}
+		if err := remoteClient.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+			return nil, errors.Wrapf(err, "failed to get node %s", machine.Status.NodeRef.Name)
+		}
+		return node, nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := remoteClient.List(ctx, nodes, client.MatchingFields{index.NodeProviderIDField: scvmmMachine.Spec.ProviderID}); err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+	if len(nodes.Items) == 0 {
+		return nil, nil
+	}
+	return &nodes.Items[0], nil
+}
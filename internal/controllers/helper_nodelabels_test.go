@@ -0,0 +1,168 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/api/v1beta1/index"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestNodeTopologyLabels(t *testing.T) {
+	failureDomain := "rack1"
+	tests := []struct {
+		name         string
+		machine      *clusterv1.Machine
+		scvmmMachine *infrav1.ScvmmMachine
+		want         map[string]string
+	}{
+		{
+			name:    "full placement",
+			machine: &clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: &failureDomain}},
+			scvmmMachine: &infrav1.ScvmmMachine{Status: infrav1.ScvmmMachineStatus{
+				VMHost:    "hv01.example.com",
+				Cloud:     "Production Cloud",
+				HostGroup: `All Hosts\Datacenter 1\Rack 1`,
+			}},
+			want: map[string]string{
+				NodeZoneLabel:      "rack1",
+				NodeVMHostLabel:    "hv01.example.com",
+				NodeCloudLabel:     "Production-Cloud",
+				NodeHostGroupLabel: "All-Hosts-Datacenter-1-Rack-1",
+			},
+		},
+		{
+			name:    "no failure domain and no cloud",
+			machine: &clusterv1.Machine{},
+			scvmmMachine: &infrav1.ScvmmMachine{Status: infrav1.ScvmmMachineStatus{
+				VMHost: "hv02",
+			}},
+			want: map[string]string{
+				NodeVMHostLabel: "hv02",
+			},
+		},
+		{
+			name: "pool machine without Machine",
+			scvmmMachine: &infrav1.ScvmmMachine{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{FailureDomainLabel: "rack2"}},
+				Status:     infrav1.ScvmmMachineStatus{VMHost: "hv03"},
+			},
+			want: map[string]string{
+				NodeZoneLabel:   "rack2",
+				NodeVMHostLabel: "hv03",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeTopologyLabels(tt.machine, tt.scvmmMachine); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nodeTopologyLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	tests := map[string]string{
+		"hv01.example.com": "hv01.example.com",
+		`\All Hosts\Prod\`: "All-Hosts-Prod",
+		"":                 "",
+		"---":              "",
+		"a very long host group name that is longer than sixty-three characters": "a-very-long-host-group-name-that-is-longer-than-sixty-three-cha",
+	}
+	for in, want := range tests {
+		if got := labelValue(in); got != want {
+			t.Errorf("labelValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// A fake workload cluster client, with the node index of the cluster cache tracker
+func workloadClusterClient(t *testing.T, nodes ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(nodes...).
+		WithIndex(&corev1.Node{}, index.NodeProviderIDField, index.NodeByProviderID).
+		Build()
+}
+
+func TestMachineNode(t *testing.T) {
+	ctx := context.Background()
+	nodes := []client.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node01"}, Spec: corev1.NodeSpec{ProviderID: "scvmm://guid-1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node02"}, Spec: corev1.NodeSpec{ProviderID: "scvmm://guid-2"}},
+	}
+	remoteClient := workloadClusterClient(t, nodes...)
+
+	machine := &clusterv1.Machine{Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node01"}}}
+	node, err := machineNode(ctx, remoteClient, machine, &infrav1.ScvmmMachine{})
+	if err != nil || node == nil || node.Name != "node01" {
+		t.Errorf("machineNode() with nodeRef = %v, %v, want node01", node, err)
+	}
+
+	// Pool machines have no Machine, their node is found by provider id
+	poolMachine := &infrav1.ScvmmMachine{Spec: infrav1.ScvmmMachineSpec{ProviderID: "scvmm://guid-2"}}
+	node, err = machineNode(ctx, remoteClient, nil, poolMachine)
+	if err != nil || node == nil || node.Name != "node02" {
+		t.Errorf("machineNode() by provider id = %v, %v, want node02", node, err)
+	}
+
+	poolMachine.Spec.ProviderID = "scvmm://guid-3"
+	node, err = machineNode(ctx, remoteClient, nil, poolMachine)
+	if err != nil || node != nil {
+		t.Errorf("machineNode() without a registered node = %v, %v, want nothing", node, err)
+	}
+}
+
+// Nothing reconciles a pool machine when its node registers, so it is requeued until it does
+func TestLabelPoolMachineNode(t *testing.T) {
+	ctx := context.Background()
+	remoteClient := workloadClusterClient(t)
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{FailureDomainLabel: "rack1"}},
+		Spec:       infrav1.ScvmmMachineSpec{ProviderID: "scvmm://guid-1"},
+		Status:     infrav1.ScvmmMachineStatus{VMHost: "hv01"},
+	}
+	res, err := labelMachineNode(ctx, remoteClient, nil, scvmmMachine)
+	if err != nil || res.RequeueAfter == 0 {
+		t.Errorf("labelMachineNode() without a node = %+v, %v, want a requeue", res, err)
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node01"}, Spec: corev1.NodeSpec{ProviderID: "scvmm://guid-1"}}
+	if err := remoteClient.Create(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+	res, err = labelMachineNode(ctx, remoteClient, nil, scvmmMachine)
+	if err != nil || !res.IsZero() {
+		t.Errorf("labelMachineNode() = %+v, %v, want no requeue", res, err)
+	}
+	if err := remoteClient.Get(ctx, client.ObjectKeyFromObject(node), node); err != nil {
+		t.Fatal(err)
+	}
+	if node.Labels[NodeZoneLabel] != "rack1" || node.Labels[NodeVMHostLabel] != "hv01" {
+		t.Errorf("node labels = %v", node.Labels)
+	}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// Script and job errors that will not go away by trying again, with the CAPI failure reason they map to
+// Everything else (timeouts, locked objects, busy hosts, ...) is assumed to be transient
+var permanentScriptErrors = []struct {
+	pattern *regexp.Regexp
+	reason  capierrors.MachineStatusError
+}{
+	{
+		pattern: regexp.MustCompile(`(?i)\b(VM template|Hardware profile|VM network|VM subnet|Port classification|Static IP address pool|Storage Fabric Classification|Virtual SAN|VHD|Cloud|Host group) .* not found`),
+		reason:  capierrors.InvalidConfigurationMachineError,
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)Too many virtual disks`),
+		reason:  capierrors.InvalidConfigurationMachineError,
+	},
+	{
+		// SCVMM user role and cloud capacity quotas
+		pattern: regexp.MustCompile(`(?i)\bquota\b.*\bexceed|\bexceeds?\b.*\bquota\b`),
+		reason:  capierrors.InsufficientResourcesMachineError,
+	},
+}
+
+// The CAPI failure reason for a script error or failed job that retrying will not fix
+// Returns false for transient errors, and for errors that did not come from a script or job
+func permanentFailureReason(err error) (capierrors.MachineStatusError, bool) {
+	var message string
+	scriptError := &ScriptError{}
+	jobError := &JobFailedError{}
+	switch {
+	case errors.As(err, &scriptError):
+		message = scriptError.message
+	case errors.As(err, &jobError):
+		message = jobError.message
+	default:
+		return "", false
+	}
+	for _, p := range permanentScriptErrors {
+		if p.pattern.MatchString(message) {
+			return p.reason, true
+		}
+	}
+	return "", false
+}
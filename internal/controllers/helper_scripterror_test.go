@@ -0,0 +1,128 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestPermanentFailureReason(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		reason    capierrors.MachineStatusError
+		permanent bool
+	}{
+		{
+			name:      "missing template",
+			err:       &ScriptError{function: "CreateVM", message: "Create VM Failed: VM template ubuntu-2204 not found"},
+			reason:    capierrors.InvalidConfigurationMachineError,
+			permanent: true,
+		},
+		{
+			name:      "missing hardware profile",
+			err:       &ScriptError{function: "CreateVM", message: "Create VM Failed: Hardware profile large not found"},
+			reason:    capierrors.InvalidConfigurationMachineError,
+			permanent: true,
+		},
+		{
+			name:      "wrapped missing vm network",
+			err:       errors.Wrap(&ScriptError{function: "CreateVM", message: "Create VM Failed: VM network lan not found"}, "VmFailed"),
+			reason:    capierrors.InvalidConfigurationMachineError,
+			permanent: true,
+		},
+		{
+			name:      "quota exceeded",
+			err:       &ScriptError{function: "CreateVM", message: "Create VM Failed: Creation Failed: The user role quota for virtual machines has been exceeded"},
+			reason:    capierrors.InsufficientResourcesMachineError,
+			permanent: true,
+		},
+		{
+			name: "locked object",
+			err:  &ScriptError{function: "SetVMProperties", message: "Set VM Properties Failed: The object is locked by another job"},
+		},
+		{
+			name: "missing vm",
+			err:  &ScriptError{function: "AddISOToVM", message: "Add ISO to VM Failed: Virtual Machine with ID 1234 not found"},
+		},
+		{
+			name:      "failed job with missing template",
+			err:       &JobFailedError{job: infrav1.VmJob{Name: "Create virtual machine", Status: "Failed"}, message: "VM template ubuntu-2204 not found in the library"},
+			reason:    capierrors.InvalidConfigurationMachineError,
+			permanent: true,
+		},
+		{
+			name:      "wrapped failed job over quota",
+			err:       errors.Wrap(&JobFailedError{job: infrav1.VmJob{Name: "Create virtual machine", Status: "Failed"}, message: "The cloud quota for memory would be exceeded"}, "VmFailed"),
+			reason:    capierrors.InsufficientResourcesMachineError,
+			permanent: true,
+		},
+		{
+			name: "failed job on a busy host",
+			err:  &JobFailedError{job: infrav1.VmJob{Name: "Create virtual machine", Status: "Failed"}, message: "The host is not responding"},
+		},
+		{
+			name: "not a script error",
+			err:  errors.New("VM template foo not found"),
+		},
+		{
+			name: "no error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, permanent := permanentFailureReason(tt.err)
+			if reason != tt.reason || permanent != tt.permanent {
+				t.Errorf("permanentFailureReason() = %q, %v, want %q, %v", reason, permanent, tt.reason, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestPatchReasonConditionWhileDeleting(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm01", DeletionTimestamp: &now, Finalizers: []string{MachineFinalizer}},
+	}
+	env := newFakeEnv(t, scvmmMachine)
+	r := &ScvmmMachineReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+	patchHelper, err := patch.NewHelper(scvmmMachine, env.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptErr := &ScriptError{function: "RemoveVM", message: "Remove VM Failed: Cloud tenant not found"}
+	res, err := r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, scriptErr, VmCreated, VmFailedReason, "Failed to delete vm")
+	if err != nil {
+		t.Fatalf("patchReasonCondition() error = %v", err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Errorf("patchReasonCondition() does not requeue a deleting machine")
+	}
+	if scvmmMachine.Status.FailureReason != nil {
+		t.Errorf("failureReason %s set on a deleting machine", *scvmmMachine.Status.FailureReason)
+	}
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// SCVMM job states that mean the job is finished
+var (
+	jobSucceededStates = map[string]bool{
+		"Completed":       true,
+		"SucceedWithInfo": true,
+	}
+	jobFailedStates = map[string]bool{
+		"Failed":   true,
+		"Canceled": true,
+	}
+)
+
+// JobFailedError is returned when an SCVMM job that the controller waited on did not succeed
+type JobFailedError struct {
+	job     infrav1.VmJob
+	message string
+}
+
+func (e *JobFailedError) Error() string {
+	if e.message == "" {
+		return "job " + e.job.Name + " " + e.job.Status
+	}
+	return "job " + e.job.Name + " " + e.job.Status + ": " + e.message
+}
+
+// The reasons a job is reported with on the condition it belongs to, while running and when failed
+var jobConditionReasons = map[clusterv1.ConditionType][2]string{
+	VmCreated:    {VmCreatingReason, VmFailedReason},
+	DisksResized: {DisksResizingReason, DisksResizeFailedReason},
+	VmRunning:    {VmStartingReason, VmFailedReason},
+}
+
+// Remember the job returned by a script so the next reconciliation can wait for it
+// Its progress and errors are reported on the given condition
+func setVMJob(scvmmMachine *infrav1.ScvmmMachine, vm VMResult, condition clusterv1.ConditionType) {
+	scvmmMachine.Status.Job = newVMJob(vm)
+	if scvmmMachine.Status.Job != nil {
+		scvmmMachine.Status.Job.Condition = condition
+	}
+}
+
+// The condition and reasons (running and failed) to report the job with
+func vmJobCondition(job *infrav1.VmJob) (clusterv1.ConditionType, string, string) {
+	condition := job.Condition
+	if condition == "" {
+		// Jobs stored before the condition was recorded were all disk expansions
+		condition = DisksResized
+	}
+	reasons, ok := jobConditionReasons[condition]
+	if !ok {
+		reasons = [2]string{VmUpdatingReason, VmFailedReason}
+	}
+	return condition, reasons[0], reasons[1]
+}
+
+func newVMJob(res VMResult) *infrav1.VmJob {
+	if res.JobId == "" {
+		return nil
+	}
+	return &infrav1.VmJob{Id: res.JobId}
+}
+
+// pollVMJob refreshes the job in the machine status from SCVMM
+// Returns true when the job is done, clearing it from the status.
+// When the job failed, a JobFailedError is returned
+func pollVMJob(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) (bool, error) {
+	if scvmmMachine.Status.Job == nil {
+		return true, nil
+	}
+	done, err := pollJob(ctx, scvmmMachine.Spec.ProviderRef, scvmmMachine.Status.Job)
+	if done {
+		scvmmMachine.Status.Job = nil
+	}
+	return done, err
+}
+
+// pollJob gets the current state of the job from SCVMM and fills it in
+// Returns true when the job is done, and a JobFailedError if it did not succeed
+func pollJob(ctx context.Context, providerRef *infrav1.ScvmmProviderReference, job *infrav1.VmJob) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+	res, err := sendWinrmCommand(log, providerRef, "GetJob -ID '%s'",
+		escapeSingleQuotes(job.Id))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get job")
+	}
+	job.Name = res.Name
+	job.Status = res.Status
+	job.Progress = res.Progress
+	switch {
+	case jobSucceededStates[res.Status]:
+		log.V(1).Info("Job finished", "job", job)
+		return true, nil
+	case jobFailedStates[res.Status]:
+		log.V(1).Info("Job failed", "job", job, "error", res.ErrorInfo)
+		return true, &JobFailedError{job: *job, message: res.ErrorInfo}
+	}
+	return false, nil
+}
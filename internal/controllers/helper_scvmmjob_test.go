@@ -0,0 +1,83 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestCreateJobFailureIsTerminal(t *testing.T) {
+	dataSecretName := "vm01-bootstrap"
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm01", Finalizers: []string{MachineFinalizer}},
+		Spec: infrav1.ScvmmMachineSpec{
+			ProviderRef: testProviderRef(),
+			VMName:      "vm01",
+			Id:          "vm-1",
+			Bootstrap:   &clusterv1.Bootstrap{DataSecretName: &dataSecretName},
+			Networking: &infrav1.Networking{
+				Domain:  "example.com",
+				Devices: []infrav1.NetworkDevice{{VMNetwork: "net"}},
+			},
+		},
+		Status: infrav1.ScvmmMachineStatus{
+			Job: &infrav1.VmJob{Id: "job-1", Condition: VmCreated},
+		},
+	}
+	env := newFakeEnv(t, scvmmMachine)
+	env.scvmm.handle("GetJob", func(string) VMResult {
+		return VMResult{Name: "Create virtual machine", Status: "Failed", ErrorInfo: "Unable to allocate memory on hv01"}
+	})
+	env.scvmm.vms["vm-1"] = VMResult{Id: "vm-1", Name: "vm01", Status: "CreationFailed"}
+	r := &ScvmmMachineReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+
+	// The failed job is reported, and retried as it is not a known permanent error
+	env.reconcile(t, r, scvmmMachine, 1)
+	if scvmmMachine.Status.Job != nil || scvmmMachine.Status.FailureReason != nil {
+		t.Fatalf("failed job not handled: %+v", scvmmMachine.Status)
+	}
+
+	// The VM that the job left behind makes the failure terminal
+	env.reconcile(t, r, scvmmMachine, 1)
+	if conditions.IsTrue(scvmmMachine, VmCreated) || conditions.GetReason(scvmmMachine, VmCreated) != VmFailedReason {
+		t.Errorf("VmCreated = %v", conditions.Get(scvmmMachine, VmCreated))
+	}
+	if !strings.Contains(conditions.GetMessage(scvmmMachine, VmCreated), "Unable to allocate memory on hv01") {
+		t.Errorf("VmCreated message %q lost the job error", conditions.GetMessage(scvmmMachine, VmCreated))
+	}
+	if reason := scvmmMachine.Status.FailureReason; reason == nil || *reason != capierrors.CreateMachineError {
+		t.Errorf("failureReason = %v, want %s", reason, capierrors.CreateMachineError)
+	}
+	if message := scvmmMachine.Status.FailureMessage; message == nil || !strings.Contains(*message, "Unable to allocate memory on hv01") {
+		t.Errorf("failureMessage = %v, want the job error", message)
+	}
+
+	getVMCalls := env.scvmm.calledCount("GetVM")
+	env.reconcile(t, r, scvmmMachine, 1)
+	if calls := env.scvmm.calledCount("GetVM"); calls != getVMCalls {
+		t.Errorf("failed machine reconciled again, GetVM called %d more times", calls-getVMCalls)
+	}
+}
@@ -3,10 +3,9 @@ package controllers
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"strings"
 	"time"
-
-	"github.com/hirochachacha/go-smb2"
 )
 
 type vfatSector []byte
@@ -64,11 +63,11 @@ func (sector vfatSector) putFATChain(offset, sectorSize, flen, fatsize uint32) u
 	return sector.putFATEnt(offset, 0xFFFFFFFF, fatsize)
 }
 
-func (sector vfatSector) putVfatDirent(offset int, name string, start, flen uint32, ctime time.Time) int {
+func (sector vfatSector) putVfatDirent(offset int, name string, attributes uint8, start, flen uint32, ctime time.Time) int {
 	// TODO: File extensions
-	shortname := strings.ToUpper(name[0:6] + "~1")
-	if len(name) <= 8 {
-		shortname = strings.ToUpper(name)
+	shortname := strings.ToUpper(name)
+	if len(name) > 8 {
+		shortname = strings.ToUpper(name[0:6] + "~1")
 	}
 	namechecksum := uint8(0)
 	for i := 0; i < 11; i++ {
@@ -99,6 +98,7 @@ func (sector vfatSector) putVfatDirent(offset int, name string, start, flen uint
 	}
 
 	sector.putString(offset, 11, shortname)
+	sector[offset+11] = attributes
 	sector.putDateTime(offset+14, ctime)
 	sector.putU16(offset+20, uint16(start>>16))
 	sector.putDateTime(offset+22, ctime)
@@ -107,14 +107,45 @@ func (sector vfatSector) putVfatDirent(offset int, name string, start, flen uint
 	return offset + 32
 }
 
-func (sector vfatSector) putDirent(offset int, name string, attributes uint8, ctime time.Time) int {
+func (sector vfatSector) putDirent(offset int, name string, attributes uint8, start uint32, ctime time.Time) int {
 	sector.putString(offset, 11, name)
 	sector[offset+11] = attributes
 	sector.putDateTime(offset+14, ctime)
+	sector.putU16(offset+20, uint16(start>>16))
 	sector.putDateTime(offset+22, ctime)
+	sector.putU16(offset+26, uint16(start))
 	return offset + 32
 }
 
+// Number of directory entries needed for a file or directory with a long name
+func vfatDirentCount(name string) int {
+	// One dirent needed for every 13 characters in the filename
+	return ((len(name) - 1) / 13) + 1 + 1
+}
+
+// Size in bytes of the directory entries of a directory
+func vfatDirSize(dir *cloudInitDir) int {
+	size := 0
+	if dir.Parent != nil {
+		size = size + 2 // . and ..
+	}
+	for _, sub := range dir.Dirs {
+		size = size + vfatDirentCount(sub.Name)
+	}
+	for _, cif := range dir.Files {
+		size = size + vfatDirentCount(cif.baseName())
+	}
+	return size * 32
+}
+
+// Number of sectors taken by a file, at least one
+func vfatSectors(length, sectorSize int) int {
+	if length == 0 {
+		return 1
+	}
+	return ((length - 1) / sectorSize) + 1
+}
+
 func (sector vfatSector) putDateTime(offset int, datetime time.Time) {
 	// fat time format: Hour (5 bits) Minute (6 bits) Second (5 bits)
 	sector.putU16(offset,
@@ -128,25 +159,27 @@ func (sector vfatSector) putDateTime(offset int, datetime time.Time) {
 			(datetime.Day()&0x1F)))
 }
 
-func writeVFAT(fh *smb2.File, files []CloudInitFile) (int, error) {
+func writeVFAT(fh io.Writer, label string, files []CloudInitFile) (int, error) {
 	const sectorSize = 256
 	sector := make(vfatSector, sectorSize)
 	now := time.Now()
 
 	// Calculate number of sectors
-	dirents := 1            // Volume identifier is first entry
-	lastSector := uint32(1) // Boot sector
-	for cif := range files {
-		// Round up to sector size
-		fsz := ((len(files[cif].Contents) - 1) / sectorSize) + 1
-		lastSector = lastSector + uint32(fsz)
-		// One dirent needed for every 13 characters in the filename
-		nsz := ((len(files[cif].Filename) - 1) / 13) + 1 + 1
-		dirents = dirents + nsz
-	}
-	// Round up to multiple of the sector size (times 32)
-	dirents = (((dirents*32 - 1) / sectorSize) + 1) * sectorSize
-	lastSector = lastSector + uint32(dirents)/sectorSize
+	dirs := cloudInitTree(files)
+	dirents := 32 + vfatDirSize(dirs[0]) // Volume identifier is first entry
+	dataSectors := uint32(0)
+	for _, dir := range dirs[1:] {
+		dataSectors = dataSectors + uint32(vfatSectors(vfatDirSize(dir), sectorSize))
+	}
+	for _, dir := range dirs {
+		for _, cif := range dir.Files {
+			// Round up to sector size
+			dataSectors = dataSectors + uint32(vfatSectors(len(cif.Contents), sectorSize))
+		}
+	}
+	// Round up to multiple of the sector size
+	dirents = (((dirents - 1) / sectorSize) + 1) * sectorSize
+	lastSector := 1 + uint32(dirents)/sectorSize + dataSectors // Boot sector, root directory, data
 
 	// Determine fat type and size
 	fatSize := uint32(12)
@@ -159,7 +192,7 @@ func writeVFAT(fh *smb2.File, files []CloudInitFile) (int, error) {
 			fatSize = 16
 		}
 		if lastSector >= 65525 {
-			panic("TODO: fat32")
+			return 0, fmt.Errorf("cloud-init data too large for FAT16")
 		}
 		// Size in bits to bytes, rounding up
 		fatSectorSize := ((fatSize*lastSector-1)/8 + 1)
@@ -182,53 +215,71 @@ func writeVFAT(fh *smb2.File, files []CloudInitFile) (int, error) {
 	sector.putU32(28, 0)                                   // Hidden sectors
 	sector[38] = 0x29                                      // Extended boot signature
 	sector.putU32(39, 0x00C1DA7A)                          // Serial number
-	sector.putString(43, 11, "cidata")                     // Volume identifier
+	sector.putString(43, 11, label)                        // Volume identifier
 	sector.putString(54, 8, fmt.Sprintf("FAT%d", fatSize)) // File system type
 
 	if _, err := fh.Write(sector); err != nil {
 		return 0, err
 	}
 
-	// Create FAT
-	fileStarts := make([]uint32, len(files))
+	// Create FAT, subdirectories first and then the files
+	starts := make(map[interface{}]uint32)
 
 	sector = make(vfatSector, sectorSize*fatSectorCount)
 
 	fatOff := uint32(0)
 	fatOff = sector.putFATEnt(fatOff, 0xFFFFFFF8, fatSize) // FAT ID
 	fatOff = sector.putFATEnt(fatOff, 0xFFFFFFFF, fatSize) // end of chain marker
-	for cif := range files {
-		fileStarts[cif] = fatOff
-		fatOff = sector.putFATChain(fatOff, sectorSize, uint32(len(files[cif].Contents)), fatSize)
-	}
-
-	if _, err := fh.Write(sector); err != nil {
-		return 0, err
+	for _, dir := range dirs[1:] {
+		starts[dir] = fatOff
+		fatOff = sector.putFATChain(fatOff, sectorSize, uint32(vfatDirSize(dir)), fatSize)
 	}
-
-	sector = make(vfatSector, dirents)
-	curOff := 0
-	curOff = sector.putDirent(curOff, "CIDATA", 0x08, now)
-
-	for cif := range files {
-		flen := uint32(len(files[cif].Contents))
-		curOff = sector.putVfatDirent(curOff, files[cif].Filename, fileStarts[cif], flen, now)
+	for _, dir := range dirs {
+		for _, cif := range dir.Files {
+			starts[cif] = fatOff
+			fatOff = sector.putFATChain(fatOff, sectorSize, uint32(len(cif.Contents)), fatSize)
+		}
 	}
 
 	if _, err := fh.Write(sector); err != nil {
 		return 0, err
 	}
 
-	sector = make(vfatSector, sectorSize)
-	for cif := range files {
-		if _, err := fh.Write(files[cif].Contents); err != nil {
+	for _, dir := range dirs {
+		size := dirents
+		curOff := 0
+		if dir.Parent == nil {
+			sector = make(vfatSector, size)
+			curOff = sector.putDirent(curOff, label, 0x08, 0, now)
+		} else {
+			size = vfatSectors(vfatDirSize(dir), sectorSize) * sectorSize
+			sector = make(vfatSector, size)
+			curOff = sector.putDirent(curOff, ".", 0x10, starts[dir], now)
+			curOff = sector.putDirent(curOff, "..", 0x10, starts[dir.Parent], now) // Root directory is 0
+		}
+		for _, sub := range dir.Dirs {
+			curOff = sector.putVfatDirent(curOff, sub.Name, 0x10, starts[sub], 0, now)
+		}
+		for _, cif := range dir.Files {
+			curOff = sector.putVfatDirent(curOff, cif.baseName(), 0, starts[cif], uint32(len(cif.Contents)), now)
+		}
+		if _, err := fh.Write(sector); err != nil {
 			return 0, err
 		}
-		padlen := (sectorSize - (len(files[cif].Contents) % sectorSize)) % sectorSize
-		if padlen > 0 {
-			if _, err := fh.Write(sector[:padlen]); err != nil {
+	}
+
+	sector = make(vfatSector, sectorSize)
+	for _, dir := range dirs {
+		for _, cif := range dir.Files {
+			if _, err := fh.Write(cif.Contents); err != nil {
 				return 0, err
 			}
+			padlen := vfatSectors(len(cif.Contents), sectorSize)*sectorSize - len(cif.Contents)
+			if padlen > 0 {
+				if _, err := fh.Write(sector[:padlen]); err != nil {
+					return 0, err
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,135 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// A vfat image, as written by writeVFAT
+type vfatImage struct {
+	image      []byte
+	sectorSize int
+	fat        []byte
+	fatSize    int
+	dataOffset int
+}
+
+// The next cluster in the chain, as stored in the FAT
+func (img *vfatImage) nextCluster(cluster int) int {
+	if img.fatSize == 16 {
+		return int(binary.LittleEndian.Uint16(img.fat[cluster*2:]))
+	}
+	entry := int(binary.LittleEndian.Uint16(img.fat[cluster*3/2:]))
+	if cluster%2 == 1 {
+		return entry >> 4
+	}
+	return entry & 0xfff
+}
+
+// The contents of a cluster chain
+func (img *vfatImage) readChain(t *testing.T, cluster int) []byte {
+	t.Helper()
+	var data []byte
+	for i := 0; cluster >= 2 && cluster < 0xff8; i++ {
+		if i > len(img.image)/img.sectorSize {
+			t.Fatalf("cluster chain does not end")
+		}
+		offset := img.dataOffset + (cluster-2)*img.sectorSize
+		data = append(data, img.image[offset:offset+img.sectorSize]...)
+		cluster = img.nextCluster(cluster)
+	}
+	return data
+}
+
+// Read the files of a directory by path, using the long file names
+func (img *vfatImage) readDir(t *testing.T, entries []byte, prefix string, files map[string]string) {
+	t.Helper()
+	longName := make(map[int]string)
+	for off := 0; off+32 <= len(entries) && entries[off] != 0; off += 32 {
+		entry := entries[off : off+32]
+		attributes := entry[11]
+		if attributes == 0x0f {
+			var chunk []byte
+			for _, pos := range []int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30} {
+				if entry[pos] == 0 {
+					break
+				}
+				chunk = append(chunk, entry[pos])
+			}
+			longName[int(entry[0]&0x1f)] = string(chunk)
+			continue
+		}
+		name := strings.TrimSpace(string(entry[0:11]))
+		if len(longName) > 0 {
+			name = ""
+			for seq := 1; seq <= len(longName); seq++ {
+				name += longName[seq]
+			}
+			longName = make(map[int]string)
+		}
+		cluster := int(binary.LittleEndian.Uint16(entry[26:28]))
+		switch {
+		case attributes&0x08 != 0, name == ".", name == "..":
+			continue
+		case attributes&0x10 != 0:
+			img.readDir(t, img.readChain(t, cluster), prefix+name+"/", files)
+		default:
+			size := int(binary.LittleEndian.Uint32(entry[28:32]))
+			files[prefix+name] = string(img.readChain(t, cluster)[:size])
+		}
+	}
+}
+
+func TestWriteVFAT(t *testing.T) {
+	var buf bytes.Buffer
+	size, err := writeVFAT(&buf, "cidata", nestedCloudInitFiles)
+	if err != nil {
+		t.Fatalf("writeVFAT() error: %v", err)
+	}
+	image := buf.Bytes()
+	if size != len(image) {
+		t.Errorf("writeVFAT() size = %d, wrote %d bytes", size, len(image))
+	}
+	sectorSize := int(binary.LittleEndian.Uint16(image[11:13]))
+	if sectors := int(binary.LittleEndian.Uint16(image[19:21])); sectors*sectorSize != len(image) {
+		t.Errorf("total sectors = %d, image has %d bytes", sectors, len(image))
+	}
+	if label := strings.TrimSpace(string(image[43:54])); label != "cidata" {
+		t.Errorf("volume label = %q, want cidata", label)
+	}
+	fatOffset := int(binary.LittleEndian.Uint16(image[14:16])) * sectorSize
+	fatLength := int(image[16]) * int(binary.LittleEndian.Uint16(image[22:24])) * sectorSize
+	rootOffset := fatOffset + fatLength
+	rootLength := int(binary.LittleEndian.Uint16(image[17:19])) * 32
+	img := &vfatImage{
+		image:      image,
+		sectorSize: sectorSize,
+		fat:        image[fatOffset:rootOffset],
+		fatSize:    12,
+		dataOffset: rootOffset + rootLength,
+	}
+	if strings.TrimSpace(string(image[54:62])) == "FAT16" {
+		img.fatSize = 16
+	}
+	files := make(map[string]string)
+	img.readDir(t, image[rootOffset:rootOffset+rootLength], "", files)
+	checkImageFiles(t, files)
+}
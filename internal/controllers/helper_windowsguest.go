@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Network device names like eth0 are positional, anything else is taken as the windows interface alias
+var windowsPositionalDevice = regexp.MustCompile(`^eth[0-9]+$`)
+
+// The config drive files for cloudbase-init
+// The user data is a multipart mime document with the network configuration script,
+// the bootstrap data and the offline domain join (when there is a domain join blob)
+func cloudbaseInitFiles(scvmmMachine *infrav1.ScvmmMachine, machineid string, bootstrapData []byte, domainJoin string) ([]CloudInitFile, error) {
+	metaData, err := configDriveMetaData(scvmmMachine, machineid)
+	if err != nil {
+		return nil, err
+	}
+	var userData bytes.Buffer
+	mpw := multipart.NewWriter(&userData)
+	userData.WriteString("MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"" + mpw.Boundary() + "\"\r\n\r\n")
+
+	networking, err := machineNetworking(scvmmMachine)
+	if err != nil {
+		return nil, err
+	}
+	if networking != nil && len(networking.Devices) > 0 {
+		script, err := windowsNetworkScript(networking)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeMimePart(mpw, "text/x-shellscript", []byte(script)); err != nil {
+			return nil, err
+		}
+	}
+	bootstrapType := "text/x-shellscript"
+	if bytes.HasPrefix(bootstrapData, []byte("#cloud-config")) {
+		bootstrapType = "text/cloud-config"
+	}
+	if err := writeMimePart(mpw, bootstrapType, bootstrapData); err != nil {
+		return nil, err
+	}
+	if domainJoin != "" {
+		if err := writeMimePart(mpw, "text/x-shellscript", []byte(windowsDomainJoinScript(domainJoin))); err != nil {
+			return nil, err
+		}
+	}
+	if err := mpw.Close(); err != nil {
+		return nil, err
+	}
+	return []CloudInitFile{
+		{configDriveDir + "meta_data.json", metaData},
+		{configDriveDir + "user_data", userData.Bytes()},
+	}, nil
+}
+
+func writeMimePart(mpw *multipart.Writer, contentType string, contents []byte) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType+"; charset=\"utf-8\"")
+	part, err := mpw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(contents)
+	return err
+}
+
+// Render a powershell script that configures the network adapters
+// Adapters with a static mac address are matched on that,
+// adapters named ethN on their position, other names on the interface alias
+// Bonds and vlans are refused, windows guests would need LBFO teams for those
+func windowsNetworkScript(networking *infrav1.Networking) (string, error) {
+	if len(networking.Bonds) > 0 {
+		return "", fmt.Errorf("bond %s: bonds are not supported on windows", networking.Bonds[0].Name)
+	}
+	if len(networking.VLANs) > 0 {
+		return "", fmt.Errorf("vlan %s: vlans are not supported on windows", networking.VLANs[0].Name)
+	}
+	var script strings.Builder
+	script.WriteString("#ps1_sysnative\n" +
+		"$ErrorActionPreference = 'Stop'\n" +
+		"$adapters = @(Get-NetAdapter -Physical | Sort-Object -Property ifIndex)\n")
+	var searchDomains []string
+	for slot, nwd := range networking.Devices {
+		devicename := nwd.DeviceName
+		if mac := deviceMACAddress(nwd, slot, nil); mac != "" {
+			script.WriteString(fmt.Sprintf("$adapter = Get-NetAdapter -Physical | Where-Object { $_.MacAddress -eq '%s' }\n",
+				strings.ToUpper(strings.ReplaceAll(mac, ":", "-"))))
+		} else if devicename == "" || windowsPositionalDevice.MatchString(devicename) {
+			position := slot
+			if devicename != "" {
+				fmt.Sscanf(devicename, "eth%d", &position)
+			}
+			script.WriteString(fmt.Sprintf("$adapter = $adapters[%d]\n", position))
+		} else {
+			script.WriteString(fmt.Sprintf("$adapter = Get-NetAdapter -Name '%s'\n", escapeSingleQuotes(devicename)))
+		}
+		if len(nwd.IPAddresses) == 0 {
+			script.WriteString("Set-NetIPInterface -InterfaceIndex $adapter.ifIndex -Dhcp Enabled\n")
+		} else {
+			script.WriteString("Set-NetIPInterface -InterfaceIndex $adapter.ifIndex -Dhcp Disabled\n" +
+				"Get-NetIPAddress -InterfaceIndex $adapter.ifIndex -ErrorAction SilentlyContinue | Remove-NetIPAddress -Confirm:$false\n" +
+				"Get-NetRoute -InterfaceIndex $adapter.ifIndex -DestinationPrefix @('0.0.0.0/0','::/0') -ErrorAction SilentlyContinue | Remove-NetRoute -Confirm:$false\n")
+		}
+		// The default gateway goes with the first address of its family
+		gateways := map[bool]string{false: nwd.Gateway, true: nwd.Gateway6}
+		for _, address := range nwd.IPAddresses {
+			ip, ipnet, err := net.ParseCIDR(address)
+			if err != nil {
+				return "", fmt.Errorf("ip address %s of %s is not in CIDR notation: %w", address, devicename, err)
+			}
+			prefix, _ := ipnet.Mask.Size()
+			gateway := ""
+			if gw := gateways[isIPv6(address)]; gw != "" {
+				gateway = fmt.Sprintf(" -DefaultGateway '%s'", escapeSingleQuotes(gw))
+				gateways[isIPv6(address)] = ""
+			}
+			script.WriteString(fmt.Sprintf("New-NetIPAddress -InterfaceIndex $adapter.ifIndex -IPAddress '%s' -PrefixLength %d%s | Out-Null\n",
+				ip.String(), prefix, gateway))
+		}
+		for _, route := range nwd.Routes {
+			if route.From != "" {
+				return "", fmt.Errorf("route to %s from %s of %s: source-based routing is not supported on windows", route.To, route.From, devicename)
+			}
+			// A route without gateway is on-link
+			nexthop := route.Via
+			if nexthop == "" {
+				nexthop = "0.0.0.0"
+				if isIPv6(route.To) {
+					nexthop = "::"
+				}
+			}
+			metric := ""
+			if route.Metric != nil {
+				metric = fmt.Sprintf(" -RouteMetric %d", *route.Metric)
+			}
+			script.WriteString(fmt.Sprintf("New-NetRoute -InterfaceIndex $adapter.ifIndex -DestinationPrefix '%s' -NextHop '%s'%s | Out-Null\n",
+				escapeSingleQuotes(route.To), escapeSingleQuotes(nexthop), metric))
+		}
+		if nwd.MTU > 0 {
+			script.WriteString(fmt.Sprintf("Set-NetIPInterface -InterfaceIndex $adapter.ifIndex -NlMtuBytes %d\n", nwd.MTU))
+		}
+		if len(nwd.Nameservers) > 0 {
+			script.WriteString(fmt.Sprintf("Set-DnsClientServerAddress -InterfaceIndex $adapter.ifIndex -ServerAddresses @(%s)\n",
+				escapeSingleQuotesArray(nwd.Nameservers)))
+		}
+		if networking.Domain != "" {
+			script.WriteString(fmt.Sprintf("Set-DnsClient -InterfaceIndex $adapter.ifIndex -ConnectionSpecificSuffix '%s'\n",
+				escapeSingleQuotes(networking.Domain)))
+		}
+		searchDomains = append(searchDomains, nwd.SearchDomains...)
+	}
+	if len(searchDomains) > 0 {
+		script.WriteString(fmt.Sprintf("Set-DnsClientGlobalSetting -SuffixSearchList @(%s)\n", escapeSingleQuotesArray(searchDomains)))
+	}
+	return script.String(), nil
+}
+
+// Render a powershell script that does an offline domain join with a (base64 encoded) djoin provisioning blob
+// The join takes effect after a reboot, which exit code 1001 asks cloudbase-init to do
+func windowsDomainJoinScript(domainJoin string) string {
+	return "#ps1_sysnative\n" +
+		"$ErrorActionPreference = 'Stop'\n" +
+		"$blobfile = Join-Path $env:TEMP 'capi-odj.txt'\n" +
+		"[System.IO.File]::WriteAllBytes($blobfile, [Convert]::FromBase64String('" + domainJoin + "'))\n" +
+		"& djoin.exe /requestODJ /loadfile $blobfile /windowspath $env:SystemRoot /localos\n" +
+		"$result = $LASTEXITCODE\n" +
+		"Remove-Item $blobfile\n" +
+		"if ($result -ne 0) { exit $result }\n" +
+		"exit 1001\n"
+}
+
+// The DNS domain name of an AD distinguished name, from its DC components
+func domainFromOUPath(oupath string) string {
+	var parts []string
+	for _, rdn := range strings.Split(oupath, ",") {
+		rdn = strings.TrimSpace(rdn)
+		if len(rdn) > 3 && strings.EqualFold(rdn[:3], "DC=") {
+			parts = append(parts, rdn[3:])
+		}
+	}
+	return strings.Join(parts, ".")
+}
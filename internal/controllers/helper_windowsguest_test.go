@@ -0,0 +1,52 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+func TestWindowsNetworkScriptErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		networking *infrav1.Networking
+	}{
+		{
+			name: "bond",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}, {DeviceName: "eth1"}},
+				Bonds:   []infrav1.NetworkBond{{Name: "bond0", Interfaces: []string{"eth0", "eth1"}}},
+			},
+		},
+		{
+			name: "vlan",
+			networking: &infrav1.Networking{
+				Devices: []infrav1.NetworkDevice{{DeviceName: "eth0"}},
+				VLANs:   []infrav1.NetworkVLAN{{Name: "vlan10", ID: 10, Link: "eth0"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := windowsNetworkScript(tt.networking); err == nil {
+				t.Errorf("windowsNetworkScript() expected an error")
+			}
+		})
+	}
+}
@@ -17,7 +17,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -35,18 +35,26 @@ type WinrmResult struct {
 
 // The result (passed as json) of a call to Scvmm scripts
 type VMResult struct {
-	Cloud          string
-	Name           string
-	Hostname       string
-	Status         string
-	Memory         int
-	CpuCount       int
-	VirtualNetwork string
-	IPv4Addresses  []string
-	VirtualDisks   []struct {
+	Cloud           string
+	Name            string
+	Hostname        string
+	VMHost          string
+	HostGroup       string
+	Status          string
+	Memory          int
+	CpuCount        int
+	VirtualNetwork  string
+	IPv4Addresses   []string
+	IPv6Addresses   []string
+	MACAddresses    []string
+	NetworkAdapters []VMNetworkAdapter
+	VirtualDisks    []struct {
 		Size        int64
 		MaximumSize int64
 		SharePath   string
+		BusType     string
+		Bus         int
+		Lun         int
 	}
 	ISOs []struct {
 		Size      int64
@@ -64,6 +72,32 @@ type VMResult struct {
 	CreationTime         metav1.Time
 	ModifiedTime         metav1.Time
 	Result               string
+	JobId                string
+	Progress             string
+	ErrorInfo            string
+	// Static IP address pools
+	Gateway            string
+	PrefixLength       int
+	DNSServers         []string
+	DNSSearchSuffixes  []string
+	TotalAddresses     int
+	AvailableAddresses int
+}
+
+type VMNetworkAdapter struct {
+	SlotId             int
+	MACAddress         string
+	MACAddressType     string
+	VMNetwork          string
+	VMSubnet           string
+	VLanID             int
+	PortClassification string
+	StaticIPAddresses  []struct {
+		Address           string
+		Gateway           string
+		DNSServers        []string
+		DNSSearchSuffixes []string
+	}
 }
 
 type VMSpecResult struct {
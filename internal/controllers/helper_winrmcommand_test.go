@@ -0,0 +1,182 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// Stand-in for the winrm workers
+// GetVM answers with the given VMs, other functions with their handler, and everything else fails
+type fakeScvmm struct {
+	mu       sync.Mutex
+	vms      map[string]VMResult
+	handlers map[string]func(args string) VMResult
+	calls    []string
+}
+
+// Replace the winrm workers with a fake scvmm for the duration of the test
+func startFakeScvmm(t *testing.T) *fakeScvmm {
+	scvmm := &fakeScvmm{vms: map[string]VMResult{}, handlers: map[string]func(string) VMResult{}}
+	savedChannel := winrmCommandChannel
+	winrmCommandChannel = make(chan WinrmCommand)
+	go scvmm.serve(winrmCommandChannel)
+	t.Cleanup(func() {
+		close(winrmCommandChannel)
+		winrmCommandChannel = savedChannel
+	})
+	return scvmm
+}
+
+// Register a provider for getProvider for the duration of the test
+func addFakeProvider(t *testing.T, providerRef *infrav1.ScvmmProviderReference, spec infrav1.ScvmmProviderSpec) {
+	winrmProviders[*providerRef] = WinrmProvider{Spec: spec}
+	t.Cleanup(func() { delete(winrmProviders, *providerRef) })
+}
+
+// Scheme with all the types the controllers use
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, clusterv1.AddToScheme, expv1.AddToScheme, ipamv1.AddToScheme, infrav1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return scheme
+}
+
+// Every kind of this provider and of IPAM that has a status, as their CRDs have a status subresource
+func statusSubresources(scheme *runtime.Scheme) []client.Object {
+	objs := []client.Object{}
+	for gvk, t := range scheme.AllKnownTypes() {
+		if gvk.GroupVersion() != infrav1.GroupVersion && gvk.GroupVersion() != ipamv1.GroupVersion {
+			continue
+		}
+		if _, ok := t.FieldByName("Status"); !ok {
+			continue
+		}
+		if obj, ok := reflect.New(t).Interface().(client.Object); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// The provider of the objects in controller tests
+func testProviderRef() *infrav1.ScvmmProviderReference {
+	return &infrav1.ScvmmProviderReference{Namespace: "default", Name: "scvmm"}
+}
+
+// What a controller test runs against: a fake scvmm behind the test provider,
+// and a fake client that starts out with the given objects
+type fakeEnv struct {
+	scvmm  *fakeScvmm
+	client client.Client
+}
+
+func newFakeEnv(t *testing.T, objs ...client.Object) *fakeEnv {
+	addFakeProvider(t, testProviderRef(), infrav1.ScvmmProviderSpec{})
+	scheme := testScheme(t)
+	return &fakeEnv{
+		scvmm: startFakeScvmm(t),
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objs...).
+			WithStatusSubresource(statusSubresources(scheme)...).
+			Build(),
+	}
+}
+
+// Reconcile the object a number of times, like the requeues would, and get it again
+// An object that is gone after the reconciles is left as it was
+func (e *fakeEnv) reconcile(t *testing.T, r reconcile.Reconciler, obj client.Object, times int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < times; i++ {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(obj)}); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	if err := e.client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil && !apierrors.IsNotFound(err) {
+		t.Fatal(err)
+	}
+}
+
+func (f *fakeScvmm) serve(commands <-chan WinrmCommand) {
+	for cmd := range commands {
+		funcName, args, _ := strings.Cut(strings.TrimSpace(string(cmd.input)), " ")
+		f.mu.Lock()
+		f.calls = append(f.calls, funcName)
+		handler := f.handlers[funcName]
+		res := VMResult{Error: "unexpected call", Message: funcName + " should not be called"}
+		if funcName == "GetVM" && handler == nil {
+			for id, vm := range f.vms {
+				if strings.Contains(args, "'"+id+"'") {
+					res = vm
+				}
+			}
+		}
+		f.mu.Unlock()
+		if handler != nil {
+			res = handler(args)
+		}
+		out, err := json.Marshal(res)
+		cmd.output <- WinrmResult{stdout: out, err: err}
+	}
+}
+
+// Answer calls to the function with the handler
+func (f *fakeScvmm) handle(funcName string, handler func(args string) VMResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[funcName] = handler
+}
+
+func (f *fakeScvmm) called() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+// The number of calls to the function
+func (f *fakeScvmm) calledCount(funcName string) int {
+	count := 0
+	for _, call := range f.called() {
+		if call == funcName {
+			count++
+		}
+	}
+	return count
+}
@@ -18,7 +18,12 @@ package controllers
 
 import (
 	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -31,7 +36,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	"github.com/pkg/errors"
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -39,7 +45,14 @@ const (
 	ClusterCreated        clusterv1.ConditionType = "ClusterCreated"
 	ClusterDeletingReason                         = "ClusterDeleting"
 
+	// The providers of all failure domains are reachable
+	FailureDomainsReady          clusterv1.ConditionType = "FailureDomainsReady"
+	FailureDomainUnhealthyReason                         = "FailureDomainUnhealthy"
+
 	ClusterFinalizer = "scvmmcluster.finalizers.cluster.x-k8s.io"
+
+	// How often the providers of the failure domains are checked
+	failureDomainCheckInterval = time.Minute * 5
 )
 
 // ScvmmClusterReconciler reconciles a ScvmmCluster object
@@ -117,6 +130,7 @@ func patchScvmmCluster(ctx context.Context, patchHelper *patch.Helper, scvmmClus
 		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 			clusterv1.ReadyCondition,
 			ClusterCreated,
+			FailureDomainsReady,
 		}},
 	)
 }
@@ -132,12 +146,16 @@ func (r *ScvmmClusterReconciler) reconcileNormal(ctx context.Context, scvmmClust
 	// Copy failureDomains spec to status
 	failureDomains := make(clusterv1.FailureDomains)
 	for key, fd := range scvmmCluster.Spec.FailureDomains {
+		attributes := map[string]string{
+			"cloud":     fd.Cloud,
+			"hostGroup": fd.HostGroup,
+		}
+		if fd.ProviderRef != nil {
+			attributes["provider"] = fd.ProviderRef.Namespace + "/" + fd.ProviderRef.Name
+		}
 		failureDomains[key] = clusterv1.FailureDomainSpec{
 			ControlPlane: fd.ControlPlane,
-			Attributes: map[string]string{
-				"cloud":     fd.Cloud,
-				"hostGroup": fd.HostGroup,
-			},
+			Attributes:   attributes,
 		}
 	}
 	scvmmCluster.Status.FailureDomains = failureDomains
@@ -146,7 +164,73 @@ func (r *ScvmmClusterReconciler) reconcileNormal(ctx context.Context, scvmmClust
 	scvmmCluster.Status.Ready = true
 	conditions.MarkTrue(scvmmCluster, ClusterCreated)
 
-	return ctrl.Result{}, nil
+	if len(scvmmCluster.Spec.FailureDomains) == 0 {
+		scvmmCluster.Status.FailureDomainStatus = nil
+		conditions.Delete(scvmmCluster, FailureDomainsReady)
+		return ctrl.Result{}, nil
+	}
+	r.checkFailureDomains(ctx, scvmmCluster)
+	// Keep checking the health of the failure domains
+	return ctrl.Result{RequeueAfter: failureDomainCheckInterval}, nil
+}
+
+// Check if the provider of each failure domain can reach its cloud and host group
+// The status of a failure domain is only rewritten when the result changes, because
+// every status patch triggers another reconcile, which would check again right away
+func (r *ScvmmClusterReconciler) checkFailureDomains(ctx context.Context, scvmmCluster *infrav1.ScvmmCluster) {
+	log := ctrl.LoggerFrom(ctx)
+	fdStatus := make(map[string]infrav1.ScvmmFailureDomainStatus)
+	var unhealthy []string
+	for key, fd := range scvmmCluster.Spec.FailureDomains {
+		providerRef := scvmmCluster.Spec.ProviderRef
+		if fd.ProviderRef != nil {
+			providerRef = fd.ProviderRef
+		}
+		previous, found := scvmmCluster.Status.FailureDomainStatus[key]
+		found = found && reflect.DeepEqual(previous.ProviderRef, providerRef)
+		status := previous
+		if !found || time.Since(previous.LastChecked.Time) >= failureDomainCheckInterval {
+			status = checkFailureDomain(log, providerRef, fd)
+			if found && status.Ready == previous.Ready && status.Message == previous.Message {
+				status = previous
+			}
+		}
+		if !status.Ready {
+			log.Info("Failure domain unhealthy", "failuredomain", key, "message", status.Message)
+			unhealthy = append(unhealthy, key)
+		}
+		fdStatus[key] = status
+	}
+	scvmmCluster.Status.FailureDomainStatus = fdStatus
+	if len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		conditions.MarkFalse(scvmmCluster, FailureDomainsReady, FailureDomainUnhealthyReason, clusterv1.ConditionSeverityWarning,
+			"Unhealthy: %s", strings.Join(unhealthy, ", "))
+	} else {
+		conditions.MarkTrue(scvmmCluster, FailureDomainsReady)
+	}
+}
+
+// Check if the provider can reach the cloud and host group of the failure domain
+func checkFailureDomain(log logr.Logger, providerRef *infrav1.ScvmmProviderReference, fd infrav1.ScvmmFailureDomainSpec) infrav1.ScvmmFailureDomainStatus {
+	status := infrav1.ScvmmFailureDomainStatus{
+		ProviderRef: providerRef,
+		LastChecked: metav1.Now(),
+	}
+	if _, err := getProvider(providerRef); err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	res, err := sendWinrmCommand(log, providerRef, "CheckFailureDomain -Cloud '%s' -HostGroup '%s'",
+		escapeSingleQuotes(fd.Cloud),
+		escapeSingleQuotes(fd.HostGroup))
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	status.Ready = res.Result == "Ready"
+	status.Message = res.Message
+	return status
 }
 
 func (r *ScvmmClusterReconciler) reconcileDelete(ctx context.Context, scvmmCluster *infrav1.ScvmmCluster) (ctrl.Result, error) {
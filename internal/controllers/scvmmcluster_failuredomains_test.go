@@ -0,0 +1,82 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// The status only changes when the result of a check does, so that patching it
+// does not set off another reconcile that checks again
+func TestCheckFailureDomains(t *testing.T) {
+	ctx := context.Background()
+	scvmmCluster := &infrav1.ScvmmCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+		Spec: infrav1.ScvmmClusterSpec{
+			ProviderRef: testProviderRef(),
+			FailureDomains: map[string]infrav1.ScvmmFailureDomainSpec{
+				"rack1": {Cloud: "Cloud", HostGroup: "Rack1"},
+			},
+		},
+	}
+	env := newFakeEnv(t, scvmmCluster)
+	result := VMResult{Result: "Ready", Message: "2 of 2 hosts responding"}
+	env.scvmm.handle("CheckFailureDomain", func(string) VMResult { return result })
+	r := &ScvmmClusterReconciler{Client: env.client}
+
+	r.checkFailureDomains(ctx, scvmmCluster)
+	status := scvmmCluster.Status.FailureDomainStatus["rack1"]
+	if !status.Ready || status.LastChecked.IsZero() || !conditions.IsTrue(scvmmCluster, FailureDomainsReady) {
+		t.Fatalf("failure domain status = %+v", status)
+	}
+
+	// A recent result is not checked again
+	r.checkFailureDomains(ctx, scvmmCluster)
+	if count := env.scvmm.calledCount("CheckFailureDomain"); count != 1 {
+		t.Errorf("CheckFailureDomain called %d times within the check interval, want 1", count)
+	}
+
+	// The same result leaves the status alone
+	lastChecked := metav1.NewTime(time.Now().Add(-failureDomainCheckInterval).Truncate(time.Second))
+	status.LastChecked = lastChecked
+	scvmmCluster.Status.FailureDomainStatus["rack1"] = status
+	r.checkFailureDomains(ctx, scvmmCluster)
+	if count := env.scvmm.calledCount("CheckFailureDomain"); count != 2 {
+		t.Errorf("CheckFailureDomain called %d times after the check interval, want 2", count)
+	}
+	if got := scvmmCluster.Status.FailureDomainStatus["rack1"]; got != status {
+		t.Errorf("unchanged result rewrote the status: %+v, want %+v", got, status)
+	}
+
+	// A different result is recorded
+	result = VMResult{Result: "NoHosts", Message: "0 of 2 hosts responding"}
+	r.checkFailureDomains(ctx, scvmmCluster)
+	status = scvmmCluster.Status.FailureDomainStatus["rack1"]
+	if status.Ready || status.Message != result.Message || !status.LastChecked.After(lastChecked.Time) {
+		t.Errorf("failure domain status = %+v, want the new result", status)
+	}
+	if conditions.GetReason(scvmmCluster, FailureDomainsReady) != FailureDomainUnhealthyReason {
+		t.Errorf("FailureDomainsReady = %v", conditions.Get(scvmmCluster, FailureDomainsReady))
+	}
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	// The static IP address pool is found in SCVMM
+	IPPoolReady clusterv1.ConditionType = "IPPoolReady"
+
+	IPPoolNotFoundReason        = "IPPoolNotFound"
+	IPPoolDeletingReason        = "IPPoolDeleting"
+	IPAddressGrantFailedReason  = "IPAddressGrantFailed"
+	IPAddressRevokeFailedReason = "IPAddressRevokeFailed"
+
+	IPPoolFinalizer = "scvmmippool.finalizers.cluster.x-k8s.io"
+	// Keeps an IPAddressClaim until its address is revoked in SCVMM
+	IPAddressReleaseFinalizer = "scvmmippool.finalizers.cluster.x-k8s.io/release-address"
+)
+
+// ScvmmIPPoolReconciler reconciles a ScvmmIPPool object
+// and the IPAddressClaims that reference it
+type ScvmmIPPoolReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmippools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmippools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmippools/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+func (r *ScvmmIPPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("scvmmippool", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	pool := &infrav1.ScvmmIPPool{}
+	if err := r.Get(ctx, req.NamespacedName, pool); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(pool, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get patchhelper")
+	}
+	defer func() {
+		err := patchScvmmIPPool(ctx, patchHelper, pool)
+		if !pool.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(pool, IPPoolFinalizer) {
+			// Without the finalizer, the pool is gone before its status can be patched
+			err = kerrors.FilterOut(err, apierrors.IsNotFound)
+		}
+		if err != nil {
+			log.Error(err, "failed to patch ScvmmIPPool")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	// Add finalizer.  Apparently we should return here to avoid a race condition
+	// (Presumably the change/patch will trigger another reconciliation so it continues)
+	if pool.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(pool, IPPoolFinalizer) {
+		controllerutil.AddFinalizer(pool, IPPoolFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	log.V(1).Info("Get static ip address pool", "pool", pool.Spec.StaticIPAddressPool)
+	res, err := sendWinrmCommand(log, pool.Spec.ProviderRef, "GetIPPool -Name '%s'",
+		escapeSingleQuotes(pool.Spec.StaticIPAddressPool))
+	if err != nil {
+		pool.Status.Ready = false
+		conditions.MarkFalse(pool, IPPoolReady, IPPoolNotFoundReason, clusterv1.ConditionSeverityError, "%v", err)
+		scriptError := &ScriptError{}
+		if !errors.As(err, &scriptError) {
+			return ctrl.Result{}, err
+		}
+		// Requeue script errors after 60 seconds to give scvmm a breather
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	pool.Status.Ready = true
+	pool.Status.Subnet = res.Result
+	pool.Status.Gateway = res.Gateway
+	pool.Status.Nameservers = res.DNSServers
+	pool.Status.SearchDomains = res.DNSSearchSuffixes
+	pool.Status.Counts = &infrav1.ScvmmPoolCounts{
+		Total: res.TotalAddresses,
+		Free:  res.AvailableAddresses,
+		Used:  res.TotalAddresses - res.AvailableAddresses,
+	}
+	conditions.MarkTrue(pool, IPPoolReady)
+
+	claims, err := r.poolClaims(ctx, pool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var errList []error
+	claimed, remaining := 0, 0
+	for i := range claims {
+		claim := &claims[i]
+		if err := r.reconcileClaim(ctx, pool, claim); err != nil {
+			errList = append(errList, err)
+		}
+		if controllerutil.ContainsFinalizer(claim, IPAddressReleaseFinalizer) {
+			remaining++
+		}
+		if claim.DeletionTimestamp.IsZero() && claim.Status.AddressRef.Name != "" {
+			claimed++
+		}
+	}
+	pool.Status.Claimed = claimed
+	if len(errList) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(errList)
+	}
+
+	if !pool.DeletionTimestamp.IsZero() {
+		if remaining > 0 {
+			log.Info("Waiting for claims to be released", "claims", remaining)
+			conditions.MarkFalse(pool, IPPoolReady, IPPoolDeletingReason, clusterv1.ConditionSeverityInfo, "%d claims still have an address", remaining)
+			return ctrl.Result{}, nil
+		}
+		log.V(1).Info("No claims left, remove finalizer")
+		controllerutil.RemoveFinalizer(pool, IPPoolFinalizer)
+	}
+	return ctrl.Result{}, nil
+}
+
+// The IPAddressClaims that reference the pool
+func (r *ScvmmIPPoolReconciler) poolClaims(ctx context.Context, pool *infrav1.ScvmmIPPool) ([]ipamv1.IPAddressClaim, error) {
+	claimList := &ipamv1.IPAddressClaimList{}
+	if err := r.List(ctx, claimList, client.InNamespace(pool.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list IPAddressClaims")
+	}
+	claims := []ipamv1.IPAddressClaim{}
+	for _, claim := range claimList.Items {
+		if isScvmmIPPoolRef(claim.Spec.PoolRef) && claim.Spec.PoolRef.Name == pool.Name {
+			claims = append(claims, claim)
+		}
+	}
+	return claims, nil
+}
+
+// Whether a pool reference is to a ScvmmIPPool
+func isScvmmIPPoolRef(poolRef corev1.TypedLocalObjectReference) bool {
+	return poolRef.Kind == "ScvmmIPPool" && poolRef.APIGroup != nil && *poolRef.APIGroup == infrav1.GroupVersion.Group
+}
+
+// The ID that SCVMM grants the address of a claim to
+// It is derived from the claim name, so it stays the same when the claim is moved by clusterctl move
+func claimGrantID(claim *ipamv1.IPAddressClaim) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("scvmmippool:"+claim.Namespace+"/"+claim.Name)).String()
+}
+
+// Grant an address to the claim, or revoke it when the claim is deleted
+func (r *ScvmmIPPoolReconciler) reconcileClaim(ctx context.Context, pool *infrav1.ScvmmIPPool, claim *ipamv1.IPAddressClaim) (retErr error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("IPAddressClaim", klog.KObj(claim))
+
+	if annotations.HasPaused(claim) {
+		log.V(1).Info("Claim is paused")
+		return nil
+	}
+	if clusterName := claim.Labels[clusterv1.ClusterNameLabel]; clusterName != "" {
+		cluster := &clusterv1.Cluster{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: clusterName}, cluster); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		} else if err == nil && cluster.Spec.Paused {
+			log.V(1).Info("Cluster of claim is paused")
+			return nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(claim, r.Client)
+	if err != nil {
+		return errors.Wrap(err, "Get patchhelper")
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, claim, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+		}}); err != nil && retErr == nil {
+			retErr = errors.Wrapf(err, "failed to patch IPAddressClaim %s", claim.Name)
+		}
+	}()
+
+	if !claim.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(claim, IPAddressReleaseFinalizer) {
+			return nil
+		}
+		// A claim that is moved to another cluster keeps its address
+		if _, moving := claim.Annotations[clusterctlv1.DeleteForMoveAnnotation]; !moving {
+			res, err := sendWinrmCommand(log, pool.Spec.ProviderRef, "RevokeIPAddress -ID '%s'", claimGrantID(claim))
+			if err != nil {
+				conditions.MarkFalse(claim, clusterv1.ReadyCondition, IPAddressRevokeFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+				return err
+			}
+			log.Info("Revoked address", "message", res.Message)
+			r.recorder.Eventf(pool, corev1.EventTypeNormal, "AddressRevoked", "%s for claim %s", res.Message, claim.Name)
+		}
+		controllerutil.RemoveFinalizer(claim, IPAddressReleaseFinalizer)
+		return nil
+	}
+
+	if claim.Status.AddressRef.Name != "" {
+		return nil
+	}
+	if !pool.DeletionTimestamp.IsZero() {
+		conditions.MarkFalse(claim, clusterv1.ReadyCondition, IPPoolDeletingReason, clusterv1.ConditionSeverityWarning, "ScvmmIPPool %s is being deleted", pool.Name)
+		return nil
+	}
+
+	controllerutil.AddFinalizer(claim, IPAddressReleaseFinalizer)
+	description := claim.Annotations[HostnameAnnotation]
+	if description == "" {
+		description = claim.Namespace + "/" + claim.Name
+	}
+	res, err := sendWinrmCommand(log, pool.Spec.ProviderRef, "GrantIPAddress -Pool '%s' -ID '%s' -Description '%s'",
+		escapeSingleQuotes(pool.Spec.StaticIPAddressPool),
+		claimGrantID(claim),
+		escapeSingleQuotes(description))
+	if err != nil {
+		conditions.MarkFalse(claim, clusterv1.ReadyCondition, IPAddressGrantFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+		r.recorder.Eventf(pool, corev1.EventTypeWarning, IPAddressGrantFailedReason, "Claim %s: %v", claim.Name, err)
+		return err
+	}
+
+	address := ipAddressForClaim(pool, claim, res)
+	if err := controllerutil.SetControllerReference(claim, address, r.Scheme()); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, address); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create IPAddress %s", address.Name)
+		}
+		log.V(1).Info("IPAddress already exists", "address", address.Name)
+	} else {
+		log.Info("Granted address", "address", address.Spec.Address)
+		r.recorder.Eventf(pool, corev1.EventTypeNormal, "AddressGranted", "%s for claim %s", address.Spec.Address, claim.Name)
+	}
+	claim.Status.AddressRef.Name = address.Name
+	conditions.MarkTrue(claim, clusterv1.ReadyCondition)
+	return nil
+}
+
+// The IPAddress object for an address that SCVMM granted to a claim
+func ipAddressForClaim(pool *infrav1.ScvmmIPPool, claim *ipamv1.IPAddressClaim, res VMResult) *ipamv1.IPAddress {
+	address := &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claim.Name,
+			Namespace: claim.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "ScvmmIPPool",
+				Name:       pool.Name,
+				UID:        pool.UID,
+			}},
+		},
+		Spec: ipamv1.IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: claim.Name},
+			PoolRef:  claim.Spec.PoolRef,
+			Address:  res.Result,
+			Prefix:   res.PrefixLength,
+			Gateway:  res.Gateway,
+		},
+	}
+	if clusterName := claim.Labels[clusterv1.ClusterNameLabel]; clusterName != "" {
+		address.Labels = map[string]string{clusterv1.ClusterNameLabel: clusterName}
+	}
+	return address
+}
+
+func patchScvmmIPPool(ctx context.Context, patchHelper *patch.Helper, pool *infrav1.ScvmmIPPool) error {
+	conditions.SetSummary(pool,
+		conditions.WithConditions(
+			IPPoolReady,
+		),
+	)
+
+	return patchHelper.Patch(
+		ctx,
+		pool,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			IPPoolReady,
+		}},
+	)
+}
+
+// Map an IPAddressClaim to the ScvmmIPPool it references
+func ipAddressClaimToScvmmIPPool(ctx context.Context, o client.Object) []reconcile.Request {
+	claim, ok := o.(*ipamv1.IPAddressClaim)
+	if !ok {
+		ctrl.LoggerFrom(ctx).Error(errors.Errorf("expected a IPAddressClaim but got a %T", o), "failed to get ScvmmIPPool for IPAddressClaim")
+		return nil
+	}
+	if !isScvmmIPPoolRef(claim.Spec.PoolRef) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: claim.Namespace, Name: claim.Spec.PoolRef.Name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScvmmIPPoolReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("caps-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ScvmmIPPool{}).
+		WithOptions(options).
+		Watches(
+			&ipamv1.IPAddressClaim{},
+			handler.EnqueueRequestsFromMapFunc(ipAddressClaimToScvmmIPPool),
+		).
+		Complete(r)
+}
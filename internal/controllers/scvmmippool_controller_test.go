@@ -0,0 +1,206 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// A pool with one claim, and an scvmm that grants addresses from it
+// The returned function gives the arguments of the GrantIPAddress and RevokeIPAddress calls
+func ipPoolTestSetup(t *testing.T) (*fakeEnv, *ScvmmIPPoolReconciler, *infrav1.ScvmmIPPool, func() []string) {
+	apiGroup := infrav1.GroupVersion.Group
+	pool := &infrav1.ScvmmIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pool", UID: "pool-uid"},
+		Spec: infrav1.ScvmmIPPoolSpec{
+			ProviderRef:         testProviderRef(),
+			StaticIPAddressPool: "Static Pool",
+		},
+	}
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "vm01-eth0",
+			UID:         "claim-uid",
+			Labels:      map[string]string{clusterv1.ClusterNameLabel: "cluster"},
+			Annotations: map[string]string{HostnameAnnotation: "vm01"},
+		},
+		Spec: ipamv1.IPAddressClaimSpec{
+			PoolRef: corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "ScvmmIPPool", Name: "pool"},
+		},
+	}
+	env := newFakeEnv(t, pool, claim)
+	var mu sync.Mutex
+	addressArgs := []string{}
+	env.scvmm.handle("GetIPPool", func(string) VMResult {
+		return VMResult{Result: "10.0.0.0/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.2"}, TotalAddresses: 100, AvailableAddresses: 99}
+	})
+	env.scvmm.handle("GrantIPAddress", func(args string) VMResult {
+		mu.Lock()
+		defer mu.Unlock()
+		addressArgs = append(addressArgs, "GrantIPAddress "+args)
+		return VMResult{Result: "10.0.0.10", PrefixLength: 24, Gateway: "10.0.0.1"}
+	})
+	env.scvmm.handle("RevokeIPAddress", func(args string) VMResult {
+		mu.Lock()
+		defer mu.Unlock()
+		addressArgs = append(addressArgs, "RevokeIPAddress "+args)
+		return VMResult{Message: "Revoked 10.0.0.10"}
+	})
+
+	r := &ScvmmIPPoolReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+	return env, r, pool, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, addressArgs...)
+	}
+}
+
+func TestIPPoolGrantsClaim(t *testing.T) {
+	ctx := context.Background()
+	env, r, pool, addressArgs := ipPoolTestSetup(t)
+
+	// Add the finalizer, then grant the address
+	env.reconcile(t, r, pool, 2)
+	if !controllerutil.ContainsFinalizer(pool, IPPoolFinalizer) {
+		t.Errorf("pool finalizers = %v, want %s", pool.Finalizers, IPPoolFinalizer)
+	}
+	if !pool.Status.Ready || pool.Status.Subnet != "10.0.0.0/24" || pool.Status.Claimed != 1 {
+		t.Errorf("pool status = %+v", pool.Status)
+	}
+
+	args := addressArgs()
+	if len(args) != 1 {
+		t.Fatalf("address calls = %v, want one grant", args)
+	}
+	for _, want := range []string{"-Pool 'Static Pool'", "-Description 'vm01'"} {
+		if !strings.Contains(args[0], want) {
+			t.Errorf("%s, missing %s", args[0], want)
+		}
+	}
+
+	claim := &ipamv1.IPAddressClaim{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01-eth0"}, claim); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(args[0], "-ID '"+claimGrantID(claim)+"'") {
+		t.Errorf("%s, missing the grant id of the claim", args[0])
+	}
+	if claim.Status.AddressRef.Name != "vm01-eth0" || !conditions.IsTrue(claim, clusterv1.ReadyCondition) {
+		t.Errorf("claim status = %+v", claim.Status)
+	}
+	if !controllerutil.ContainsFinalizer(claim, IPAddressReleaseFinalizer) {
+		t.Errorf("claim finalizers = %v, want %s", claim.Finalizers, IPAddressReleaseFinalizer)
+	}
+
+	address := &ipamv1.IPAddress{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01-eth0"}, address); err != nil {
+		t.Fatal(err)
+	}
+	if address.Spec.Address != "10.0.0.10" || address.Spec.Prefix != 24 || address.Spec.Gateway != "10.0.0.1" {
+		t.Errorf("address spec = %+v", address.Spec)
+	}
+	if address.Labels[clusterv1.ClusterNameLabel] != "cluster" {
+		t.Errorf("address labels = %v", address.Labels)
+	}
+
+	// A granted claim is not granted again
+	env.reconcile(t, r, pool, 1)
+	if args := addressArgs(); len(args) != 1 {
+		t.Errorf("address calls after grant = %v", args)
+	}
+}
+
+func TestIPPoolRevokesDeletedClaim(t *testing.T) {
+	ctx := context.Background()
+	env, r, pool, addressArgs := ipPoolTestSetup(t)
+	env.reconcile(t, r, pool, 2)
+
+	claim := &ipamv1.IPAddressClaim{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01-eth0"}, claim); err != nil {
+		t.Fatal(err)
+	}
+	grantID := claimGrantID(claim)
+	if err := env.client.Delete(ctx, claim); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, pool, 1)
+
+	args := addressArgs()
+	if len(args) != 2 || args[1] != "RevokeIPAddress -ID '"+grantID+"'" {
+		t.Fatalf("address calls = %v, want a grant and a revoke of %s", args, grantID)
+	}
+	// With its finalizer removed the claim is gone
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01-eth0"}, claim); !apierrors.IsNotFound(err) {
+		t.Errorf("claim after revoke: %v, finalizers %v", err, claim.Finalizers)
+	}
+}
+
+func TestIPPoolDeletionWaitsForClaims(t *testing.T) {
+	ctx := context.Background()
+	env, r, pool, _ := ipPoolTestSetup(t)
+	env.reconcile(t, r, pool, 2)
+
+	if err := env.client.Delete(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool stays while the claim holds an address
+	env.reconcile(t, r, pool, 1)
+	if pool.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(pool, IPPoolFinalizer) {
+		t.Fatalf("pool deleted with a claim left")
+	}
+	if conditions.GetReason(pool, IPPoolReady) != IPPoolDeletingReason {
+		t.Errorf("pool condition reason = %q, want %s", conditions.GetReason(pool, IPPoolReady), IPPoolDeletingReason)
+	}
+
+	// A claim that is being moved keeps its address in scvmm
+	claim := &ipamv1.IPAddressClaim{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01-eth0"}, claim); err != nil {
+		t.Fatal(err)
+	}
+	claim.Annotations[clusterctlv1.DeleteForMoveAnnotation] = ""
+	if err := env.client.Update(ctx, claim); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.client.Delete(ctx, claim); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, pool, 1)
+	if count := env.scvmm.calledCount("RevokeIPAddress"); count != 0 {
+		t.Errorf("RevokeIPAddress called %d times for a moved claim", count)
+	}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pool"}, pool); !apierrors.IsNotFound(err) {
+		t.Errorf("pool after releasing its claims: %v, finalizers %v", err, pool.Finalizers)
+	}
+}
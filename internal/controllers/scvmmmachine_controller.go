@@ -29,6 +29,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -45,15 +47,27 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
 const (
+	// VM name is allocated from the name pool
+	NameAllocated clusterv1.ConditionType = "NameAllocated"
 	// Creation started
 	VmCreated clusterv1.ConditionType = "VmCreated"
+	// Disks have the size given in the spec
+	DisksResized clusterv1.ConditionType = "DisksResized"
+	// Tag and custom properties are set on the VM
+	PropertiesSynced clusterv1.ConditionType = "PropertiesSynced"
+	// Cloud-init device is attached to the VM
+	BootstrapMediaAttached clusterv1.ConditionType = "BootstrapMediaAttached"
+	// AD computer entry is created
+	ADComputerReady clusterv1.ConditionType = "ADComputerReady"
 	// VM running
 	VmRunning clusterv1.ConditionType = "VmRunning"
+	// Cloud-init device is detached and deleted after boot
+	BootstrapMediaRemoved clusterv1.ConditionType = "BootstrapMediaRemoved"
 
 	// Cluster-Api related statuses
 	WaitingForClusterInfrastructureReason = "WaitingForClusterInfrastructure"
@@ -71,6 +85,22 @@ const (
 	VmRunningReason  = "VmRunning"
 	VmFailedReason   = "VmFailed"
 
+	InsufficientCapacityReason = "InsufficientCapacity"
+
+	NameAllocationFailedReason = "NameAllocationFailed"
+
+	DisksResizingReason      = "DisksResizing"
+	DisksResizeFailedReason  = "DisksResizeFailed"
+	WaitingForPowerOffReason = "WaitingForPowerOff"
+
+	PropertiesSyncingReason    = "PropertiesSyncing"
+	PropertiesSyncFailedReason = "PropertiesSyncFailed"
+
+	BootstrapMediaAttachFailedReason = "BootstrapMediaAttachFailed"
+	BootstrapMediaRemoveFailedReason = "BootstrapMediaRemoveFailed"
+
+	ADComputerFailedReason = "ADComputerFailed"
+
 	MachineFinalizer = "scvmmmachine.finalizers.cluster.x-k8s.io"
 )
 
@@ -85,11 +115,20 @@ var (
 		"scsi":   "AddVHDToVM",
 		"ide":    "AddVHDToVM",
 	}
+	cloudInitRemoveFunctions = map[string]string{
+		"":       "RemoveIsoFromVM",
+		"dvd":    "RemoveIsoFromVM",
+		"floppy": "RemoveFloppyFromVM",
+		"scsi":   "RemoveVHDFromVM",
+		"ide":    "RemoveVHDFromVM",
+	}
 )
 
 // ScvmmMachineReconciler reconciles a ScvmmMachine object
 type ScvmmMachineReconciler struct {
 	client.Client
+	// Cached clients of the workload clusters
+	Tracker  *remote.ClusterCacheTracker
 	recorder record.EventRecorder
 }
 
@@ -179,6 +218,12 @@ func (r *ScvmmMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, ClusterNotAvailableReason, "")
 			}
 			scvmmMachine.Spec.ProviderRef = scvmmCluster.Spec.ProviderRef
+			// A failure domain can be on a different scvmm server
+			if machine.Spec.FailureDomain != nil {
+				if fd, ok := scvmmCluster.Spec.FailureDomains[*machine.Spec.FailureDomain]; ok && fd.ProviderRef != nil {
+					scvmmMachine.Spec.ProviderRef = fd.ProviderRef
+				}
+			}
 			// Get cloud and hostgroup from failureDomains if needed
 			if scvmmMachine.Spec.Cloud == "" || scvmmMachine.Spec.HostGroup == "" {
 				if machine.Spec.FailureDomain == nil {
@@ -196,11 +241,23 @@ func (r *ScvmmMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			}
 		}
 
+		if scvmmMachine.Spec.AutoAvailabilitySet && scvmmMachine.Spec.Id == "" {
+			scvmmMachine.Spec.AvailabilitySet = availabilitySetName(cluster, machine)
+		}
+
 		// Check if the infrastructure is ready, otherwise return and wait for the cluster object to be updated
 		if !cluster.Status.InfrastructureReady {
 			log.Info("Waiting for ScvmmCluster Controller to create cluster infrastructure")
 			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, WaitingForClusterInfrastructureReason, "")
 		}
+	} else if scvmmMachine.Labels[clusterv1.ClusterNameLabel] != "" {
+		// Standalone machines that belong to a cluster, like the ones of a ScvmmMachinePool,
+		// have no Machine, but the cluster is still needed to label their node
+		cluster, err = util.GetClusterFromMetadata(ctx, r.Client, scvmmMachine.ObjectMeta)
+		if err != nil {
+			log.V(1).Info("Cluster of standalone machine not found", "cluster", scvmmMachine.Labels[clusterv1.ClusterNameLabel], "error", err)
+			cluster = nil
+		}
 	}
 
 	log.V(1).Info("Check finalizer")
@@ -213,10 +270,35 @@ func (r *ScvmmMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	if scvmmMachine.Status.FailureReason != nil {
+		log.Info("Machine has failed permanently, skipping reconciliation", "reason", *scvmmMachine.Status.FailureReason)
+		return ctrl.Result{}, nil
+	}
+
 	// Handle non-deleted machines
 	return r.reconcileNormal(ctx, patchHelper, cluster, machine, scvmmMachine)
 }
 
+// One availability set per cluster for the control plane, and one per MachineDeployment (or MachineSet)
+func availabilitySetName(cluster *clusterv1.Cluster, machine *clusterv1.Machine) string {
+	var name string
+	switch {
+	case util.IsControlPlaneMachine(machine):
+		name = "control-plane"
+	case machine.Labels[clusterv1.MachineDeploymentNameLabel] != "":
+		name = machine.Labels[clusterv1.MachineDeploymentNameLabel]
+	case machine.Labels[clusterv1.MachineSetNameLabel] != "":
+		name = machine.Labels[clusterv1.MachineSetNameLabel]
+	default:
+		return ""
+	}
+	// Availability sets are global in SCVMM, so make them unique per cluster
+	if !strings.HasPrefix(name, cluster.Name+"-") {
+		name = cluster.Name + "-" + name
+	}
+	return name
+}
+
 func baseName(path string) string {
 	return path[strings.LastIndexAny(path, "\\/")+1:]
 }
@@ -226,6 +308,13 @@ func (r *ScvmmMachineReconciler) reconcileNormal(ctx context.Context, patchHelpe
 	ctx = ctrl.LoggerInto(ctx, log)
 
 	log.Info("Doing reconciliation of ScvmmMachine")
+	if scvmmMachine.Spec.VMNameFromPool != nil && scvmmMachine.Spec.VMName != "" {
+		// The name is already allocated, so pool bookkeeping problems should not hold up the vm
+		if err := r.reconcileVMNameInPool(ctx, scvmmMachine); err != nil {
+			log.Error(err, "Failed to register vmname in pool", "namepool", scvmmMachine.Spec.VMNameFromPool.Name)
+			r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, NameAllocationFailedReason, "Failed to register vmname in pool: %v", err)
+		}
+	}
 	vm, err := r.getVM(ctx, scvmmMachine)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -239,21 +328,52 @@ func (r *ScvmmMachineReconciler) reconcileNormal(ctx context.Context, patchHelpe
 		return ctrl.Result{}, err
 	}
 
+	// Wait for the SCVMM job started by the previous step, if any
+	if scvmmMachine.Status.Job != nil {
+		condition, runningReason, failedReason := vmJobCondition(scvmmMachine.Status.Job)
+		done, err := pollVMJob(ctx, scvmmMachine)
+		if err != nil {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, condition, failedReason, "%v", err)
+		}
+		if !done {
+			log.V(1).Info("Job running, requeue in 10 seconds", "job", scvmmMachine.Status.Job)
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, condition, runningReason, "%s %s", scvmmMachine.Status.Job.Name, scvmmMachine.Status.Job.Progress)
+		}
+		// Reread the vm to get the result of the job
+		vm, err = r.getVM(ctx, scvmmMachine)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if vm.Status == "UnderCreation" {
 		log.V(1).Info("Creating, Requeue in 15 seconds")
 		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 15, nil, VmCreated, VmCreatingReason, "")
 	}
+	if vm.Status == "CreationFailed" {
+		return r.vmCreationFailed(ctx, patchHelper, scvmmMachine)
+	}
 	log.V(1).Info("Machine is there, fill in status")
 	conditions.MarkTrue(scvmmMachine, VmCreated)
+	if scvmmMachine.Spec.VMNameFromPool != nil {
+		conditions.MarkTrue(scvmmMachine, NameAllocated)
+	}
+	setNetworkAdapterStatus(scvmmMachine, vm)
 	if vm.Status == "PowerOff" {
 		if err := r.addVMSpec(ctx, patchHelper, scvmmMachine); err != nil {
 			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed calling add spec function")
 		}
-		if vmNeedsExpandDisks(scvmmMachine, vm) {
-			return r.expandDisks(ctx, patchHelper, scvmmMachine)
+		if vmNeedsExpandDisks(scvmmMachine, vm, false) {
+			return r.expandDisks(ctx, patchHelper, scvmmMachine, false)
 		}
-		if !hasAllIPAddresses(scvmmMachine.Spec.Networking) {
-			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, WaitingForIPAddressReason, "")
+		conditions.MarkTrue(scvmmMachine, DisksResized)
+		if !hasAllIPAddresses(scvmmMachine) {
+			if conditions.IsFalse(scvmmMachine, IPAddressClaimed) {
+				// Keep the reason given by the claims
+				scvmmMachine.Status.Ready = false
+				return ctrl.Result{}, patchScvmmMachine(ctx, patchHelper, scvmmMachine)
+			}
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, IPAddressClaimed, WaitingForIPAddressReason, "Not all network devices have an ip address and gateway")
 		}
 
 		log.V(1).Info("Get provider")
@@ -268,25 +388,64 @@ func (r *ScvmmMachineReconciler) reconcileNormal(ctx context.Context, patchHelpe
 			log.Error(err, "Failed to get provider")
 			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, ProviderNotAvailableReason, "")
 		}
+		// IDE devices can only be removed when the VM is off
+		if conditions.GetReason(scvmmMachine, BootstrapMediaRemoved) == WaitingForPowerOffReason {
+			return r.removeBootstrapMedia(ctx, patchHelper, provider, scvmmMachine, ciPath)
+		}
 		if vmNeedsCloudInit(ciPath, scvmmMachine, vm) {
 			return r.addCloudInitToVM(ctx, patchHelper, cluster, machine, provider, scvmmMachine, vm, ciPath)
 		}
+		if vmHasBootstrapMedia(scvmmMachine) {
+			conditions.MarkTrue(scvmmMachine, BootstrapMediaAttached)
+		}
 		if (scvmmMachine.Spec.Tag != "" && vm.Tag != scvmmMachine.Spec.Tag) || !equalStringMap(scvmmMachine.Spec.CustomProperty, vm.CustomProperty) {
 			return r.setVMProperties(ctx, patchHelper, scvmmMachine)
 		}
+		conditions.MarkTrue(scvmmMachine, PropertiesSynced)
 		return r.startVM(ctx, patchHelper, cluster, machine, provider, scvmmMachine)
 	}
 	// Support changing properties or tags
 	if (scvmmMachine.Spec.Tag != "" && vm.Tag != scvmmMachine.Spec.Tag) || !equalStringMap(scvmmMachine.Spec.CustomProperty, vm.CustomProperty) {
 		return r.setVMProperties(ctx, patchHelper, scvmmMachine)
 	}
+	conditions.MarkTrue(scvmmMachine, PropertiesSynced)
+	// The VM was started, so the steps before that are done (also for machines started before those conditions existed)
+	if vmHasBootstrapMedia(scvmmMachine) && !conditions.Has(scvmmMachine, BootstrapMediaAttached) {
+		conditions.MarkTrue(scvmmMachine, BootstrapMediaAttached)
+	}
+	if scvmmMachine.Spec.ActiveDirectory != nil && !conditions.Has(scvmmMachine, ADComputerReady) {
+		conditions.MarkTrue(scvmmMachine, ADComputerReady)
+	}
+	// SCSI disks can be expanded while running, IDE disks have to wait for the next power off
+	if vm.Status == "Running" && vmNeedsExpandDisks(scvmmMachine, vm, true) {
+		return r.expandDisks(ctx, patchHelper, scvmmMachine, true)
+	}
+	if vmNeedsExpandDisks(scvmmMachine, vm, false) {
+		conditions.MarkFalse(scvmmMachine, DisksResized, WaitingForPowerOffReason, clusterv1.ConditionSeverityWarning, "IDE disks can only be expanded when the VM is powered off")
+	} else {
+		conditions.MarkTrue(scvmmMachine, DisksResized)
+	}
 
 	// Wait for machine to get running state
 	if vm.Status != "Running" {
 		log.V(1).Info("Not running, Requeue in 15 seconds")
 		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 15, nil, VmRunning, VmStartingReason, "")
 	}
-	return r.getVMInfo(ctx, patchHelper, scvmmMachine, vm)
+	result, err := r.getVMInfo(ctx, patchHelper, scvmmMachine, vm)
+	if err != nil {
+		return result, err
+	}
+	dnsResult, err := r.reconcileDNS(ctx, patchHelper, scvmmMachine)
+	if err != nil {
+		return dnsResult, err
+	}
+	result = util.LowestNonZeroResult(result, dnsResult)
+	nodeResult, err := r.reconcileNodeLabels(ctx, cluster, machine, scvmmMachine)
+	if err != nil {
+		r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, "NodeLabels", "%v", err)
+		return ctrl.Result{}, err
+	}
+	return util.LowestNonZeroResult(result, nodeResult), nil
 }
 
 func (r *ScvmmMachineReconciler) getVM(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) (VMResult, error) {
@@ -324,9 +483,10 @@ func (r *ScvmmMachineReconciler) createVM(ctx context.Context, patchHelper *patc
 		if spec.VMNameFromPool != nil {
 			vmName, err := r.generateVMName(ctx, scvmmMachine)
 			if err != nil {
-				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed generate vmname")
+				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, NameAllocated, NameAllocationFailedReason, "Failed generate vmname")
 			}
 			scvmmMachine.Spec.VMName = vmName
+			conditions.MarkTrue(scvmmMachine, NameAllocated)
 			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, VmCreatingReason, "Set VMName %s", vmName)
 		} else {
 			vmName = scvmmMachine.Name
@@ -366,7 +526,39 @@ func (r *ScvmmMachineReconciler) createVM(ctx context.Context, patchHelper *patc
 			memoryBuffer = *spec.DynamicMemory.BufferPercentage
 		}
 	}
-	vm, err := sendWinrmCommand(log, spec.ProviderRef, "CreateVM -Cloud '%s' -HostGroup '%s' -VMName '%s' -VMTemplate '%s' -Memory %d -MemoryMin %d -MemoryMax %d -MemoryBuffer %d -CPUCount %d -Disks '%s' -NetworkDevices '%s' -FibreChannel '%s' -HardwareProfile '%s' -OperatingSystem '%s' -AvailabilitySet '%s' -VMOptions '%s'",
+	if spec.AutoAvailabilitySet && spec.AvailabilitySet != "" {
+		_, err := sendWinrmCommand(log, spec.ProviderRef, "AddAvailabilitySet -HostGroup '%s' -Name '%s'",
+			escapeSingleQuotes(spec.HostGroup),
+			escapeSingleQuotes(spec.AvailabilitySet))
+		if err != nil {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to create availability set")
+		}
+	}
+	vmHost := ""
+	if spec.Placement != nil {
+		memoryMB := memoryFixed
+		if memoryMB < 0 {
+			memoryMB = memoryMin
+		}
+		res, err := r.placeVM(ctx, scvmmMachine, vmName, memoryMB)
+		if err != nil {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to place vm")
+		}
+		if res.Result == "InsufficientCapacity" {
+			log.Info("No host with enough capacity, requeue in 120 seconds", "message", res.Message)
+			scvmmMachine.Status.Ready = false
+			r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, InsufficientCapacityReason, "%s", res.Message)
+			conditions.MarkFalse(scvmmMachine, VmCreated, InsufficientCapacityReason, clusterv1.ConditionSeverityWarning, "%s", res.Message)
+			if err := patchScvmmMachine(ctx, patchHelper, scvmmMachine); err != nil {
+				log.Error(err, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 120}, nil
+		}
+		log.V(1).Info("Placing vm", "host", res.VMHost, "message", res.Message)
+		vmHost = res.VMHost
+	}
+	vm, err := sendWinrmCommand(log, spec.ProviderRef, "CreateVM -Cloud '%s' -HostGroup '%s' -VMName '%s' -VMTemplate '%s' -Memory %d -MemoryMin %d -MemoryMax %d -MemoryBuffer %d -CPUCount %d -Disks '%s' -NetworkDevices '%s' -FibreChannel '%s' -HardwareProfile '%s' -OperatingSystem '%s' -AvailabilitySet '%s' -VMOptions '%s' -VMHost '%s' -OSType '%s'",
 		escapeSingleQuotes(spec.Cloud),
 		escapeSingleQuotes(spec.HostGroup),
 		escapeSingleQuotes(vmName),
@@ -383,6 +575,8 @@ func (r *ScvmmMachineReconciler) createVM(ctx context.Context, patchHelper *patc
 		escapeSingleQuotes(spec.OperatingSystem),
 		escapeSingleQuotes(spec.AvailabilitySet),
 		escapeSingleQuotes(string(optionsjson)),
+		escapeSingleQuotes(vmHost),
+		escapeSingleQuotes(spec.OSType),
 	)
 	if err != nil {
 		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to create vm")
@@ -394,14 +588,45 @@ func (r *ScvmmMachineReconciler) createVM(ctx context.Context, patchHelper *patc
 		scvmmMachine.Spec.ProviderID = "scvmm://" + vm.VMId
 	}
 	scvmmMachine.Spec.Id = vm.Id
+	setVMJob(scvmmMachine, vm, VmCreated)
 	scvmmMachine.Status.Ready = false
 	scvmmMachine.Status.VMStatus = vm.Status
 	scvmmMachine.Status.BiosGuid = vm.BiosGuid
 	scvmmMachine.Status.CreationTime = vm.CreationTime
 	scvmmMachine.Status.ModifiedTime = vm.ModifiedTime
+	if vmHost != "" {
+		scvmmMachine.Status.VMHost = vmHost
+	}
 	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmCreated, VmCreatingReason, "Creating VM %s", vmName)
 }
 
+// Pick a host from the host ratings, and check if there is enough capacity
+func (r *ScvmmMachineReconciler) placeVM(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine, vmName string, memoryMB int64) (VMResult, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := scvmmMachine.Spec
+	diskSpace := int64(0)
+	for _, d := range spec.Disks {
+		if d.Size != nil {
+			diskSpace += d.Size.Value()
+		}
+	}
+	minFreeMemory := int64(0)
+	if spec.Placement.MinimumFreeMemory != nil {
+		minFreeMemory = spec.Placement.MinimumFreeMemory.Value() / 1024 / 1024
+	}
+	return sendWinrmCommand(log, spec.ProviderRef, "PlaceVM -HostGroup '%s' -VMName '%s' -VMTemplate '%s' -HardwareProfile '%s' -Memory %d -DiskSpaceGB %f -PreferredHosts @(%s) -ExcludedHosts @(%s) -MinFreeMemory %d",
+		escapeSingleQuotes(spec.HostGroup),
+		escapeSingleQuotes(vmName),
+		escapeSingleQuotes(spec.VMTemplate),
+		escapeSingleQuotes(spec.HardwareProfile),
+		max(memoryMB, 0),
+		float64(diskSpace)/1024/1024/1024,
+		escapeSingleQuotesArray(spec.Placement.PreferredHosts),
+		escapeSingleQuotesArray(spec.Placement.ExcludedHosts),
+		minFreeMemory,
+	)
+}
+
 func (r *ScvmmMachineReconciler) setVMProperties(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	custompropertyjson, err := json.Marshal(scvmmMachine.Spec.CustomProperty)
@@ -413,17 +638,23 @@ func (r *ScvmmMachineReconciler) setVMProperties(ctx context.Context, patchHelpe
 		)
 	}
 	if err != nil {
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to set vm properties")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, PropertiesSynced, PropertiesSyncFailedReason, "Failed to set vm properties")
 	}
-	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmCreated, VmUpdatingReason, "Setting properties")
+	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, PropertiesSynced, PropertiesSyncingReason, "Setting properties")
+}
+
+// If there is an empty bootstrap section, the VM gets no cloud-init device
+func vmHasBootstrapMedia(scvmmMachine *infrav1.ScvmmMachine) bool {
+	return scvmmMachine.Spec.Bootstrap == nil || scvmmMachine.Spec.Bootstrap.DataSecretName != nil
 }
 
 func vmNeedsCloudInit(ciPath string, scvmmMachine *infrav1.ScvmmMachine, vm VMResult) bool {
-	// If there is an empty bootstrap section, don't add an iso
-	if scvmmMachine.Spec.Bootstrap != nil {
-		if scvmmMachine.Spec.Bootstrap.DataSecretName == nil {
-			return false
-		}
+	if !vmHasBootstrapMedia(scvmmMachine) {
+		return false
+	}
+	// Don't put it back after it was removed
+	if conditions.IsTrue(scvmmMachine, BootstrapMediaRemoved) {
+		return false
 	}
 	ciBase := baseName(ciPath)
 	for _, iso := range vm.ISOs {
@@ -455,45 +686,63 @@ func (r *ScvmmMachineReconciler) addVMSpec(ctx context.Context, patchHelper *pat
 	return nil
 }
 
-func vmNeedsExpandDisks(scvmmMachine *infrav1.ScvmmMachine, vm VMResult) bool {
+// Check if any of the disks in the spec is bigger than the vm disk
+// When online is set, only SCSI disks are considered because those can be expanded while running
+func vmNeedsExpandDisks(scvmmMachine *infrav1.ScvmmMachine, vm VMResult, online bool) bool {
 	for i, d := range scvmmMachine.Spec.Disks {
-		// For rounding errors
-		if d.Size != nil && vm.VirtualDisks[i].MaximumSize < (d.Size.Value()-1024*1024) {
-			return true
+		if d.Size == nil {
+			continue
+		}
+		// Spec disks are attached on bus 0 with the index as lun
+		for _, vhd := range vm.VirtualDisks {
+			if vhd.Bus != 0 || vhd.Lun != i {
+				continue
+			}
+			if online && vhd.BusType != "SCSI" {
+				continue
+			}
+			// For rounding errors
+			if vhd.MaximumSize < (d.Size.Value() - 1024*1024) {
+				return true
+			}
 		}
 	}
 	return false
 }
 
-func (r *ScvmmMachineReconciler) expandDisks(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+func (r *ScvmmMachineReconciler) expandDisks(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine, online bool) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	spec := scvmmMachine.Spec
 	diskjson, err := makeDisksJSON(spec.Disks)
 	var vm VMResult
 	if err == nil {
-		vm, err = sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "ExpandVMDisks -ID '%s' -Disks '%s'",
+		onlineSwitch := ""
+		if online {
+			onlineSwitch = " -Online"
+		}
+		vm, err = sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "ExpandVMDisks -ID '%s' -Disks '%s'"+onlineSwitch,
 			escapeSingleQuotes(scvmmMachine.Spec.Id),
 			escapeSingleQuotes(string(diskjson)))
 	}
 	if err != nil {
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to expand disks")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, DisksResized, DisksResizeFailedReason, "Failed to expand disks")
 	}
-	scvmmMachine.Status.Ready = false
+	setVMJob(scvmmMachine, vm, DisksResized)
 	scvmmMachine.Status.VMStatus = vm.Status
 	scvmmMachine.Status.BiosGuid = vm.BiosGuid
 	scvmmMachine.Status.CreationTime = vm.CreationTime
 	scvmmMachine.Status.ModifiedTime = vm.ModifiedTime
-	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmCreated, VmUpdatingReason, "Updating Disks")
+	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, DisksResized, DisksResizingReason, "%s", vm.Message)
 }
 
 func (r *ScvmmMachineReconciler) addCloudInitToVM(ctx context.Context, patchHelper *patch.Helper, cluster *clusterv1.Cluster, machine *clusterv1.Machine, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine, vm VMResult, ciPath string) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
-	var bootstrapData, metaData, networkConfig []byte
+	var metaData, networkConfig []byte
 	var err error
 	deviceFunction, ok := cloudInitDeviceTypeFunctions[provider.CloudInit.DeviceType]
 	if !ok {
 		log.Error(err, "Unknown devicetype "+provider.CloudInit.DeviceType)
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, WaitingForBootstrapDataReason, "Unknown devicetype "+provider.CloudInit.DeviceType)
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaAttached, BootstrapMediaAttachFailedReason, "Unknown devicetype "+provider.CloudInit.DeviceType)
 	}
 	dataSecretName := ""
 	dataSecretNamespace := ""
@@ -508,10 +757,10 @@ func (r *ScvmmMachineReconciler) addCloudInitToVM(ctx context.Context, patchHelp
 		if machine.Spec.Bootstrap.DataSecretName == nil {
 			if !util.IsControlPlaneMachine(machine) && !conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition) {
 				log.Info("Waiting for the control plane to be initialized")
-				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, WaitingForControlPlaneAvailableReason, "")
+				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, BootstrapMediaAttached, WaitingForControlPlaneAvailableReason, "")
 			}
 			log.Info("Waiting for the Bootstrap provider controller to set bootstrap data")
-			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, VmCreated, WaitingForBootstrapDataReason, "")
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, nil, BootstrapMediaAttached, WaitingForBootstrapDataReason, "")
 		}
 		dataSecretName = *machine.Spec.Bootstrap.DataSecretName
 		dataSecretNamespace = machine.Namespace
@@ -521,15 +770,23 @@ func (r *ScvmmMachineReconciler) addCloudInitToVM(ctx context.Context, patchHelp
 	}
 
 	log.V(1).Info("Get bootstrap data")
-	bootstrapData, err = r.getBootstrapData(ctx, dataSecretName, dataSecretNamespace)
+	bootstrapData, format, err := r.getBootstrapData(ctx, dataSecretName, dataSecretNamespace)
 	if err != nil {
 		log.Error(err, "failed to get bootstrap data")
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, WaitingForBootstrapDataReason, "Failed to get bootstrap data")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaAttached, WaitingForBootstrapDataReason, "Failed to get bootstrap data")
+	}
+	domainJoin, err := provisionADJoin(ctx, provider, scvmmMachine)
+	if err != nil {
+		log.Error(err, "failed to provision domain join")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, ADComputerReady, ADComputerFailedReason, "Failed to provision domain join")
+	}
+	if domainJoin != "" {
+		conditions.MarkTrue(scvmmMachine, ADComputerReady)
 	}
 	log.V(1).Info("Create cloudinit")
-	if err := writeCloudInit(log, scvmmMachine, provider, vm.VMId, ciPath, bootstrapData, metaData, networkConfig); err != nil {
+	if err := writeCloudInit(log, scvmmMachine, provider, vm, ciPath, format, bootstrapData, metaData, networkConfig, domainJoin); err != nil {
 		log.Error(err, "failed to create cloud init")
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, WaitingForBootstrapDataReason, "Failed to create cloud init data")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaAttached, BootstrapMediaAttachFailedReason, "Failed to create cloud init data")
 	}
 	conditions.MarkFalse(scvmmMachine, VmRunning, VmStartingReason, clusterv1.ConditionSeverityInfo, "")
 	if err := patchScvmmMachine(ctx, patchHelper, scvmmMachine); err != nil {
@@ -542,61 +799,159 @@ func (r *ScvmmMachineReconciler) addCloudInitToVM(ctx context.Context, patchHelp
 		escapeSingleQuotes(ciPath),
 		escapeSingleQuotes(provider.CloudInit.DeviceType))
 	if err != nil {
-		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, WaitingForBootstrapDataReason, "Failed to add iso to vm")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaAttached, BootstrapMediaAttachFailedReason, "Failed to add iso to vm")
 	}
-	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmCreated, VmCreatingReason, "Adding ISO to VM %s", vm.Name)
+	conditions.MarkTrue(scvmmMachine, BootstrapMediaAttached)
+	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmRunning, VmStartingReason, "Added ISO to VM %s", vm.Name)
 }
 
 func (r *ScvmmMachineReconciler) startVM(ctx context.Context, patchHelper *patch.Helper, cluster *clusterv1.Cluster, machine *clusterv1.Machine, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	// Add adcomputer here, because we now know the vmname will not change
 	// (a VM with the cloud-init iso connected is prio 1 in vmname clash resolution)
-	var err error
-	adspec := scvmmMachine.Spec.ActiveDirectory
-	if adspec != nil {
-		domaincontroller := adspec.DomainController
-		if domaincontroller == "" {
-			domaincontroller = provider.ADServer
-		}
-		_, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "CreateADComputer -Name '%s' -OUPath '%s' -DomainController '%s' -Description '%s' -MemberOf @(%s)",
-			escapeSingleQuotes(scvmmMachine.Spec.VMName),
-			escapeSingleQuotes(adspec.OUPath),
-			escapeSingleQuotes(domaincontroller),
-			escapeSingleQuotes(adspec.Description),
-			escapeSingleQuotesArray(adspec.MemberOf))
-		if err != nil {
-			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to create AD entry")
-		}
+	if err := createADComputer(ctx, provider, scvmmMachine); err != nil {
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, ADComputerReady, ADComputerFailedReason, "Failed to create AD entry")
+	}
+	if scvmmMachine.Spec.ActiveDirectory != nil {
+		conditions.MarkTrue(scvmmMachine, ADComputerReady)
 	}
 	vm, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "StartVM -ID '%s'",
 		escapeSingleQuotes(scvmmMachine.Spec.Id))
 	if err != nil {
-		return ctrl.Result{}, errors.Wrap(err, "Failed to start vm")
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmRunning, VmFailedReason, "Failed to start vm")
 	}
 	scvmmMachine.Status.VMStatus = vm.Status
-	if err := patchScvmmMachine(ctx, patchHelper, scvmmMachine); err != nil {
-		log.Error(err, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
-		return ctrl.Result{}, err
-	}
-	r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, VmStartingReason, "Powering on %s", vm.Name)
+	setVMJob(scvmmMachine, vm, VmRunning)
 	log.V(1).Info("Requeue in 10 seconds")
-	return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+	return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmRunning, VmStartingReason, "Powering on %s", vm.Name)
+}
+
+// SCVMM leaves the VM behind in CreationFailed when the create job fails, and retrying will not fix that
+// The failure is terminal, so the owner can replace the machine; the VM is removed when the machine is deleted
+func (r *ScvmmMachineReconciler) vmCreationFailed(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	message := "VM creation failed"
+	if conditions.GetReason(scvmmMachine, VmCreated) == VmFailedReason {
+		// Keep the error of the failed create job
+		message = conditions.GetMessage(scvmmMachine, VmCreated)
+	}
+	failureReason := capierrors.CreateMachineError
+	scvmmMachine.Status.FailureReason = &failureReason
+	scvmmMachine.Status.FailureMessage = &message
+	scvmmMachine.Status.Ready = false
+	conditions.MarkFalse(scvmmMachine, VmCreated, VmFailedReason, clusterv1.ConditionSeverityError, "%s", message)
+	r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, VmFailedReason, "%s", message)
+	log.Info("VM creation failed, not requeueing", "message", message)
+	return ctrl.Result{}, patchScvmmMachine(ctx, patchHelper, scvmmMachine)
+}
+
+// Report the virtual network adapters as SCVMM configured them,
+// so the MAC addresses are known before the VM boots, together with
+// the addresses SCVMM granted from its static IP address pools
+func setNetworkAdapterStatus(scvmmMachine *infrav1.ScvmmMachine, vm VMResult) {
+	if vm.NetworkAdapters == nil {
+		return
+	}
+	adapters := make([]infrav1.NetworkAdapterStatus, len(vm.NetworkAdapters))
+	for i, nic := range vm.NetworkAdapters {
+		adapters[i] = infrav1.NetworkAdapterStatus{
+			MACAddress:         nic.MACAddress,
+			MACAddressType:     nic.MACAddressType,
+			VMNetwork:          nic.VMNetwork,
+			VMSubnet:           nic.VMSubnet,
+			VLanID:             nic.VLanID,
+			PortClassification: nic.PortClassification,
+		}
+		if networking := scvmmMachine.Spec.Networking; networking != nil && nic.SlotId < len(networking.Devices) {
+			adapters[i].DeviceName = networkDeviceName(networking.Devices[nic.SlotId], nic.SlotId)
+		}
+		for _, ip := range nic.StaticIPAddresses {
+			adapters[i].StaticIPAddresses = append(adapters[i].StaticIPAddresses, ip.Address)
+		}
+		if len(nic.StaticIPAddresses) > 0 {
+			pool := nic.StaticIPAddresses[0]
+			adapters[i].StaticGateway = pool.Gateway
+			adapters[i].StaticNameservers = pool.DNSServers
+			adapters[i].StaticSearchDomains = pool.DNSSearchSuffixes
+		}
+	}
+	scvmmMachine.Status.NetworkAdapters = adapters
+}
+
+// Create the AD computer entry, if there is an activeDirectory spec
+func createADComputer(ctx context.Context, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) error {
+	adspec := scvmmMachine.Spec.ActiveDirectory
+	if adspec == nil {
+		return nil
+	}
+	domaincontroller := adspec.DomainController
+	if domaincontroller == "" {
+		domaincontroller = provider.ADServer
+	}
+	_, err := sendWinrmCommand(ctrl.LoggerFrom(ctx), scvmmMachine.Spec.ProviderRef, "CreateADComputer -Name '%s' -OUPath '%s' -DomainController '%s' -Description '%s' -MemberOf @(%s)",
+		escapeSingleQuotes(scvmmMachine.Spec.VMName),
+		escapeSingleQuotes(adspec.OUPath),
+		escapeSingleQuotes(domaincontroller),
+		escapeSingleQuotes(adspec.Description),
+		escapeSingleQuotesArray(adspec.MemberOf))
+	return err
+}
+
+// Windows guests join the domain offline, with a djoin blob for the (precreated) AD computer entry
+// Returns the base64 encoded blob, or nothing if the machine does not need to join a domain
+func provisionADJoin(ctx context.Context, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) (string, error) {
+	adspec := scvmmMachine.Spec.ActiveDirectory
+	if adspec == nil || scvmmMachine.Spec.OSType != infrav1.OSTypeWindows {
+		return "", nil
+	}
+	domain := domainFromOUPath(adspec.OUPath)
+	if domain == "" {
+		return "", fmt.Errorf("no domain components in activeDirectory.ouPath %s", adspec.OUPath)
+	}
+	if err := createADComputer(ctx, provider, scvmmMachine); err != nil {
+		return "", err
+	}
+	domaincontroller := adspec.DomainController
+	if domaincontroller == "" {
+		domaincontroller = provider.ADServer
+	}
+	res, err := sendWinrmCommand(ctrl.LoggerFrom(ctx), scvmmMachine.Spec.ProviderRef, "ProvisionADJoin -Name '%s' -Domain '%s' -OUPath '%s' -DomainController '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.VMName),
+		escapeSingleQuotes(domain),
+		escapeSingleQuotes(adspec.OUPath),
+		escapeSingleQuotes(domaincontroller))
+	if err != nil {
+		return "", err
+	}
+	if res.Result == "" {
+		return "", fmt.Errorf("ProvisionADJoin returned no blob")
+	}
+	return res.Result, nil
 }
 
 func (r *ScvmmMachineReconciler) getVMInfo(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine, vm VMResult) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
-	if vm.IPv4Addresses != nil {
-		scvmmMachine.Status.Addresses = make([]clusterv1.MachineAddress, len(vm.IPv4Addresses))
-		for i := range vm.IPv4Addresses {
-			scvmmMachine.Status.Addresses[i] = clusterv1.MachineAddress{
+	if vm.IPv4Addresses != nil || vm.IPv6Addresses != nil {
+		scvmmMachine.Status.Addresses = make([]clusterv1.MachineAddress, 0, len(vm.IPv4Addresses)+len(vm.IPv6Addresses))
+		for _, address := range append(vm.IPv4Addresses, vm.IPv6Addresses...) {
+			scvmmMachine.Status.Addresses = append(scvmmMachine.Status.Addresses, clusterv1.MachineAddress{
 				Type:    clusterv1.MachineInternalIP,
-				Address: vm.IPv4Addresses[i],
-			}
+				Address: address,
+			})
 		}
 	}
 	if vm.Hostname != "" {
 		scvmmMachine.Status.Hostname = vm.Hostname
 	}
+	if vm.VMHost != "" {
+		scvmmMachine.Status.VMHost = vm.VMHost
+	}
+	if vm.Cloud != "" {
+		scvmmMachine.Status.Cloud = vm.Cloud
+	}
+	if vm.HostGroup != "" {
+		scvmmMachine.Status.HostGroup = vm.HostGroup
+	}
 	log.V(1).Info("Running, set status true")
 	scvmmMachine.Status.Ready = true
 	conditions.MarkTrue(scvmmMachine, VmRunning)
@@ -604,7 +959,7 @@ func (r *ScvmmMachineReconciler) getVMInfo(ctx context.Context, patchHelper *pat
 		log.Error(err, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
 		return ctrl.Result{}, err
 	}
-	if vm.IPv4Addresses == nil || vm.Hostname == "" {
+	if (vm.IPv4Addresses == nil && vm.IPv6Addresses == nil) || vm.Hostname == "" {
 		vm, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "ReadVM -ID '%s'",
 			escapeSingleQuotes(scvmmMachine.Spec.Id))
 		if err != nil {
@@ -614,8 +969,104 @@ func (r *ScvmmMachineReconciler) getVMInfo(ctx context.Context, patchHelper *pat
 		r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, VmRunningReason, "Waiting for IP of %s", vm.Name)
 		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
 	}
+	if vmNeedsBootstrapMediaRemoved(scvmmMachine) {
+		provider, err := getProvider(scvmmMachine.Spec.ProviderRef)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if cloudInitDetachAfterBoot(provider, scvmmMachine) {
+			ciPath, err := cloudInitPath(ctx, provider, scvmmMachine)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return r.removeBootstrapMedia(ctx, patchHelper, provider, scvmmMachine, ciPath)
+		}
+	}
 	r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, VmRunningReason, "VM %s up and running", vm.Name)
 	log.V(1).Info("Done")
+	// Keep checking for the VM to be powered off, to detach the IDE disk then
+	if conditions.GetReason(scvmmMachine, BootstrapMediaRemoved) == WaitingForPowerOffReason {
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// Delete the cloud-init file of a deleted machine from the library share, it contains secrets
+func (r *ScvmmMachineReconciler) removeCloudInitFile(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) error {
+	log := ctrl.LoggerFrom(ctx)
+	if scvmmMachine.Spec.VMName == "" {
+		return nil
+	}
+	provider, err := getProvider(scvmmMachine.Spec.ProviderRef)
+	if err != nil {
+		// Without a provider there is no way to reach the share, leave it to the janitor
+		log.Info("Provider not available, not removing cloud-init file", "error", err)
+		return nil
+	}
+	ciPath, err := cloudInitPath(ctx, provider, scvmmMachine)
+	if err != nil {
+		return err
+	}
+	return removeCloudInit(log, provider, ciPath)
+}
+
+// Check if the VM has booted with cloud-init media which have not been removed yet
+func vmNeedsBootstrapMediaRemoved(scvmmMachine *infrav1.ScvmmMachine) bool {
+	if !vmHasBootstrapMedia(scvmmMachine) {
+		return false
+	}
+	if conditions.IsTrue(scvmmMachine, BootstrapMediaRemoved) {
+		return false
+	}
+	// IDE devices will be removed when the VM is off
+	return conditions.GetReason(scvmmMachine, BootstrapMediaRemoved) != WaitingForPowerOffReason
+}
+
+func cloudInitDetachAfterBoot(provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine) bool {
+	if scvmmMachine.Spec.CloudInit != nil && scvmmMachine.Spec.CloudInit.DetachAfterBoot != nil {
+		return *scvmmMachine.Spec.CloudInit.DetachAfterBoot
+	}
+	return provider.CloudInit.DetachAfterBoot
+}
+
+// Detach the cloud-init device from the vm and delete the file from the library share
+// The cloud-init data contains the join token, so it should not stay around
+func (r *ScvmmMachineReconciler) removeBootstrapMedia(ctx context.Context, patchHelper *patch.Helper, provider *infrav1.ScvmmProviderSpec, scvmmMachine *infrav1.ScvmmMachine, ciPath string) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	deviceFunction, ok := cloudInitRemoveFunctions[provider.CloudInit.DeviceType]
+	if !ok {
+		err := fmt.Errorf("Unknown devicetype " + provider.CloudInit.DeviceType)
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaRemoved, BootstrapMediaRemoveFailedReason, "Unknown devicetype "+provider.CloudInit.DeviceType)
+	}
+	log.V(1).Info("Removing bootstrap media", "path", ciPath)
+	res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, deviceFunction+" -ID '%s' -CIPath '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id),
+		escapeSingleQuotes(ciPath))
+	if err != nil {
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, BootstrapMediaRemoved, BootstrapMediaRemoveFailedReason, "Failed to detach bootstrap media")
+	}
+	if res.Result == "NeedsPowerOff" {
+		// SCVMM copied the disk to the host, so the copy on the library share is not in use and can go already
+		if err := removeCloudInit(log, provider, ciPath); err != nil {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 60, err, BootstrapMediaRemoved, BootstrapMediaRemoveFailedReason, "Failed to delete bootstrap media from library share")
+		}
+		conditions.MarkFalse(scvmmMachine, BootstrapMediaRemoved, WaitingForPowerOffReason, clusterv1.ConditionSeverityWarning, "%s", res.Message)
+		if err := patchScvmmMachine(ctx, patchHelper, scvmmMachine); err != nil {
+			log.Error(err, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
+			return ctrl.Result{}, err
+		}
+		r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, WaitingForPowerOffReason, "Deleted bootstrap media of %s from the library share, but %s", scvmmMachine.Spec.VMName, res.Message)
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+	}
+	if err := removeCloudInit(log, provider, ciPath); err != nil {
+		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 60, err, BootstrapMediaRemoved, BootstrapMediaRemoveFailedReason, "Failed to delete bootstrap media from library share")
+	}
+	conditions.MarkTrue(scvmmMachine, BootstrapMediaRemoved)
+	if err := patchScvmmMachine(ctx, patchHelper, scvmmMachine); err != nil {
+		log.Error(err, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, VmRunningReason, "Removed bootstrap media from %s", scvmmMachine.Spec.VMName)
 	return ctrl.Result{}, nil
 }
 
@@ -661,63 +1112,126 @@ func (r *ScvmmMachineReconciler) generateVMName(ctx context.Context, scvmmMachin
 	if err := r.Get(ctx, poolName, scvmmNamePool); err != nil {
 		return "", err
 	}
-	log.V(1).Info("Retrieving vmnames from ScvmmMachines", "namespace", scvmmNamePool.Namespace)
-	if scvmmNamePool.Status.VMNameOwners == nil {
-		scvmmNamePool.Status.VMNameOwners = make(map[string]string)
-	}
-	owners := scvmmNamePool.Status.VMNameOwners
-	for vmName, owner := range owners {
+	for vmName, owner := range scvmmNamePool.Status.VMNameOwners {
 		if owner == scvmmMachine.Name {
 			return vmName, nil
 		}
 	}
+	log.V(1).Info("Retrieving vmnames from ScvmmMachines", "namespace", scvmmNamePool.Namespace)
 	vmList := &infrav1.ScvmmMachineList{}
 	if err := r.List(ctx, vmList, client.InNamespace(scvmmNamePool.Namespace)); err != nil {
 		return "", err
 	}
-	for _, vm := range vmList.Items {
+	rebuildVMNameOwners(scvmmNamePool, vmList.Items)
+	vmName := firstFreeVMName(scvmmNamePool)
+	if vmName != "" {
+		scvmmNamePool.Status.VMNameOwners[vmName] = scvmmMachine.Name
+		log.V(1).Info("Registering vmname in pool status", "vmnameOwners", scvmmNamePool.Status.VMNameOwners, "namepool", scvmmNamePool, "name", vmName, "owner", scvmmMachine.Name)
+	}
+	scvmmNamePool.Status.Counts = vmNamePoolCounts(scvmmNamePool)
+	if err := r.Client.Status().Update(ctx, scvmmNamePool); err != nil {
+		log.Error(err, "Failed to patch scvmmNamePool", "scvmmnamepool", scvmmNamePool)
+		return "", err
+	}
+	if vmName == "" {
+		return "", fmt.Errorf("All vmnames in range %s claimed", poolName.Name)
+	}
+	return vmName, nil
+}
+
+// Make sure the name pool has the vmname of the machine registered
+// The pool status is not kept by clusterctl move, so it is rebuilt from the ScvmmMachines when needed
+func (r *ScvmmMachineReconciler) reconcileVMNameInPool(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine) error {
+	log := ctrl.LoggerFrom(ctx)
+	poolName := client.ObjectKey{Namespace: scvmmMachine.Namespace, Name: scvmmMachine.Spec.VMNameFromPool.Name}
+	scvmmNamePool := &infrav1.ScvmmNamePool{}
+	if err := r.Get(ctx, poolName, scvmmNamePool); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Deleted, or not moved (yet), there is nothing to register the name in
+			log.V(1).Info("Name pool not found, not registering vmname", "namepool", poolName)
+			return nil
+		}
+		return err
+	}
+	if scvmmNamePool.Status.VMNameOwners[scvmmMachine.Spec.VMName] == scvmmMachine.Name {
+		return nil
+	}
+	vmList := &infrav1.ScvmmMachineList{}
+	if err := r.List(ctx, vmList, client.InNamespace(scvmmNamePool.Namespace)); err != nil {
+		return err
+	}
+	if !rebuildVMNameOwners(scvmmNamePool, vmList.Items) {
+		return nil
+	}
+	scvmmNamePool.Status.Counts = vmNamePoolCounts(scvmmNamePool)
+	log.V(1).Info("Rebuilt pool status", "namepool", poolName, "vmnameOwners", scvmmNamePool.Status.VMNameOwners)
+	return r.Client.Status().Update(ctx, scvmmNamePool)
+}
+
+// Register the vmnames of the ScvmmMachines that are in the ranges of the pool, if they are not there yet
+// Returns true if any owner was added
+func rebuildVMNameOwners(scvmmNamePool *infrav1.ScvmmNamePool, scvmmMachines []infrav1.ScvmmMachine) bool {
+	if scvmmNamePool.Status.VMNameOwners == nil {
+		scvmmNamePool.Status.VMNameOwners = make(map[string]string)
+	}
+	owners := scvmmNamePool.Status.VMNameOwners
+	changed := false
+	for _, vm := range scvmmMachines {
 		if vm.Spec.VMName != "" && owners[vm.Spec.VMName] == "" {
 			for _, nameRange := range scvmmNamePool.Spec.VMNameRanges {
-				if (nameRange.End == "" && nameRange.Start == vm.Spec.VMName) ||
-					(nameRange.Start <= vm.Spec.VMName && nameRange.End >= vm.Spec.VMName) {
+				if (nameRange.To == "" && nameRange.From == vm.Spec.VMName) ||
+					(nameRange.From <= vm.Spec.VMName && nameRange.To >= vm.Spec.VMName) {
 					owners[vm.Spec.VMName] = vm.Name
+					changed = true
 					break
 				}
 			}
 		}
 	}
+	return changed
+}
+
+// The first name in the ranges of the pool that has no owner
+func firstFreeVMName(scvmmNamePool *infrav1.ScvmmNamePool) string {
 	vmName := ""
+	forEachVMName(scvmmNamePool, func(candidate string) bool {
+		if scvmmNamePool.Status.VMNameOwners[candidate] == "" {
+			vmName = candidate
+			return false
+		}
+		return true
+	})
+	return vmName
+}
+
+func vmNamePoolCounts(scvmmNamePool *infrav1.ScvmmNamePool) *infrav1.ScvmmPoolCounts {
 	counts := &infrav1.ScvmmPoolCounts{}
+	forEachVMName(scvmmNamePool, func(candidate string) bool {
+		counts.Total++
+		if scvmmNamePool.Status.VMNameOwners[candidate] == "" {
+			counts.Free++
+		} else {
+			counts.Used++
+		}
+		return true
+	})
+	return counts
+}
+
+// Call fn for all names in the ranges of the pool, until it returns false
+func forEachVMName(scvmmNamePool *infrav1.ScvmmNamePool, fn func(string) bool) {
 	for _, nameRange := range scvmmNamePool.Spec.VMNameRanges {
-		candidate := nameRange.Start
+		candidate := nameRange.From
 		for {
-			if vmName == "" && owners[candidate] == "" {
-				vmName = candidate
-				owners[candidate] = scvmmMachine.Name
-				log.V(1).Info("Registering vmname in pool status", "vmnameOwners", scvmmNamePool.Status.VMNameOwners, "namepool", scvmmNamePool, "name", vmName, "owner", scvmmMachine.Name)
-			}
-			counts.Total++
-			if owners[candidate] == "" {
-				counts.Free++
-			} else {
-				counts.Used++
+			if !fn(candidate) {
+				return
 			}
 			candidate = incrementString(candidate)
-			if nameRange.End == "" || candidate > nameRange.End {
+			if nameRange.To == "" || candidate > nameRange.To {
 				break
 			}
 		}
 	}
-
-	scvmmNamePool.Status.Counts = counts
-	if err := r.Client.Status().Update(ctx, scvmmNamePool); err != nil {
-		log.Error(err, "Failed to patch scvmmNamePool", "scvmmnamepool", scvmmNamePool)
-		return "", err
-	}
-	if vmName == "" {
-		return "", fmt.Errorf("All vmnames in range %s claimed", poolName.Name)
-	}
-	return vmName, nil
 }
 
 // Increment a string as if it's a number, digits remain digits and letters remain letters.
@@ -810,6 +1324,17 @@ func (r *ScvmmMachineReconciler) reconcileDelete(ctx context.Context, patchHelpe
 		return ctrl.Result{}, errors.Wrap(err, "failed to patch ScvmmMachine")
 	}
 
+	// Wait for the running job (stopping or removing the vm) to finish first
+	if scvmmMachine.Status.Job != nil {
+		done, err := pollVMJob(ctx, scvmmMachine)
+		if err != nil {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "%v", err)
+		}
+		if !done {
+			return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 10, nil, VmCreated, VmDeletingReason, "%s %s", scvmmMachine.Status.Job.Name, scvmmMachine.Status.Job.Progress)
+		}
+	}
+
 	log.Info("Doing removal of ScvmmMachine")
 
 	vm, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "RemoveVM -ID '%s'",
@@ -829,6 +1354,27 @@ func (r *ScvmmMachineReconciler) reconcileDelete(ctx context.Context, patchHelpe
 				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to remove AD entry")
 			}
 		}
+		if dns := scvmmMachine.Status.DNS; dns != nil {
+			r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, VmDeletingReason, "Removing DNS records %s.%s", dns.Name, dns.Zone)
+			if err := removeDNSRecords(ctx, scvmmMachine); err != nil {
+				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to remove DNS records")
+			}
+			scvmmMachine.Status.DNS = nil
+		}
+		if scvmmMachine.Spec.AutoAvailabilitySet && scvmmMachine.Spec.AvailabilitySet != "" {
+			// Only actually removed when this was the last vm in it
+			_, err = sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "RemoveAvailabilitySet -HostGroup '%s' -Name '%s'",
+				escapeSingleQuotes(scvmmMachine.Spec.HostGroup),
+				escapeSingleQuotes(scvmmMachine.Spec.AvailabilitySet))
+			if err != nil {
+				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to remove availability set")
+			}
+		}
+		if !conditions.IsTrue(scvmmMachine, BootstrapMediaRemoved) {
+			if err := r.removeCloudInitFile(ctx, scvmmMachine); err != nil {
+				return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 0, err, VmCreated, VmFailedReason, "Failed to remove cloud-init file")
+			}
+		}
 		if scvmmMachine.Spec.VMNameFromPool != nil {
 			log.V(1).Info("Remove namepool reference")
 			if err := r.removeVMNameInPool(ctx, scvmmMachine); err != nil {
@@ -851,6 +1397,7 @@ func (r *ScvmmMachineReconciler) reconcileDelete(ctx context.Context, patchHelpe
 		scvmmMachine.Status.VMStatus = vm.Status
 		scvmmMachine.Status.CreationTime = vm.CreationTime
 		scvmmMachine.Status.ModifiedTime = vm.ModifiedTime
+		setVMJob(scvmmMachine, vm, VmCreated)
 		log.V(1).Info("Requeue after 15 seconds")
 		return r.patchReasonCondition(ctx, patchHelper, scvmmMachine, 15, nil, VmCreated, VmDeletingReason, "%s %s", vm.Status, scvmmMachine.Spec.VMName)
 	}
@@ -859,6 +1406,12 @@ func (r *ScvmmMachineReconciler) reconcileDelete(ctx context.Context, patchHelpe
 func (r *ScvmmMachineReconciler) patchReasonCondition(ctx context.Context, patchHelper *patch.Helper, scvmmMachine *infrav1.ScvmmMachine, requeue int, err error, condition clusterv1.ConditionType, reason string, message string, messageargs ...interface{}) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	scvmmMachine.Status.Ready = false
+	var failureReason capierrors.MachineStatusError
+	permanent := false
+	// Deleting keeps retrying, whatever the error, or the finalizer would never be removed
+	if scvmmMachine.DeletionTimestamp.IsZero() {
+		failureReason, permanent = permanentFailureReason(err)
+	}
 	if err != nil {
 		if message != "" {
 			r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, reason, message, messageargs...)
@@ -866,6 +1419,11 @@ func (r *ScvmmMachineReconciler) patchReasonCondition(ctx context.Context, patch
 			r.recorder.Eventf(scvmmMachine, corev1.EventTypeWarning, reason, "%v", err)
 		}
 		conditions.MarkFalse(scvmmMachine, condition, reason, clusterv1.ConditionSeverityError, message, messageargs...)
+		if permanent {
+			failureMessage := err.Error()
+			scvmmMachine.Status.FailureReason = &failureReason
+			scvmmMachine.Status.FailureMessage = &failureMessage
+		}
 	} else {
 		if message != "" {
 			r.recorder.Eventf(scvmmMachine, corev1.EventTypeNormal, reason, message, messageargs...)
@@ -875,12 +1433,18 @@ func (r *ScvmmMachineReconciler) patchReasonCondition(ctx context.Context, patch
 	if perr := patchScvmmMachine(ctx, patchHelper, scvmmMachine); perr != nil {
 		log.Error(perr, "Failed to patch scvmmMachine", "scvmmmachine", scvmmMachine)
 	}
+	if permanent {
+		// Retrying will not help, so leave it to the owner (or a MachineHealthCheck) to replace the machine
+		log.Error(err, "Permanent failure, not requeueing", "reason", failureReason)
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		scriptError := &ScriptError{}
-		if !errors.As(err, &scriptError) {
+		jobError := &JobFailedError{}
+		if !errors.As(err, &scriptError) && !errors.As(err, &jobError) {
 			return ctrl.Result{}, errors.Wrap(err, reason)
 		}
-		// Requeue script errors after 60 seconds to give scvmm a breather
+		// Requeue script and job errors after 60 seconds to give scvmm a breather
 		requeue = 60
 	}
 	if requeue != 0 {
@@ -899,10 +1463,7 @@ func patchScvmmMachine(ctx context.Context, patchHelper *patch.Helper, scvmmMach
 	// Always update the readyCondition by summarizing the state of other conditions.
 	// A step counter is added to represent progress during the provisioning process (instead we are hiding the step counter during the deletion process).
 	conditions.SetSummary(scvmmMachine,
-		conditions.WithConditions(
-			VmCreated,
-			VmRunning,
-		),
+		conditions.WithConditions(provisioningConditions(scvmmMachine)...),
 		conditions.WithStepCounterIf(scvmmMachine.DeletionTimestamp.IsZero()),
 	)
 
@@ -912,25 +1473,60 @@ func patchScvmmMachine(ctx context.Context, patchHelper *patch.Helper, scvmmMach
 		scvmmMachine,
 		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 			clusterv1.ReadyCondition,
+			NameAllocated,
 			VmCreated,
+			IPAddressClaimed,
+			DisksResized,
+			PropertiesSynced,
+			BootstrapMediaAttached,
+			ADComputerReady,
+			BootstrapMediaRemoved,
 			VmRunning,
+			DNSRegistered,
 		}},
 	)
 }
 
-func (r *ScvmmMachineReconciler) getBootstrapData(ctx context.Context, name, namespace string) ([]byte, error) {
+// The conditions that make up the provisioning steps of the machine, in order
+// Steps that do not apply to the machine are left out, so the step counter adds up
+func provisioningConditions(scvmmMachine *infrav1.ScvmmMachine) []clusterv1.ConditionType {
+	steps := []clusterv1.ConditionType{}
+	if scvmmMachine.Spec.VMNameFromPool != nil {
+		steps = append(steps, NameAllocated)
+	}
+	steps = append(steps, VmCreated, IPAddressClaimed, DisksResized, PropertiesSynced)
+	if vmHasBootstrapMedia(scvmmMachine) {
+		steps = append(steps, BootstrapMediaAttached)
+	}
+	if scvmmMachine.Spec.ActiveDirectory != nil {
+		steps = append(steps, ADComputerReady)
+	}
+	steps = append(steps, VmRunning)
+	// Registration happens once the VM is running and its addresses are known
+	if conditions.Has(scvmmMachine, DNSRegistered) {
+		steps = append(steps, DNSRegistered)
+	}
+	return steps
+}
+
+// Returns the bootstrap data and its format (cloud-config or ignition)
+func (r *ScvmmMachineReconciler) getBootstrapData(ctx context.Context, name, namespace string) ([]byte, string, error) {
 	s := &corev1.Secret{}
 	key := client.ObjectKey{Namespace: namespace, Name: name}
 	if err := r.Client.Get(ctx, key, s); err != nil {
-		return nil, errors.Wrapf(err, "failed to retrieve bootstrap data secret for ScvmmMachine %s/%s", namespace, name)
+		return nil, "", errors.Wrapf(err, "failed to retrieve bootstrap data secret for ScvmmMachine %s/%s", namespace, name)
 	}
 
 	value, ok := s.Data["value"]
 	if !ok {
-		return nil, errors.New("error retrieving bootstrap data: secret value key is missing")
+		return nil, "", errors.New("error retrieving bootstrap data: secret value key is missing")
+	}
+	format := string(s.Data["format"])
+	if format == "" {
+		format = BootstrapFormatCloudConfig
 	}
 
-	return value, nil
+	return value, format, nil
 }
 
 func escapeSingleQuotes(str string) string {
@@ -944,9 +1540,9 @@ func escapeSingleQuotesArray(str []string) string {
 	}
 	res.WriteString("'")
 	res.WriteString(strings.Replace(str[0], `'`, `''`, -1))
-	for s := range str[1:] {
+	for _, s := range str[1:] {
 		res.WriteString("','")
-		res.WriteString(strings.Replace(str[s], `'`, `''`, -1))
+		res.WriteString(strings.Replace(s, `'`, `''`, -1))
 	}
 	res.WriteString("'")
 	return res.String()
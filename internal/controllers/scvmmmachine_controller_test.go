@@ -27,7 +27,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	infrastructurev1alpha1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrastructurev1beta1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 )
 
 var _ = Describe("ScvmmMachine Controller", func() {
@@ -40,13 +40,13 @@ var _ = Describe("ScvmmMachine Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		scvmmmachine := &infrastructurev1alpha1.ScvmmMachine{}
+		scvmmmachine := &infrastructurev1beta1.ScvmmMachine{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind ScvmmMachine")
 			err := k8sClient.Get(ctx, typeNamespacedName, scvmmmachine)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &infrastructurev1alpha1.ScvmmMachine{
+				resource := &infrastructurev1beta1.ScvmmMachine{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
@@ -59,7 +59,7 @@ var _ = Describe("ScvmmMachine Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &infrastructurev1alpha1.ScvmmMachine{}
+			resource := &infrastructurev1beta1.ScvmmMachine{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
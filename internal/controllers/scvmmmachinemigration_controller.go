@@ -0,0 +1,330 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	// VM is moved to the target
+	MigrationCompleted clusterv1.ConditionType = "MigrationCompleted"
+
+	MigratingReason        = "Migrating"
+	MigrationFailedReason  = "MigrationFailed"
+	MigrationRefusedReason = "MigrationRefused"
+
+	// Hyper-V host the VM of the ScvmmMachine is on
+	VMHostLabel = "infrastructure.cluster.x-k8s.io/scvmm-vmhost"
+	// Failure domain of the cluster the VM of the ScvmmMachine is in
+	FailureDomainLabel = "infrastructure.cluster.x-k8s.io/scvmm-failure-domain"
+)
+
+// ScvmmMachineMigrationReconciler reconciles a ScvmmMachineMigration object
+type ScvmmMachineMigrationReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinemigrations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinemigrations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinemigrations/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachines,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachines/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+func (r *ScvmmMachineMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("scvmmmachinemigration", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	migration := &infrav1.ScvmmMachineMigration{}
+	if err := r.Get(ctx, req.NamespacedName, migration); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// A migration is done only once, and there is nothing to clean up
+	if migration.Status.Ready || !migration.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(migration, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get patchhelper")
+	}
+	defer func() {
+		if err := patchScvmmMachineMigration(ctx, patchHelper, migration); err != nil {
+			log.Error(err, "failed to patch ScvmmMachineMigration")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	log.V(1).Info("Fetching scvmmmachine", "machine", migration.Spec.MachineRef.Name)
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	machineKey := client.ObjectKey{Namespace: migration.Namespace, Name: migration.Spec.MachineRef.Name}
+	if err := r.Get(ctx, machineKey, scvmmMachine); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkFalse(migration, MigrationCompleted, MachineNotFoundReason, clusterv1.ConditionSeverityWarning, "ScvmmMachine %s not found", machineKey.Name)
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	if scvmmMachine.Spec.Id == "" || !conditions.IsTrue(scvmmMachine, VmCreated) {
+		conditions.MarkFalse(migration, MigrationCompleted, WaitingForVMReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+	}
+
+	// Owned by the machine, so the migration goes away with the machine
+	migration.SetOwnerReferences(util.EnsureOwnerRef(migration.OwnerReferences, metav1.OwnerReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "ScvmmMachine",
+		Name:       scvmmMachine.Name,
+		UID:        scvmmMachine.UID,
+	}))
+
+	if migration.Status.Job != nil {
+		done, err := pollJob(ctx, scvmmMachine.Spec.ProviderRef, migration.Status.Job)
+		if done {
+			migration.Status.Job = nil
+		}
+		if err != nil {
+			if done {
+				// The move failed, so it can be started again
+				migration.Status.SourceHost = ""
+			}
+			return r.migrationError(migration, err)
+		}
+		if !done {
+			log.V(1).Info("Job running, requeue in 10 seconds", "job", migration.Status.Job)
+			conditions.MarkFalse(migration, MigrationCompleted, MigratingReason, clusterv1.ConditionSeverityInfo, "%s %s", migration.Status.Job.Name, migration.Status.Job.Progress)
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+		return r.finishMigration(ctx, migration, scvmmMachine)
+	}
+	// A move that went through is finished, not started again
+	if migration.Status.SourceHost != "" {
+		return r.finishMigration(ctx, migration, scvmmMachine)
+	}
+	return r.startMigration(ctx, migration, scvmmMachine)
+}
+
+func (r *ScvmmMachineMigrationReconciler) startMigration(ctx context.Context, migration *infrav1.ScvmmMachineMigration, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	// The hostgroup and cloud of the machine are the ones from its failure domain
+	requireHostGroup, requireCloud := scvmmMachine.Spec.HostGroup, scvmmMachine.Spec.Cloud
+	if migration.Spec.Force {
+		requireHostGroup, requireCloud = "", ""
+	}
+	storage := migration.Spec.Storage
+	if storage == nil {
+		storage = &infrav1.MigrationStorage{}
+	}
+	uselan := ""
+	if storage.UseLAN {
+		uselan = "-UseLAN"
+	}
+	log.Info("Migrating vm", "vmhost", migration.Spec.VMHost, "hostgroup", migration.Spec.HostGroup, "cloud", migration.Spec.Cloud)
+	res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "MoveVM -ID '%s' -VMHost '%s' -HostGroup '%s' -Cloud '%s' -Path '%s' %s -RequireHostGroup '%s' -RequireCloud '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id),
+		escapeSingleQuotes(migration.Spec.VMHost),
+		escapeSingleQuotes(migration.Spec.HostGroup),
+		escapeSingleQuotes(migration.Spec.Cloud),
+		escapeSingleQuotes(storage.Path),
+		uselan,
+		escapeSingleQuotes(requireHostGroup),
+		escapeSingleQuotes(requireCloud))
+	if err != nil {
+		return r.migrationError(migration, err)
+	}
+	if res.Result == "Refused" {
+		log.Info("Migration refused", "reason", res.Message)
+		r.recorder.Eventf(migration, corev1.EventTypeWarning, MigrationRefusedReason, "%s, set force to migrate anyway", res.Message)
+		conditions.MarkFalse(migration, MigrationCompleted, MigrationRefusedReason, clusterv1.ConditionSeverityError, "%s, set force to migrate anyway", res.Message)
+		return ctrl.Result{}, nil
+	}
+	migration.Status.SourceHost = res.VMHost
+	migration.Status.Job = newVMJob(res)
+	if migration.Status.Job == nil {
+		// Already on the target
+		return r.finishMigration(ctx, migration, scvmmMachine)
+	}
+	r.recorder.Eventf(migration, corev1.EventTypeNormal, MigratingReason, "Migrating %s from %s", res.Name, res.VMHost)
+	conditions.MarkFalse(migration, MigrationCompleted, MigratingReason, clusterv1.ConditionSeverityInfo, "")
+	return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+}
+
+// Update the machine to reflect where the VM ended up
+func (r *ScvmmMachineMigrationReconciler) finishMigration(ctx context.Context, migration *infrav1.ScvmmMachineMigration, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	vm, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "GetVM -ID '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id))
+	if err != nil {
+		return r.migrationError(migration, err)
+	}
+	// The move job keeps the VM locked, so the cloud is only set once it is done
+	if migration.Spec.Cloud != "" && vm.Cloud != migration.Spec.Cloud {
+		log.Info("Setting cloud of vm", "cloud", migration.Spec.Cloud)
+		vm, err = sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "SetVMCloud -ID '%s' -Cloud '%s'",
+			escapeSingleQuotes(scvmmMachine.Spec.Id),
+			escapeSingleQuotes(migration.Spec.Cloud))
+		if err != nil {
+			return r.migrationError(migration, err)
+		}
+	}
+	machinePatchHelper, err := patch.NewHelper(scvmmMachine, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get patchhelper")
+	}
+	scvmmMachine.Status.VMHost = vm.VMHost
+	if scvmmMachine.Labels == nil {
+		scvmmMachine.Labels = make(map[string]string)
+	}
+	shortHost, _, _ := strings.Cut(vm.VMHost, ".")
+	scvmmMachine.Labels[VMHostLabel] = shortHost
+	failureDomain, err := r.vmFailureDomain(ctx, scvmmMachine, vm)
+	if err != nil {
+		log.Error(err, "Failed to determine failure domain")
+	} else if failureDomain != "" {
+		scvmmMachine.Labels[FailureDomainLabel] = failureDomain
+	} else {
+		delete(scvmmMachine.Labels, FailureDomainLabel)
+	}
+	if err := machinePatchHelper.Patch(ctx, scvmmMachine); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Failed to patch scvmmMachine")
+	}
+	migration.Status.TargetHost = vm.VMHost
+	migration.Status.CompletionTime = metav1.Now()
+	migration.Status.Ready = true
+	conditions.MarkTrue(migration, MigrationCompleted)
+	r.recorder.Eventf(migration, corev1.EventTypeNormal, MigratingReason, "Migrated %s to %s", vm.Name, vm.VMHost)
+	return ctrl.Result{}, nil
+}
+
+// Find the failure domain of the cluster that contains the vm host
+// Returns empty if the machine is not part of a cluster, or the host is outside of all failure domains
+func (r *ScvmmMachineMigrationReconciler) vmFailureDomain(ctx context.Context, scvmmMachine *infrav1.ScvmmMachine, vm VMResult) (string, error) {
+	if scvmmMachine.Labels[clusterv1.ClusterNameLabel] == "" {
+		return "", nil
+	}
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, scvmmMachine.ObjectMeta)
+	if err != nil {
+		return "", err
+	}
+	if cluster.Spec.InfrastructureRef == nil {
+		return "", nil
+	}
+	scvmmCluster := &infrav1.ScvmmCluster{}
+	scvmmClusterName := client.ObjectKey{
+		Namespace: cluster.Spec.InfrastructureRef.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+	if err := r.Get(ctx, scvmmClusterName, scvmmCluster); err != nil {
+		return "", err
+	}
+	// Prefer the current one, otherwise take the first match
+	if fd, ok := scvmmCluster.Spec.FailureDomains[scvmmMachine.Labels[FailureDomainLabel]]; ok {
+		if fd.Cloud == vm.Cloud && hostGroupContains(vm.HostGroup, fd.HostGroup) {
+			return scvmmMachine.Labels[FailureDomainLabel], nil
+		}
+	}
+	names := make([]string, 0, len(scvmmCluster.Spec.FailureDomains))
+	for name := range scvmmCluster.Spec.FailureDomains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fd := scvmmCluster.Spec.FailureDomains[name]
+		if fd.Cloud == vm.Cloud && hostGroupContains(vm.HostGroup, fd.HostGroup) {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// Check if the host group path (like 'All Hosts\Site\Rack') is the given host group or one of its children
+// The host group can be given as a name or as a path
+func hostGroupContains(path, hostGroup string) bool {
+	if hostGroup == "" {
+		return false
+	}
+	if path == hostGroup || strings.HasPrefix(path, hostGroup+`\`) {
+		return true
+	}
+	for _, name := range strings.Split(path, `\`) {
+		if name == hostGroup {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ScvmmMachineMigrationReconciler) migrationError(migration *infrav1.ScvmmMachineMigration, err error) (ctrl.Result, error) {
+	r.recorder.Eventf(migration, corev1.EventTypeWarning, MigrationFailedReason, "%v", err)
+	conditions.MarkFalse(migration, MigrationCompleted, MigrationFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+	scriptError := &ScriptError{}
+	jobError := &JobFailedError{}
+	if !errors.As(err, &scriptError) && !errors.As(err, &jobError) {
+		return ctrl.Result{}, errors.Wrap(err, MigrationFailedReason)
+	}
+	// Requeue script and job errors after 60 seconds to give scvmm a breather
+	return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+}
+
+func patchScvmmMachineMigration(ctx context.Context, patchHelper *patch.Helper, migration *infrav1.ScvmmMachineMigration) error {
+	conditions.SetSummary(migration,
+		conditions.WithConditions(
+			MigrationCompleted,
+		),
+	)
+
+	return patchHelper.Patch(
+		ctx,
+		migration,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			MigrationCompleted,
+		}},
+	)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScvmmMachineMigrationReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("caps-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ScvmmMachineMigration{}).
+		WithOptions(options).
+		Complete(r)
+}
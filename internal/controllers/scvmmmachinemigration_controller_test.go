@@ -0,0 +1,253 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// A cluster with two failure domains, and a machine in the first one
+// The returned function gives the arguments of the last MoveVM call
+func migrationTestSetup(t *testing.T, migration *infrav1.ScvmmMachineMigration) (*fakeEnv, *ScvmmMachineMigrationReconciler, func() string) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Namespace: "default", Name: "cluster"},
+		},
+	}
+	scvmmCluster := &infrav1.ScvmmCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+		Spec: infrav1.ScvmmClusterSpec{
+			FailureDomains: map[string]infrav1.ScvmmFailureDomainSpec{
+				"rack1": {Cloud: "Cloud", HostGroup: "Rack1"},
+				"rack2": {Cloud: "Cloud", HostGroup: "Rack2"},
+			},
+		},
+	}
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "vm01",
+			UID:       "machine-uid",
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: "cluster",
+				FailureDomainLabel:         "rack1",
+			},
+		},
+		Spec: infrav1.ScvmmMachineSpec{
+			ProviderRef: testProviderRef(),
+			VMName:      "vm01",
+			Id:          "vm-1",
+			Cloud:       "Cloud",
+			HostGroup:   "Rack1",
+		},
+	}
+	conditions.MarkTrue(scvmmMachine, VmCreated)
+	env := newFakeEnv(t, cluster, scvmmCluster, scvmmMachine, migration)
+	var mu sync.Mutex
+	moveArgs := ""
+	env.scvmm.handle("MoveVM", func(args string) VMResult {
+		mu.Lock()
+		defer mu.Unlock()
+		moveArgs = args
+		return VMResult{Id: "vm-1", Name: "vm01", VMHost: "hv01.example.com", JobId: "job-move"}
+	})
+	env.scvmm.handle("GetJob", func(string) VMResult {
+		return VMResult{Name: "Migrate", Status: "Completed"}
+	})
+	env.scvmm.vms["vm-1"] = VMResult{Id: "vm-1", Name: "vm01", VMHost: "hv02.example.com", HostGroup: `All Hosts\Site\Rack2`, Cloud: "Cloud"}
+	r := &ScvmmMachineMigrationReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+	return env, r, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return moveArgs
+	}
+}
+
+func TestMigrationMovesToFailureDomain(t *testing.T) {
+	ctx := context.Background()
+	migration := &infrav1.ScvmmMachineMigration{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "move-vm01"},
+		Spec: infrav1.ScvmmMachineMigrationSpec{
+			MachineRef: corev1.LocalObjectReference{Name: "vm01"},
+			HostGroup:  "Rack2",
+		},
+	}
+	env, r, moveArgs := migrationTestSetup(t, migration)
+
+	// Start the move, then wait for the job
+	env.reconcile(t, r, migration, 2)
+	if count := env.scvmm.calledCount("MoveVM"); count != 1 {
+		t.Fatalf("MoveVM called %d times, want 1", count)
+	}
+	args := moveArgs()
+	for _, want := range []string{"-VMHost ''", "-HostGroup 'Rack2'", "-RequireHostGroup 'Rack1'", "-RequireCloud 'Cloud'"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("MoveVM %s, missing %s", args, want)
+		}
+	}
+	if !migration.Status.Ready || !conditions.IsTrue(migration, MigrationCompleted) {
+		t.Fatalf("migration not completed: %+v", migration.Status)
+	}
+	if migration.Status.SourceHost != "hv01.example.com" || migration.Status.TargetHost != "hv02.example.com" {
+		t.Errorf("migration from %s to %s, want hv01.example.com to hv02.example.com", migration.Status.SourceHost, migration.Status.TargetHost)
+	}
+
+	// The machine is labeled with where the VM ended up
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01"}, scvmmMachine); err != nil {
+		t.Fatal(err)
+	}
+	if host := scvmmMachine.Labels[VMHostLabel]; host != "hv02" {
+		t.Errorf("vmhost label = %q, want hv02", host)
+	}
+	if fd := scvmmMachine.Labels[FailureDomainLabel]; fd != "rack2" {
+		t.Errorf("failure domain label = %q, want rack2", fd)
+	}
+
+	// A finished migration is not done again
+	env.reconcile(t, r, migration, 1)
+	if count := env.scvmm.calledCount("MoveVM"); count != 1 {
+		t.Errorf("MoveVM called %d times after completion, want 1", count)
+	}
+}
+
+func TestMigrationRefusedOutsideFailureDomain(t *testing.T) {
+	ctx := context.Background()
+	migration := &infrav1.ScvmmMachineMigration{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "move-vm01"},
+		Spec: infrav1.ScvmmMachineMigrationSpec{
+			MachineRef: corev1.LocalObjectReference{Name: "vm01"},
+			VMHost:     "hv09",
+		},
+	}
+	env, r, _ := migrationTestSetup(t, migration)
+	moveArgs := ""
+	env.scvmm.handle("MoveVM", func(args string) VMResult {
+		moveArgs = args
+		return VMResult{Result: "Refused", Message: "Host hv09 is not in host group Rack1"}
+	})
+
+	env.reconcile(t, r, migration, 1)
+	if migration.Status.Ready || conditions.GetReason(migration, MigrationCompleted) != MigrationRefusedReason {
+		t.Fatalf("migration not refused: %+v", migration.Status)
+	}
+	if !strings.Contains(moveArgs, "-RequireHostGroup 'Rack1'") {
+		t.Errorf("MoveVM %s, missing the host group of the failure domain", moveArgs)
+	}
+
+	// Force drops the failure domain requirements
+	env.scvmm.handle("MoveVM", func(args string) VMResult {
+		moveArgs = args
+		return VMResult{Id: "vm-1", Name: "vm01", VMHost: "hv01.example.com", JobId: "job-move"}
+	})
+	migration.Spec.Force = true
+	if err := env.client.Update(ctx, migration); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, migration, 2)
+	for _, want := range []string{"-VMHost 'hv09'", "-RequireHostGroup ''", "-RequireCloud ''"} {
+		if !strings.Contains(moveArgs, want) {
+			t.Errorf("MoveVM %s, missing %s", moveArgs, want)
+		}
+	}
+	if !migration.Status.Ready {
+		t.Errorf("forced migration not completed: %+v", migration.Status)
+	}
+}
+
+// The cloud is set after the move job, and a failure to set it does not start another move
+func TestMigrationSetsCloudAfterMove(t *testing.T) {
+	ctx := context.Background()
+	migration := &infrav1.ScvmmMachineMigration{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "move-vm01"},
+		Spec: infrav1.ScvmmMachineMigrationSpec{
+			MachineRef: corev1.LocalObjectReference{Name: "vm01"},
+			HostGroup:  "Rack2",
+			Cloud:      "Cloud2",
+			Force:      true,
+		},
+	}
+	env, r, _ := migrationTestSetup(t, migration)
+	env.scvmm.handle("SetVMCloud", func(string) VMResult {
+		return VMResult{Error: "VM is locked", Message: "Set VM Cloud Failed"}
+	})
+
+	// Start the move, then the job is done but the cloud can't be set yet
+	env.reconcile(t, r, migration, 2)
+	if migration.Status.Ready || conditions.GetReason(migration, MigrationCompleted) != MigrationFailedReason {
+		t.Fatalf("migration = %+v, want the failure to set the cloud", migration.Status)
+	}
+	if count := env.scvmm.calledCount("MoveVM"); count != 1 {
+		t.Fatalf("MoveVM called %d times, want 1", count)
+	}
+
+	setArgs := ""
+	env.scvmm.handle("SetVMCloud", func(args string) VMResult {
+		setArgs = args
+		return VMResult{Id: "vm-1", Name: "vm01", VMHost: "hv02.example.com", HostGroup: `All Hosts\Site\Rack2`, Cloud: "Cloud2"}
+	})
+	env.reconcile(t, r, migration, 1)
+	if !migration.Status.Ready {
+		t.Fatalf("migration not completed: %+v", migration.Status)
+	}
+	if count := env.scvmm.calledCount("MoveVM"); count != 1 {
+		t.Errorf("MoveVM called %d times after setting the cloud failed, want 1", count)
+	}
+	if setArgs != "-ID 'vm-1' -Cloud 'Cloud2'" {
+		t.Errorf("SetVMCloud %s", setArgs)
+	}
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	if err := env.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "vm01"}, scvmmMachine); err != nil {
+		t.Fatal(err)
+	}
+	if host := scvmmMachine.Labels[VMHostLabel]; host != "hv02" {
+		t.Errorf("vmhost label = %q, want hv02", host)
+	}
+}
+
+func TestHostGroupContains(t *testing.T) {
+	tests := []struct {
+		path      string
+		hostGroup string
+		want      bool
+	}{
+		{`All Hosts\Site\Rack1`, "Rack1", true},
+		{`All Hosts\Site\Rack1`, "Site", true},
+		{`All Hosts\Site\Rack1`, `All Hosts\Site`, true},
+		{`All Hosts\Site\Rack1`, "Rack2", false},
+		{`All Hosts\Site\Rack10`, `All Hosts\Site\Rack1`, false},
+		{`All Hosts\Site\Rack1`, "", false},
+	}
+	for _, tt := range tests {
+		if got := hostGroupContains(tt.path, tt.hostGroup); got != tt.want {
+			t.Errorf("hostGroupContains(%q, %q) = %v, want %v", tt.path, tt.hostGroup, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	exputil "sigs.k8s.io/cluster-api/exp/util"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	// The pool has the desired number of up-to-date machines
+	ReplicasReady clusterv1.ConditionType = "ReplicasReady"
+
+	RollingUpdateReason = "RollingUpdate"
+
+	MachinePoolFinalizer = "scvmmmachinepool.finalizers.cluster.x-k8s.io"
+
+	// ScvmmMachinePool a ScvmmMachine belongs to
+	MachinePoolLabel = "infrastructure.cluster.x-k8s.io/scvmm-machine-pool"
+	// Hash of the pool template a ScvmmMachine was created from
+	MachinePoolTemplateHashLabel = "infrastructure.cluster.x-k8s.io/scvmm-template-hash"
+)
+
+// ScvmmMachinePoolReconciler reconciles a ScvmmMachinePool object
+type ScvmmMachinePoolReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinepools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinepools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinepools/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachines,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machinepools,verbs=get;list;watch
+
+func (r *ScvmmMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("scvmmmachinepool", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	scvmmMachinePool := &infrav1.ScvmmMachinePool{}
+	if err := r.Get(ctx, req.NamespacedName, scvmmMachinePool); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(scvmmMachinePool, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get patchhelper")
+	}
+	defer func() {
+		if err := patchScvmmMachinePool(ctx, patchHelper, scvmmMachinePool); err != nil {
+			log.Error(err, "failed to patch ScvmmMachinePool")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	if !scvmmMachinePool.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, scvmmMachinePool)
+	}
+
+	log.V(1).Info("Fetching machinepool")
+	machinePool, err := exputil.GetOwnerMachinePool(ctx, r.Client, scvmmMachinePool.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get owner machinepool")
+	}
+	if machinePool == nil {
+		log.Info("Waiting for MachinePool Controller to set OwnerRef on ScvmmMachinePool")
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, WaitingForOwnerReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+	log = log.WithValues("machinepool", machinePool.Name)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	log.V(1).Info("Fetching cluster")
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, machinePool.ObjectMeta)
+	if err != nil {
+		log.Info("ScvmmMachinePool owner MachinePool is missing cluster label or cluster does not exist")
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, MissingClusterReason, clusterv1.ConditionSeverityWarning, "MachinePool is missing cluster label or cluster does not exist")
+		return ctrl.Result{}, nil
+	}
+	if annotations.IsPaused(cluster, scvmmMachinePool) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+	if !cluster.Status.InfrastructureReady {
+		log.Info("Waiting for ScvmmCluster Controller to create cluster infrastructure")
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+	scvmmCluster := &infrav1.ScvmmCluster{}
+	scvmmClusterName := client.ObjectKey{
+		Namespace: cluster.Spec.InfrastructureRef.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+	if err := r.Get(ctx, scvmmClusterName, scvmmCluster); err != nil {
+		log.Info("ScvmmCluster is not available yet", "error", err)
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, ClusterNotAvailableReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(scvmmMachinePool, MachinePoolFinalizer) {
+		controllerutil.AddFinalizer(scvmmMachinePool, MachinePoolFinalizer)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	return r.reconcileNormal(ctx, cluster, scvmmCluster, machinePool, scvmmMachinePool)
+}
+
+func (r *ScvmmMachinePoolReconciler) reconcileNormal(ctx context.Context, cluster *clusterv1.Cluster, scvmmCluster *infrav1.ScvmmCluster, machinePool *expv1.MachinePool, scvmmMachinePool *infrav1.ScvmmMachinePool) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	machines, err := r.poolMachines(ctx, scvmmMachinePool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always report what is there, also when waiting for bootstrap data
+	defer func() {
+		setMachinePoolStatus(scvmmMachinePool, machinePool, machines)
+	}()
+
+	dataSecretName := machinePool.Spec.Template.Spec.Bootstrap.DataSecretName
+	if dataSecretName == nil {
+		log.Info("Waiting for the Bootstrap provider controller to set bootstrap data")
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+	version := ""
+	if machinePool.Spec.Template.Spec.Version != nil {
+		version = *machinePool.Spec.Template.Spec.Version
+	}
+	templateHash, err := machinePoolTemplateHash(&scvmmMachinePool.Spec.Template, version, *dataSecretName)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to hash machine template")
+	}
+	scvmmMachinePool.Status.TemplateHash = templateHash
+
+	desired := 1
+	if machinePool.Spec.Replicas != nil {
+		desired = int(*machinePool.Spec.Replicas)
+	}
+	strategy := infrav1.ScvmmMachinePoolStrategy{MaxSurge: 1}
+	if scvmmMachinePool.Spec.Strategy != nil {
+		strategy = *scvmmMachinePool.Spec.Strategy
+	}
+	active := []infrav1.ScvmmMachine{}
+	for _, m := range machines {
+		if m.DeletionTimestamp.IsZero() {
+			active = append(active, m)
+		}
+	}
+	create, remove := planMachinePool(active, templateHash, desired, strategy)
+	removed := map[string]bool{}
+	for _, m := range remove {
+		log.Info("Deleting machine", "scvmmmachine", m.Name, "templateHash", m.Labels[MachinePoolTemplateHashLabel])
+		if err := r.Delete(ctx, &m); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to delete ScvmmMachine %s", m.Name)
+		}
+		r.recorder.Eventf(scvmmMachinePool, corev1.EventTypeNormal, "MachineDeleted", "Deleted ScvmmMachine %s", m.Name)
+		removed[m.Name] = true
+	}
+	if len(removed) > 0 {
+		now := metav1.Now()
+		kept := []infrav1.ScvmmMachine{}
+		for i, m := range machines {
+			if removed[m.Name] {
+				machines[i].DeletionTimestamp = &now
+			}
+		}
+		for _, m := range active {
+			if !removed[m.Name] {
+				kept = append(kept, m)
+			}
+		}
+		active = kept
+	}
+	for i := 0; i < create; i++ {
+		scvmmMachine, err := r.createMachine(ctx, cluster, scvmmCluster, machinePool, scvmmMachinePool, active, templateHash, *dataSecretName)
+		if err != nil {
+			r.recorder.Eventf(scvmmMachinePool, corev1.EventTypeWarning, VmFailedReason, "Failed to create ScvmmMachine: %v", err)
+			conditions.MarkFalse(scvmmMachinePool, ReplicasReady, VmFailedReason, clusterv1.ConditionSeverityError, "Failed to create ScvmmMachine: %v", err)
+			return ctrl.Result{}, err
+		}
+		log.Info("Created machine", "scvmmmachine", scvmmMachine.Name)
+		r.recorder.Eventf(scvmmMachinePool, corev1.EventTypeNormal, "MachineCreated", "Created ScvmmMachine %s", scvmmMachine.Name)
+		active = append(active, *scvmmMachine)
+		machines = append(machines, *scvmmMachine)
+	}
+
+	updated, ready := 0, 0
+	for _, m := range active {
+		if m.Labels[MachinePoolTemplateHashLabel] == templateHash {
+			updated++
+		}
+		if m.Status.Ready {
+			ready++
+		}
+	}
+	switch {
+	case updated < len(active):
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, RollingUpdateReason, clusterv1.ConditionSeverityInfo, "%d of %d machines updated", updated, len(active))
+	case len(active) < desired || ready < desired:
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, clusterv1.ScalingUpReason, clusterv1.ConditionSeverityInfo, "%d of %d machines ready", ready, desired)
+	case len(active) > desired:
+		conditions.MarkFalse(scvmmMachinePool, ReplicasReady, clusterv1.ScalingDownReason, clusterv1.ConditionSeverityInfo, "%d machines, %d desired", len(active), desired)
+	default:
+		conditions.MarkTrue(scvmmMachinePool, ReplicasReady)
+	}
+	if create > 0 || len(remove) > 0 || !conditions.IsTrue(scvmmMachinePool, ReplicasReady) {
+		// The machines report back through the watch, this is just a safety net
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// Create a ScvmmMachine from the template of the pool, in the least used failure domain
+func (r *ScvmmMachinePoolReconciler) createMachine(ctx context.Context, cluster *clusterv1.Cluster, scvmmCluster *infrav1.ScvmmCluster, machinePool *expv1.MachinePool, scvmmMachinePool *infrav1.ScvmmMachinePool, machines []infrav1.ScvmmMachine, templateHash, dataSecretName string) (*infrav1.ScvmmMachine, error) {
+	template := scvmmMachinePool.Spec.Template.DeepCopy()
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    scvmmMachinePool.Namespace,
+			GenerateName: scvmmMachinePool.Name + "-",
+			Labels:       template.Labels,
+			Annotations:  template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if scvmmMachine.Labels == nil {
+		scvmmMachine.Labels = map[string]string{}
+	}
+	scvmmMachine.Labels[clusterv1.ClusterNameLabel] = cluster.Name
+	scvmmMachine.Labels[MachinePoolLabel] = scvmmMachinePool.Name
+	scvmmMachine.Labels[MachinePoolTemplateHashLabel] = templateHash
+	// The pool machines are standalone machines, there is no Machine to get the bootstrap data from
+	scvmmMachine.Spec.Bootstrap = &clusterv1.Bootstrap{DataSecretName: &dataSecretName}
+	scvmmMachine.Spec.ProviderID = ""
+	scvmmMachine.Spec.Id = ""
+	if scvmmMachine.Spec.ProviderRef == nil {
+		scvmmMachine.Spec.ProviderRef = scvmmCluster.Spec.ProviderRef
+	}
+	if scvmmMachine.Spec.Cloud == "" || scvmmMachine.Spec.HostGroup == "" {
+		failureDomains := machinePool.Spec.FailureDomains
+		if len(failureDomains) == 0 {
+			for name := range scvmmCluster.Spec.FailureDomains {
+				failureDomains = append(failureDomains, name)
+			}
+		}
+		failureDomain := pickFailureDomain(failureDomains, machines)
+		if failureDomain == "" {
+			return nil, errors.New("missing failureDomain")
+		}
+		fd, ok := scvmmCluster.Spec.FailureDomains[failureDomain]
+		if !ok {
+			return nil, errors.Errorf("unknown failureDomain %s", failureDomain)
+		}
+		scvmmMachine.Labels[FailureDomainLabel] = failureDomain
+		scvmmMachine.Spec.Cloud = fd.Cloud
+		scvmmMachine.Spec.HostGroup = fd.HostGroup
+		if fd.ProviderRef != nil {
+			scvmmMachine.Spec.ProviderRef = fd.ProviderRef
+		}
+		if scvmmMachine.Spec.Networking == nil {
+			scvmmMachine.Spec.Networking = fd.Networking
+		}
+	}
+	if scvmmMachine.Spec.AutoAvailabilitySet {
+		scvmmMachine.Spec.AvailabilitySet = cluster.Name + "-" + machinePool.Name
+	}
+	if err := controllerutil.SetControllerReference(scvmmMachinePool, scvmmMachine, r.Scheme()); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, scvmmMachine); err != nil {
+		return nil, err
+	}
+	return scvmmMachine, nil
+}
+
+// The ScvmmMachines of the pool, oldest first
+func (r *ScvmmMachinePoolReconciler) poolMachines(ctx context.Context, scvmmMachinePool *infrav1.ScvmmMachinePool) ([]infrav1.ScvmmMachine, error) {
+	machineList := &infrav1.ScvmmMachineList{}
+	if err := r.List(ctx, machineList, client.InNamespace(scvmmMachinePool.Namespace), client.MatchingLabels{MachinePoolLabel: scvmmMachinePool.Name}); err != nil {
+		return nil, errors.Wrap(err, "failed to list ScvmmMachines")
+	}
+	machines := []infrav1.ScvmmMachine{}
+	for _, m := range machineList.Items {
+		if metav1.IsControlledBy(&m, scvmmMachinePool) {
+			machines = append(machines, m)
+		}
+	}
+	sort.SliceStable(machines, func(i, j int) bool {
+		return machines[i].CreationTimestamp.Before(&machines[j].CreationTimestamp)
+	})
+	return machines, nil
+}
+
+func setMachinePoolStatus(scvmmMachinePool *infrav1.ScvmmMachinePool, machinePool *expv1.MachinePool, machines []infrav1.ScvmmMachine) {
+	desired := int32(1)
+	if machinePool.Spec.Replicas != nil {
+		desired = *machinePool.Spec.Replicas
+	}
+	providerIDList := []string{}
+	getters := []conditions.Getter{}
+	var replicas, readyReplicas, updatedReplicas int32
+	for i, m := range machines {
+		if !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+		replicas++
+		if m.Status.Ready {
+			readyReplicas++
+		}
+		if m.Labels[MachinePoolTemplateHashLabel] == scvmmMachinePool.Status.TemplateHash {
+			updatedReplicas++
+		}
+		if m.Spec.ProviderID != "" {
+			providerIDList = append(providerIDList, m.Spec.ProviderID)
+		}
+		getters = append(getters, &machines[i])
+	}
+	sort.Strings(providerIDList)
+	scvmmMachinePool.Spec.ProviderIDList = providerIDList
+	scvmmMachinePool.Status.Replicas = replicas
+	scvmmMachinePool.Status.ReadyReplicas = readyReplicas
+	scvmmMachinePool.Status.UpdatedReplicas = updatedReplicas
+	scvmmMachinePool.Status.Ready = readyReplicas >= desired
+	if len(getters) > 0 {
+		conditions.SetAggregate(scvmmMachinePool, clusterv1.MachinesReadyCondition, getters, conditions.AddSourceRef(), conditions.WithStepCounterIf(false))
+	} else {
+		conditions.MarkTrue(scvmmMachinePool, clusterv1.MachinesReadyCondition)
+	}
+}
+
+func (r *ScvmmMachinePoolReconciler) reconcileDelete(ctx context.Context, scvmmMachinePool *infrav1.ScvmmMachinePool) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	machines, err := r.poolMachines(ctx, scvmmMachinePool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(machines) == 0 {
+		log.Info("All machines deleted, removing finalizer")
+		controllerutil.RemoveFinalizer(scvmmMachinePool, MachinePoolFinalizer)
+		return ctrl.Result{}, nil
+	}
+	for _, m := range machines {
+		if !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+		log.Info("Deleting machine", "scvmmmachine", m.Name)
+		if err := r.Delete(ctx, &m); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to delete ScvmmMachine %s", m.Name)
+		}
+	}
+	conditions.MarkFalse(scvmmMachinePool, ReplicasReady, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "Waiting for %d machines to be deleted", len(machines))
+	return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+}
+
+func patchScvmmMachinePool(ctx context.Context, patchHelper *patch.Helper, scvmmMachinePool *infrav1.ScvmmMachinePool) error {
+	conditions.SetSummary(scvmmMachinePool,
+		conditions.WithConditions(
+			ReplicasReady,
+			clusterv1.MachinesReadyCondition,
+		),
+	)
+
+	return patchHelper.Patch(
+		ctx,
+		scvmmMachinePool,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			ReplicasReady,
+			clusterv1.MachinesReadyCondition,
+		}},
+	)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScvmmMachinePoolReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	log := ctrl.LoggerFrom(ctx)
+	r.recorder = mgr.GetEventRecorderFor("caps-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ScvmmMachinePool{}).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPaused(log)).
+		Watches(
+			&expv1.MachinePool{},
+			handler.EnqueueRequestsFromMapFunc(exputil.MachinePoolToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("ScvmmMachinePool"), log)),
+		).
+		Owns(&infrav1.ScvmmMachine{}).
+		Complete(r)
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// A cluster with two failure domains and a MachinePool with an ScvmmMachinePool of two replicas
+func machinePoolTestSetup(t *testing.T) (*fakeEnv, *ScvmmMachinePoolReconciler, *expv1.MachinePool, *infrav1.ScvmmMachinePool) {
+	replicas := int32(2)
+	dataSecretName := "workers-bootstrap"
+	version := "v1.29.0"
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Namespace: "default", Name: "cluster"},
+		},
+		Status: clusterv1.ClusterStatus{InfrastructureReady: true},
+	}
+	scvmmCluster := &infrav1.ScvmmCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+		Spec: infrav1.ScvmmClusterSpec{
+			ProviderRef: testProviderRef(),
+			FailureDomains: map[string]infrav1.ScvmmFailureDomainSpec{
+				"rack1": {Cloud: "Cloud", HostGroup: "Rack1"},
+				"rack2": {Cloud: "Cloud", HostGroup: "Rack2"},
+			},
+		},
+	}
+	machinePool := &expv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "workers",
+			UID:       "machinepool-uid",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: expv1.MachinePoolSpec{
+			ClusterName: cluster.Name,
+			Replicas:    &replicas,
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					ClusterName: cluster.Name,
+					Bootstrap:   clusterv1.Bootstrap{DataSecretName: &dataSecretName},
+					Version:     &version,
+				},
+			},
+		},
+	}
+	scvmmMachinePool := &infrav1.ScvmmMachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "workers",
+			UID:       "scvmmmachinepool-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: expv1.GroupVersion.String(),
+				Kind:       "MachinePool",
+				Name:       machinePool.Name,
+				UID:        machinePool.UID,
+			}},
+		},
+		Spec: infrav1.ScvmmMachinePoolSpec{
+			Template: infrav1.ScvmmMachineTemplateResource{
+				Spec: infrav1.ScvmmMachineSpec{VMTemplate: "ubuntu-2204"},
+			},
+		},
+	}
+	env := newFakeEnv(t, cluster, scvmmCluster, machinePool, scvmmMachinePool)
+	r := &ScvmmMachinePoolReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+	return env, r, machinePool, scvmmMachinePool
+}
+
+// Reconcile the pool once, and list its machines
+func reconcileMachinePool(t *testing.T, env *fakeEnv, r *ScvmmMachinePoolReconciler, scvmmMachinePool *infrav1.ScvmmMachinePool) []infrav1.ScvmmMachine {
+	env.reconcile(t, r, scvmmMachinePool, 1)
+	machines, err := r.poolMachines(context.Background(), scvmmMachinePool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return machines
+}
+
+// Make the machines ready, like the machine controller would when their VM runs
+func setPoolMachinesReady(t *testing.T, c client.Client, machines []infrav1.ScvmmMachine) {
+	ctx := context.Background()
+	for i := range machines {
+		m := &machines[i]
+		if m.Status.Ready {
+			continue
+		}
+		m.Spec.ProviderID = "scvmm://" + m.Name
+		if err := c.Update(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		m.Status.Ready = true
+		if err := c.Status().Update(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMachinePoolScale(t *testing.T) {
+	ctx := context.Background()
+	env, r, machinePool, scvmmMachinePool := machinePoolTestSetup(t)
+
+	// Finalizer first, then the machines
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	machines := reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if len(machines) != 2 {
+		t.Fatalf("%d machines created, want 2", len(machines))
+	}
+	failureDomains := map[string]bool{}
+	for _, m := range machines {
+		if m.Labels[clusterv1.ClusterNameLabel] != "cluster" || m.Labels[MachinePoolTemplateHashLabel] != scvmmMachinePool.Status.TemplateHash {
+			t.Errorf("machine %s labels = %v", m.Name, m.Labels)
+		}
+		if m.Spec.Bootstrap == nil || *m.Spec.Bootstrap.DataSecretName != "workers-bootstrap" {
+			t.Errorf("machine %s bootstrap = %v, want the bootstrap data of the MachinePool", m.Name, m.Spec.Bootstrap)
+		}
+		if !metav1.IsControlledBy(&m, scvmmMachinePool) {
+			t.Errorf("machine %s is not controlled by the pool", m.Name)
+		}
+		failureDomains[m.Labels[FailureDomainLabel]] = true
+	}
+	if !failureDomains["rack1"] || !failureDomains["rack2"] {
+		t.Errorf("machines not spread over the failure domains: %v", failureDomains)
+	}
+	if conditions.GetReason(scvmmMachinePool, ReplicasReady) != clusterv1.ScalingUpReason {
+		t.Errorf("ReplicasReady reason = %q, want %s", conditions.GetReason(scvmmMachinePool, ReplicasReady), clusterv1.ScalingUpReason)
+	}
+
+	setPoolMachinesReady(t, env.client, machines)
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if !conditions.IsTrue(scvmmMachinePool, ReplicasReady) || !scvmmMachinePool.Status.Ready || scvmmMachinePool.Status.ReadyReplicas != 2 {
+		t.Errorf("pool not ready: %+v", scvmmMachinePool.Status)
+	}
+	if len(scvmmMachinePool.Spec.ProviderIDList) != 2 {
+		t.Errorf("providerIDList = %v, want both machines", scvmmMachinePool.Spec.ProviderIDList)
+	}
+
+	// Scale down
+	replicas := int32(1)
+	machinePool.Spec.Replicas = &replicas
+	if err := env.client.Update(ctx, machinePool); err != nil {
+		t.Fatal(err)
+	}
+	machines = reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if len(machines) != 1 {
+		t.Errorf("%d machines after scaling down, want 1", len(machines))
+	}
+}
+
+func TestMachinePoolRoll(t *testing.T) {
+	ctx := context.Background()
+	env, r, _, scvmmMachinePool := machinePoolTestSetup(t)
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	setPoolMachinesReady(t, env.client, reconcileMachinePool(t, env, r, scvmmMachinePool))
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	oldHash := scvmmMachinePool.Status.TemplateHash
+
+	scvmmMachinePool.Spec.Template.Spec.VMTemplate = "ubuntu-2404"
+	if err := env.client.Update(ctx, scvmmMachinePool); err != nil {
+		t.Fatal(err)
+	}
+
+	// With the default strategy, one machine is added before an old one goes
+	machines := reconcileMachinePool(t, env, r, scvmmMachinePool)
+	newHash := scvmmMachinePool.Status.TemplateHash
+	if newHash == oldHash {
+		t.Fatalf("template hash did not change")
+	}
+	countHashes := func(machines []infrav1.ScvmmMachine) (int, int) {
+		old, updated := 0, 0
+		for _, m := range machines {
+			switch m.Labels[MachinePoolTemplateHashLabel] {
+			case oldHash:
+				old++
+			case newHash:
+				updated++
+			}
+		}
+		return old, updated
+	}
+	if old, updated := countHashes(machines); old != 2 || updated != 1 {
+		t.Fatalf("%d old and %d updated machines, want 2 and 1", old, updated)
+	}
+	if conditions.GetReason(scvmmMachinePool, ReplicasReady) != RollingUpdateReason {
+		t.Errorf("ReplicasReady reason = %q, want %s", conditions.GetReason(scvmmMachinePool, ReplicasReady), RollingUpdateReason)
+	}
+
+	// An old machine is only removed when the new one is ready
+	machines = reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if old, updated := countHashes(machines); old != 2 || updated != 1 {
+		t.Fatalf("old machine removed before the new one is ready: %d old and %d updated", old, updated)
+	}
+	for i := 0; i < 4; i++ {
+		setPoolMachinesReady(t, env.client, machines)
+		machines = reconcileMachinePool(t, env, r, scvmmMachinePool)
+	}
+	if old, updated := countHashes(machines); old != 0 || updated != 2 {
+		t.Errorf("%d old and %d updated machines after the roll, want 0 and 2", old, updated)
+	}
+	if !conditions.IsTrue(scvmmMachinePool, ReplicasReady) {
+		t.Errorf("ReplicasReady = %v after the roll", conditions.Get(scvmmMachinePool, ReplicasReady))
+	}
+}
+
+// Failed machines have no MachineHealthCheck to replace them, the pool does
+func TestMachinePoolReplacesFailedMachine(t *testing.T) {
+	ctx := context.Background()
+	env, r, _, scvmmMachinePool := machinePoolTestSetup(t)
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	setPoolMachinesReady(t, env.client, reconcileMachinePool(t, env, r, scvmmMachinePool))
+	machines := reconcileMachinePool(t, env, r, scvmmMachinePool)
+
+	failed := &machines[0]
+	reason := capierrors.CreateMachineError
+	failed.Status.Ready = false
+	failed.Status.FailureReason = &reason
+	if err := env.client.Status().Update(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	machines = reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if len(machines) != 2 {
+		t.Fatalf("%d machines after replacing the failed one, want 2", len(machines))
+	}
+	for _, m := range machines {
+		if m.Name == failed.Name {
+			t.Errorf("failed machine %s not removed", m.Name)
+		}
+	}
+	if scvmmMachinePool.Status.ReadyReplicas != 1 || scvmmMachinePool.Status.Ready {
+		t.Errorf("pool status = %+v, want one ready replica", scvmmMachinePool.Status)
+	}
+
+	setPoolMachinesReady(t, env.client, machines)
+	reconcileMachinePool(t, env, r, scvmmMachinePool)
+	if !scvmmMachinePool.Status.Ready || scvmmMachinePool.Status.ReadyReplicas != 2 {
+		t.Errorf("pool not ready after replacing the failed machine: %+v", scvmmMachinePool.Status)
+	}
+}
@@ -0,0 +1,358 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+const (
+	// Checkpoint exists
+	SnapshotCreated clusterv1.ConditionType = "SnapshotCreated"
+	// VM is reverted to the checkpoint
+	SnapshotRestored clusterv1.ConditionType = "SnapshotRestored"
+
+	SnapshotCreatingReason  = "SnapshotCreating"
+	SnapshotRestoringReason = "SnapshotRestoring"
+	SnapshotDeletingReason  = "SnapshotDeleting"
+	SnapshotFailedReason    = "SnapshotFailed"
+	MachineNotFoundReason   = "MachineNotFound"
+	ManagedMachineReason    = "ManagedMachine"
+	WaitingForVMReason      = "WaitingForVM"
+	RestoreRefusedReason    = "RestoreRefused"
+
+	SnapshotFinalizer = "scvmmmachinesnapshot.finalizers.cluster.x-k8s.io"
+)
+
+// ScvmmMachineSnapshotReconciler reconciles a ScvmmMachineSnapshot object
+type ScvmmMachineSnapshotReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinesnapshots,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinesnapshots/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachinesnapshots/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachines,verbs=get;list;watch
+
+func (r *ScvmmMachineSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("scvmmmachinesnapshot", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	snapshot := &infrav1.ScvmmMachineSnapshot{}
+	if err := r.Get(ctx, req.NamespacedName, snapshot); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(snapshot, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "Get patchhelper")
+	}
+	defer func() {
+		err := patchScvmmMachineSnapshot(ctx, patchHelper, snapshot)
+		if !snapshot.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(snapshot, SnapshotFinalizer) {
+			// Without the finalizer, the snapshot is gone before its status can be patched
+			err = kerrors.FilterOut(err, apierrors.IsNotFound)
+		}
+		if err != nil {
+			log.Error(err, "failed to patch ScvmmMachineSnapshot")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	log.V(1).Info("Fetching scvmmmachine", "machine", snapshot.Spec.MachineRef.Name)
+	scvmmMachine := &infrav1.ScvmmMachine{}
+	machineKey := client.ObjectKey{Namespace: snapshot.Namespace, Name: snapshot.Spec.MachineRef.Name}
+	if err := r.Get(ctx, machineKey, scvmmMachine); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		scvmmMachine = nil
+	}
+
+	if !snapshot.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, snapshot, scvmmMachine)
+	}
+
+	if scvmmMachine == nil {
+		conditions.MarkFalse(snapshot, SnapshotCreated, MachineNotFoundReason, clusterv1.ConditionSeverityWarning, "ScvmmMachine %s not found", machineKey.Name)
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	if isManagedMachine(scvmmMachine) && !snapshot.Spec.AllowManagedMachine {
+		log.Info("Refusing checkpoint of cluster-api managed machine")
+		conditions.MarkFalse(snapshot, SnapshotCreated, ManagedMachineReason, clusterv1.ConditionSeverityError, "ScvmmMachine %s is managed by cluster-api, set allowManagedMachine to allow checkpoints", machineKey.Name)
+		return ctrl.Result{}, nil
+	}
+	if scvmmMachine.Spec.Id == "" || !conditions.IsTrue(scvmmMachine, VmCreated) {
+		conditions.MarkFalse(snapshot, SnapshotCreated, WaitingForVMReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+	}
+
+	// Owned by the machine, so the snapshot goes away with the machine (and the VM)
+	snapshot.SetOwnerReferences(util.EnsureOwnerRef(snapshot.OwnerReferences, metav1.OwnerReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "ScvmmMachine",
+		Name:       scvmmMachine.Name,
+		UID:        scvmmMachine.UID,
+	}))
+
+	// Add finalizer.  Apparently we should return here to avoid a race condition
+	// (Presumably the change/patch will trigger another reconciliation so it continues)
+	if !controllerutil.ContainsFinalizer(snapshot, SnapshotFinalizer) {
+		controllerutil.AddFinalizer(snapshot, SnapshotFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	return r.reconcileNormal(ctx, snapshot, scvmmMachine)
+}
+
+// A machine with an owning cluster-api Machine is managed by cluster-api
+func isManagedMachine(scvmmMachine *infrav1.ScvmmMachine) bool {
+	return util.HasOwner(scvmmMachine.OwnerReferences, clusterv1.GroupVersion.String(), []string{"Machine"})
+}
+
+func (r *ScvmmMachineSnapshotReconciler) reconcileNormal(ctx context.Context, snapshot *infrav1.ScvmmMachineSnapshot, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if snapshot.Status.Job != nil {
+		restoring := conditions.GetReason(snapshot, SnapshotRestored) == SnapshotRestoringReason
+		condition, reason := SnapshotCreated, SnapshotCreatingReason
+		if restoring {
+			condition, reason = SnapshotRestored, SnapshotRestoringReason
+		}
+		done, err := pollJob(ctx, scvmmMachine.Spec.ProviderRef, snapshot.Status.Job)
+		if done {
+			snapshot.Status.Job = nil
+		}
+		if err != nil {
+			if done {
+				if restoring {
+					// Don't keep on trying, a new restore has to be requested
+					snapshot.Status.LastRestoreRequest = snapshot.Spec.RestoreRequest
+				} else {
+					// Try to create it again
+					snapshot.Status.CheckpointId = ""
+				}
+			}
+			return r.snapshotError(snapshot, condition, err)
+		}
+		if !done {
+			log.V(1).Info("Job running, requeue in 10 seconds", "job", snapshot.Status.Job)
+			conditions.MarkFalse(snapshot, condition, reason, clusterv1.ConditionSeverityInfo, "%s %s", snapshot.Status.Job.Name, snapshot.Status.Job.Progress)
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+		if restoring {
+			return r.finishRestore(ctx, snapshot, scvmmMachine)
+		}
+	}
+	if restoreRequested(snapshot) && !snapshot.Status.Ready {
+		// Restoring is destructive, so it is not done for a request that was there before the checkpoint
+		log.Info("Refusing restore of checkpoint that is not ready", "request", snapshot.Spec.RestoreRequest)
+		snapshot.Status.LastRestoreRequest = snapshot.Spec.RestoreRequest
+		conditions.MarkFalse(snapshot, SnapshotRestored, RestoreRefusedReason, clusterv1.ConditionSeverityWarning, "Checkpoint was not ready for restore request %s", snapshot.Spec.RestoreRequest)
+		r.recorder.Eventf(snapshot, corev1.EventTypeWarning, RestoreRefusedReason, "Checkpoint was not ready for restore request %s", snapshot.Spec.RestoreRequest)
+	}
+	if snapshot.Status.CheckpointId == "" {
+		return r.createCheckpoint(ctx, snapshot, scvmmMachine)
+	}
+	snapshot.Status.Ready = true
+	conditions.MarkTrue(snapshot, SnapshotCreated)
+
+	if restoreRequested(snapshot) {
+		return r.restoreCheckpoint(ctx, snapshot, scvmmMachine)
+	}
+	return ctrl.Result{}, nil
+}
+
+// A restore is requested by setting restoreRequest to a value that was not handled yet
+func restoreRequested(snapshot *infrav1.ScvmmMachineSnapshot) bool {
+	return snapshot.Spec.RestoreRequest != "" && snapshot.Spec.RestoreRequest != snapshot.Status.LastRestoreRequest
+}
+
+func (r *ScvmmMachineSnapshotReconciler) createCheckpoint(ctx context.Context, snapshot *infrav1.ScvmmMachineSnapshot, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "CreateVMCheckpoint -ID '%s' -Name '%s' -Description '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id),
+		escapeSingleQuotes(snapshot.Name),
+		escapeSingleQuotes(snapshot.Spec.Description))
+	if err != nil {
+		return r.snapshotError(snapshot, SnapshotCreated, err)
+	}
+	snapshot.Status.CheckpointId = res.Result
+	snapshot.Status.CreationTime = res.CreationTime
+	snapshot.Status.Job = newVMJob(res)
+	r.recorder.Eventf(snapshot, corev1.EventTypeNormal, SnapshotCreatingReason, "Creating checkpoint of %s", scvmmMachine.Spec.VMName)
+	if snapshot.Status.Job == nil {
+		// Checkpoint was already there
+		snapshot.Status.Ready = true
+		conditions.MarkTrue(snapshot, SnapshotCreated)
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(snapshot, SnapshotCreated, SnapshotCreatingReason, clusterv1.ConditionSeverityInfo, "")
+	return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+}
+
+func (r *ScvmmMachineSnapshotReconciler) restoreCheckpoint(ctx context.Context, snapshot *infrav1.ScvmmMachineSnapshot, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Restoring checkpoint", "checkpoint", snapshot.Status.CheckpointId)
+	res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "RestoreVMCheckpoint -ID '%s' -CheckpointID '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id),
+		escapeSingleQuotes(snapshot.Status.CheckpointId))
+	if err != nil {
+		return r.snapshotError(snapshot, SnapshotRestored, err)
+	}
+	r.recorder.Eventf(snapshot, corev1.EventTypeNormal, SnapshotRestoringReason, "Restoring %s to checkpoint", scvmmMachine.Spec.VMName)
+	snapshot.Status.Job = newVMJob(res)
+	if snapshot.Status.Job == nil {
+		return r.finishRestore(ctx, snapshot, scvmmMachine)
+	}
+	conditions.MarkFalse(snapshot, SnapshotRestored, SnapshotRestoringReason, clusterv1.ConditionSeverityInfo, "")
+	return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+}
+
+// Power the VM back on after it has been reverted to the checkpoint
+func (r *ScvmmMachineSnapshotReconciler) finishRestore(ctx context.Context, snapshot *infrav1.ScvmmMachineSnapshot, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	vm, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "GetVM -ID '%s'",
+		escapeSingleQuotes(scvmmMachine.Spec.Id))
+	if err == nil && vm.Status != "Running" {
+		vm, err = sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "StartVM -ID '%s'",
+			escapeSingleQuotes(scvmmMachine.Spec.Id))
+	}
+	if err != nil {
+		// Stay in restoring state, so starting the vm is retried
+		conditions.MarkFalse(snapshot, SnapshotRestored, SnapshotRestoringReason, clusterv1.ConditionSeverityWarning, "Failed to start vm: %v", err)
+		r.recorder.Eventf(snapshot, corev1.EventTypeWarning, SnapshotRestoringReason, "Failed to start vm: %v", err)
+		return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	}
+	snapshot.Status.LastRestoreRequest = snapshot.Spec.RestoreRequest
+	snapshot.Status.LastRestoreTime = metav1.Now()
+	conditions.MarkTrue(snapshot, SnapshotRestored)
+	r.recorder.Eventf(snapshot, corev1.EventTypeNormal, SnapshotRestoringReason, "Restored %s to checkpoint", vm.Name)
+	return ctrl.Result{}, nil
+}
+
+func (r *ScvmmMachineSnapshotReconciler) reconcileDelete(ctx context.Context, snapshot *infrav1.ScvmmMachineSnapshot, scvmmMachine *infrav1.ScvmmMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(snapshot, SnapshotFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	// If the machine is gone, the checkpoint went with the vm
+	if scvmmMachine == nil || scvmmMachine.Spec.Id == "" {
+		log.V(1).Info("Machine is gone, remove finalizer")
+		controllerutil.RemoveFinalizer(snapshot, SnapshotFinalizer)
+		return ctrl.Result{}, nil
+	}
+	if snapshot.Status.Job != nil {
+		deleting := conditions.GetReason(snapshot, SnapshotCreated) == SnapshotDeletingReason
+		done, err := pollJob(ctx, scvmmMachine.Spec.ProviderRef, snapshot.Status.Job)
+		if !done {
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			log.V(1).Info("Job running, requeue in 10 seconds", "job", snapshot.Status.Job)
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+		snapshot.Status.Job = nil
+		if err != nil {
+			// Removal will be retried
+			return r.snapshotError(snapshot, SnapshotCreated, err)
+		}
+		if deleting {
+			snapshot.Status.CheckpointId = ""
+		}
+	}
+	if snapshot.Status.CheckpointId != "" {
+		log.Info("Removing checkpoint", "checkpoint", snapshot.Status.CheckpointId)
+		res, err := sendWinrmCommand(log, scvmmMachine.Spec.ProviderRef, "RemoveVMCheckpoint -ID '%s' -CheckpointID '%s'",
+			escapeSingleQuotes(scvmmMachine.Spec.Id),
+			escapeSingleQuotes(snapshot.Status.CheckpointId))
+		if err != nil {
+			return r.snapshotError(snapshot, SnapshotCreated, err)
+		}
+		snapshot.Status.Ready = false
+		if res.Message != "Removed" {
+			snapshot.Status.Job = newVMJob(res)
+			conditions.MarkFalse(snapshot, SnapshotCreated, SnapshotDeletingReason, clusterv1.ConditionSeverityInfo, "")
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+		snapshot.Status.CheckpointId = ""
+	}
+	log.V(1).Info("Checkpoint is removed, remove finalizer")
+	r.recorder.Eventf(snapshot, corev1.EventTypeNormal, SnapshotDeletingReason, "Removed checkpoint of %s", scvmmMachine.Spec.VMName)
+	controllerutil.RemoveFinalizer(snapshot, SnapshotFinalizer)
+	return ctrl.Result{}, nil
+}
+
+func (r *ScvmmMachineSnapshotReconciler) snapshotError(snapshot *infrav1.ScvmmMachineSnapshot, condition clusterv1.ConditionType, err error) (ctrl.Result, error) {
+	r.recorder.Eventf(snapshot, corev1.EventTypeWarning, SnapshotFailedReason, "%v", err)
+	conditions.MarkFalse(snapshot, condition, SnapshotFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+	scriptError := &ScriptError{}
+	jobError := &JobFailedError{}
+	if !errors.As(err, &scriptError) && !errors.As(err, &jobError) {
+		return ctrl.Result{}, errors.Wrap(err, SnapshotFailedReason)
+	}
+	// Requeue script and job errors after 60 seconds to give scvmm a breather
+	return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+}
+
+func patchScvmmMachineSnapshot(ctx context.Context, patchHelper *patch.Helper, snapshot *infrav1.ScvmmMachineSnapshot) error {
+	conditions.SetSummary(snapshot,
+		conditions.WithConditions(
+			SnapshotCreated,
+			SnapshotRestored,
+		),
+	)
+
+	return patchHelper.Patch(
+		ctx,
+		snapshot,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			SnapshotCreated,
+			SnapshotRestored,
+		}},
+	)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScvmmMachineSnapshotReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	r.recorder = mgr.GetEventRecorderFor("caps-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ScvmmMachineSnapshot{}).
+		WithOptions(options).
+		Complete(r)
+}
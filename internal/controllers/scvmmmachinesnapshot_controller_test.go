@@ -0,0 +1,154 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
+)
+
+// A machine whose checkpoint jobs finish right away
+func snapshotTestSetup(t *testing.T, snapshot *infrav1.ScvmmMachineSnapshot) (*fakeEnv, *ScvmmMachineSnapshotReconciler) {
+	scvmmMachine := &infrav1.ScvmmMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm01", UID: "machine-uid"},
+		Spec: infrav1.ScvmmMachineSpec{
+			ProviderRef: testProviderRef(),
+			VMName:      "vm01",
+			Id:          "vm-1",
+		},
+	}
+	conditions.MarkTrue(scvmmMachine, VmCreated)
+	env := newFakeEnv(t, scvmmMachine, snapshot)
+	env.scvmm.handle("CreateVMCheckpoint", func(string) VMResult {
+		return VMResult{Result: "checkpoint-1", JobId: "job-create"}
+	})
+	env.scvmm.handle("RestoreVMCheckpoint", func(string) VMResult {
+		return VMResult{JobId: "job-restore"}
+	})
+	env.scvmm.handle("GetJob", func(string) VMResult {
+		return VMResult{Name: "job", Status: "Completed"}
+	})
+	env.scvmm.handle("GetVM", func(string) VMResult {
+		return VMResult{Id: "vm-1", Name: "vm01", Status: "PowerOff"}
+	})
+	env.scvmm.handle("StartVM", func(string) VMResult {
+		return VMResult{Id: "vm-1", Name: "vm01", Status: "Running"}
+	})
+	env.scvmm.handle("RemoveVMCheckpoint", func(string) VMResult {
+		return VMResult{Message: "Removed"}
+	})
+	return env, &ScvmmMachineSnapshotReconciler{Client: env.client, recorder: record.NewFakeRecorder(100)}
+}
+
+func TestSnapshotCreateRestoreDelete(t *testing.T) {
+	ctx := context.Background()
+	snapshot := &infrav1.ScvmmMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "before-upgrade"},
+		Spec: infrav1.ScvmmMachineSnapshotSpec{
+			MachineRef: corev1.LocalObjectReference{Name: "vm01"},
+		},
+	}
+	env, r := snapshotTestSetup(t, snapshot)
+
+	// Finalizer, create checkpoint, wait for the job
+	env.reconcile(t, r, snapshot, 3)
+	if !snapshot.Status.Ready || snapshot.Status.CheckpointId != "checkpoint-1" || !conditions.IsTrue(snapshot, SnapshotCreated) {
+		t.Fatalf("checkpoint not created: %+v", snapshot.Status)
+	}
+	if len(snapshot.OwnerReferences) != 1 || snapshot.OwnerReferences[0].Name != "vm01" {
+		t.Errorf("snapshot not owned by the machine: %v", snapshot.OwnerReferences)
+	}
+	if count := env.scvmm.calledCount("CreateVMCheckpoint"); count != 1 {
+		t.Errorf("CreateVMCheckpoint called %d times, want 1", count)
+	}
+
+	// Unrelated spec changes don't restore
+	snapshot.Spec.Description = "changed"
+	if err := env.client.Update(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, snapshot, 1)
+	if count := env.scvmm.calledCount("RestoreVMCheckpoint"); count != 0 {
+		t.Fatalf("RestoreVMCheckpoint called %d times without a restore request", count)
+	}
+
+	// A new request restores once, and turns the vm back on
+	snapshot.Spec.RestoreRequest = "first"
+	if err := env.client.Update(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, snapshot, 3)
+	if count := env.scvmm.calledCount("RestoreVMCheckpoint"); count != 1 {
+		t.Errorf("RestoreVMCheckpoint called %d times, want 1", count)
+	}
+	if count := env.scvmm.calledCount("StartVM"); count != 1 {
+		t.Errorf("StartVM called %d times, want 1", count)
+	}
+	if snapshot.Status.LastRestoreRequest != "first" || !conditions.IsTrue(snapshot, SnapshotRestored) {
+		t.Errorf("restore not recorded: %+v", snapshot.Status)
+	}
+
+	// Delete removes the checkpoint before the finalizer
+	if err := env.client.Delete(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile(t, r, snapshot, 1)
+	if count := env.scvmm.calledCount("RemoveVMCheckpoint"); count != 1 {
+		t.Errorf("RemoveVMCheckpoint called %d times, want 1", count)
+	}
+	if err := env.client.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot); !apierrors.IsNotFound(err) {
+		t.Errorf("snapshot not deleted: %v", err)
+	}
+}
+
+func TestSnapshotRefusesRestoreBeforeReady(t *testing.T) {
+	snapshot := &infrav1.ScvmmMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "restore-on-create"},
+		Spec: infrav1.ScvmmMachineSnapshotSpec{
+			MachineRef:     corev1.LocalObjectReference{Name: "vm01"},
+			RestoreRequest: "too-early",
+		},
+	}
+	env, r := snapshotTestSetup(t, snapshot)
+
+	env.reconcile(t, r, snapshot, 5)
+	if !snapshot.Status.Ready {
+		t.Fatalf("checkpoint not created: %+v", snapshot.Status)
+	}
+	if count := env.scvmm.calledCount("RestoreVMCheckpoint"); count != 0 {
+		t.Errorf("RestoreVMCheckpoint called %d times for a request made before the checkpoint was ready", count)
+	}
+	if reason := conditions.GetReason(snapshot, SnapshotRestored); reason != RestoreRefusedReason {
+		t.Errorf("SnapshotRestored reason = %q, want %q", reason, RestoreRefusedReason)
+	}
+	if snapshot.Status.LastRestoreRequest != "too-early" {
+		t.Errorf("refused request not recorded: %+v", snapshot.Status)
+	}
+	if severity := conditions.GetSeverity(snapshot, SnapshotRestored); severity == nil || *severity != clusterv1.ConditionSeverityWarning {
+		t.Errorf("refused restore is not a warning: %v", snapshot.Status.Conditions)
+	}
+}
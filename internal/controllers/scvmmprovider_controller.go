@@ -20,13 +20,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrav1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 )
 
 // ScvmmProviderReconciler reconciles a ScvmmProvider object
@@ -37,12 +38,15 @@ type ScvmmProviderReconciler struct {
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmproviders,verbs=get;list;watch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmproviders/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmproviders/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=scvmmmachines,verbs=get;list;watch
 
-// This reconcile loop currently just reads the providers into memory for the winrm workers
+// This reconcile loop reads the providers into memory for the winrm workers
 // Seemed the easiest way to force the workers to reload when the provider changes, without having
 // to read them every time
+// It also runs the cloud-init janitor periodically
 func (r *ScvmmProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	// log := ctrl.LoggerFrom(ctx).WithValues("scvmmprovider", req.NamespacedName)
+	log := ctrl.LoggerFrom(ctx).WithValues("scvmmprovider", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
 
 	providerRef := infrav1.ScvmmProviderReference{
 		Name:      req.NamespacedName.Name,
@@ -54,11 +58,13 @@ func (r *ScvmmProviderReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{}, err
 		}
 		delete(winrmProviders, providerRef)
+		delete(janitorLastRun, providerRef)
 		return ctrl.Result{}, nil
 	}
 
 	if !scvmmProvider.DeletionTimestamp.IsZero() {
 		delete(winrmProviders, providerRef)
+		delete(janitorLastRun, providerRef)
 		return ctrl.Result{}, nil
 	}
 	// TODO: Verify that the scripts work and add status field
@@ -68,6 +74,20 @@ func (r *ScvmmProviderReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		ResourceVersion: scvmmProvider.ResourceVersion,
 	}
 
+	if janitor := scvmmProvider.Spec.CloudInit.Janitor; janitor != nil {
+		interval := janitorInterval(janitor)
+		if lastRun, ok := janitorLastRun[providerRef]; ok && time.Since(lastRun) < interval {
+			return ctrl.Result{RequeueAfter: interval - time.Since(lastRun)}, nil
+		}
+		janitorLastRun[providerRef] = time.Now()
+		if err := runCloudInitJanitor(ctx, r.Client, providerRef, &scvmmProvider.Spec); err != nil {
+			// Just try again next time
+			log.Error(err, "Cloud-init janitor failed")
+			janitorErrors.WithLabelValues(providerRef.Namespace + "/" + providerRef.Name).Inc()
+		}
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+	delete(janitorLastRun, providerRef)
 	return ctrl.Result{}, nil
 }
 
@@ -27,7 +27,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	infrastructurev1alpha1 "github.com/willemm/cluster-api-provider-scvmm/api/v1alpha1"
+	infrastructurev1beta1 "github.com/willemm/cluster-api-provider-scvmm/api/v1beta1"
 )
 
 var _ = Describe("ScvmmProvider Controller", func() {
@@ -40,13 +40,13 @@ var _ = Describe("ScvmmProvider Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		scvmmprovider := &infrastructurev1alpha1.ScvmmProvider{}
+		scvmmprovider := &infrastructurev1beta1.ScvmmProvider{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind ScvmmProvider")
 			err := k8sClient.Get(ctx, typeNamespacedName, scvmmprovider)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &infrastructurev1alpha1.ScvmmProvider{
+				resource := &infrastructurev1beta1.ScvmmProvider{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
@@ -59,7 +59,7 @@ var _ = Describe("ScvmmProvider Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &infrastructurev1alpha1.ScvmmProvider{}
+			resource := &infrastructurev1beta1.ScvmmProvider{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -0,0 +1,17 @@
+param($hostgroup, $name)
+try {
+  $hg = Get-SCVMHostGroup -Name $hostgroup | select -first 1
+  if (-not $hg) {
+    throw "Host group $hostgroup not found"
+  }
+  # Availability sets are defined on the host clusters in (or under) the host group
+  $clusters = @(Get-SCVMHostCluster | Where-Object { $_.HostGroup.Path -eq $hg.Path -or "$($_.HostGroup.Path)".StartsWith("$($hg.Path)\") })
+  foreach ($cluster in $clusters) {
+    if (@($cluster.AvailabilitySetNames) -notcontains $name) {
+      Set-SCVMHostCluster -VMHostCluster $cluster -AvailabilitySetNames (@($cluster.AvailabilitySetNames) + $name) | out-null
+    }
+  }
+  return @{ Message = "Availability set $name on $($clusters.Count) host clusters" } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Add Availability Set' $_
+}
@@ -0,0 +1,70 @@
+param($server, $zone, $name, $addresses, $ttl, $ptr)
+try {
+  $cimparam = @{}
+  if ($server) {
+    $cimparam.ComputerName = $server
+  }
+  if (${env:ACTIVEDIRECTORY_USERNAME} -and ${env:ACTIVEDIRECTORY_PASSWORD}) {
+    $cimparam.Credential = new-object PSCredential(${env:ACTIVEDIRECTORY_USERNAME}, (ConvertTo-Securestring -force -AsPlainText -String ${env:ACTIVEDIRECTORY_PASSWORD}))
+  }
+  $session = New-CimSession @cimparam
+  try {
+    $ttlparam = @{}
+    if ($ttl) {
+      $ttlparam.TimeToLive = New-TimeSpan -Seconds $ttl
+    }
+    $fqdn = "$($name).$($zone)."
+    $existing = @(Get-DnsServerResourceRecord -CimSession $session -ZoneName $zone -Name $name -ErrorAction SilentlyContinue |
+      Where-Object { $_.RecordType -eq 'A' -or $_.RecordType -eq 'AAAA' })
+    $current = @()
+    # Remove the records of addresses the machine no longer has
+    foreach ($rec in $existing) {
+      if ($rec.RecordType -eq 'A') {
+        $addr = "$($rec.RecordData.IPv4Address)"
+      } else {
+        $addr = "$($rec.RecordData.IPv6Address)"
+      }
+      if ($addresses -contains $addr) {
+        $current += $addr
+        continue
+      }
+      Remove-DnsServerResourceRecord -CimSession $session -ZoneName $zone -InputObject $rec -Force
+      $reverse = ReverseDNSRecord $session $addr
+      if ($reverse) {
+        Get-DnsServerResourceRecord -CimSession $session -ZoneName $reverse.Zone -Name $reverse.Name -RRType Ptr -ErrorAction SilentlyContinue |
+          Where-Object { $_.RecordData.PtrDomainName -eq $fqdn } |
+          Remove-DnsServerResourceRecord -CimSession $session -ZoneName $reverse.Zone -Force
+      }
+    }
+    $noreverse = @()
+    foreach ($addr in $addresses) {
+      if ($current -notcontains $addr) {
+        if (([System.Net.IPAddress]::Parse($addr)).AddressFamily -eq 'InterNetwork') {
+          Add-DnsServerResourceRecordA -CimSession $session -ZoneName $zone -Name $name -IPv4Address $addr @ttlparam
+        } else {
+          Add-DnsServerResourceRecordAAAA -CimSession $session -ZoneName $zone -Name $name -IPv6Address $addr @ttlparam
+        }
+      }
+      if ($ptr) {
+        $reverse = ReverseDNSRecord $session $addr
+        if (-not $reverse) {
+          $noreverse += $addr
+          continue
+        }
+        $ptrs = @(Get-DnsServerResourceRecord -CimSession $session -ZoneName $reverse.Zone -Name $reverse.Name -RRType Ptr -ErrorAction SilentlyContinue)
+        if (-not ($ptrs | Where-Object { $_.RecordData.PtrDomainName -eq $fqdn })) {
+          Add-DnsServerResourceRecordPtr -CimSession $session -ZoneName $reverse.Zone -Name $reverse.Name -PtrDomainName $fqdn @ttlparam
+        }
+      }
+    }
+    $message = "Registered $($fqdn) as $($addresses -join ', ')"
+    if ($noreverse) {
+      $message += ", no reverse lookup zone for $($noreverse -join ', ')"
+    }
+    return @{ Message = $message } | convertto-json -Compress
+  } finally {
+    Remove-CimSession $session
+  }
+} catch {
+  ErrorToJson 'Add DNS Records' $_
+}
@@ -0,0 +1,22 @@
+param($cloud, $hostgroup)
+try {
+  if (-not (Get-SCCloud -Name $cloud)) {
+    throw "Cloud $cloud not found"
+  }
+  $hg = Get-SCVMHostGroup -Name $hostgroup | select -first 1
+  if (-not $hg) {
+    throw "Host group $hostgroup not found"
+  }
+  $hosts = @(Get-SCVMHost -VMHostGroup $hg)
+  $responding = @($hosts | Where-Object { "$($_.CommunicationState)" -eq 'Responding' })
+  $result = "Ready"
+  if ($responding.Count -eq 0) {
+    $result = "NoHosts"
+  }
+  return @{
+    Result = $result
+    Message = "$($responding.Count) of $($hosts.Count) hosts responding"
+  } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Check Failure Domain' $_
+}
@@ -1,11 +1,17 @@
-param($cloud, $hostgroup, $vmname, $vmtemplate, [int]$memory, [int]$memorymin, [int]$memorymax, [int]$memorybuffer, [int]$cpucount, $disks, $networkdevices, $fibrechannel, $hardwareprofile, $operatingsystem, $availabilityset, $vmoptions)
+param($cloud, $hostgroup, $vmname, $vmtemplate, [int]$memory, [int]$memorymin, [int]$memorymax, [int]$memorybuffer, [int]$cpucount, $disks, $networkdevices, $fibrechannel, $hardwareprofile, $operatingsystem, $availabilityset, $vmoptions, $vmhost, $ostype)
 try {
   $generation = 1
   if ($vmtemplate) {
     $VMTemplateObj = Get-SCVMTemplate -Name $vmtemplate
+    if (-not $VMTemplateObj) {
+      throw "VM template $vmtemplate not found"
+    }
     $generation = $VMTemplateObj.Generation
   } else {
     $HardwareProfile = Get-SCHardwareProfile | Where-Object {$_.Name -eq $hardwareprofile }
+    if (-not $HardwareProfile) {
+      throw "Hardware profile $hardwareprofile not found"
+    }
     $generation = $HardwareProfile.generation
   }
 
@@ -65,7 +71,11 @@ try {
       $LinuxOS = $VirtualHardDisk.OperatingSystem
     } else {
       if (-not $operatingsystem) {
-        $operatingsystem = 'Other Linux (64 bit)'
+        if ($ostype -eq 'Windows') {
+          $operatingsystem = 'Windows Server 2022 Standard'
+        } else {
+          $operatingsystem = 'Other Linux (64 bit)'
+        }
       }
       $LinuxOS = Get-SCOperatingSystem | Where-Object {$_.name -eq $operatingsystem }
     }
@@ -77,12 +87,47 @@ try {
   $networkslot = 0
   foreach ($networkdevice in ($networkdevices | ConvertFrom-Json)) {
     $VMNetwork = Get-SCVMNetwork -Name $networkdevice.VMNetwork
-    $VMSubnet = $VMNetwork.VMSubnet | Select-Object -First 1
+    if (-not $VMNetwork) {
+      throw "VM network $($networkdevice.VMNetwork) not found"
+    }
+    if ($networkdevice.vmSubnet) {
+      $VMSubnet = $VMNetwork.VMSubnet | Where-Object { $_.Name -eq $networkdevice.vmSubnet }
+      if (-not $VMSubnet) {
+        throw "VM subnet $($networkdevice.vmSubnet) not found in VM network $($networkdevice.VMNetwork)"
+      }
+    } else {
+      $VMSubnet = $VMNetwork.VMSubnet | Select-Object -First 1
+    }
 
+    $nicargs = @{
+      JobGroup = $JobGroupID
+      SlotID = $networkslot
+      VMNetwork = $VMNetwork
+      VMSubnet = $VMSubnet
+    }
+    if ($networkdevice.macAddress) {
+      $nicargs.MACAddressType = 'Static'
+      $nicargs.MACAddress = $networkdevice.macAddress.Replace('-', ':').ToUpper()
+    } elseif ($networkdevice.macAddressFromPool) {
+      $nicargs.MACAddressType = 'Static'
+    }
+    if ($networkdevice.vlanID) {
+      $nicargs.VLanEnabled = $true
+      $nicargs.VLanID = $networkdevice.vlanID
+    }
+    if ($networkdevice.portClassification) {
+      $nicargs.PortClassification = Get-SCPortClassification -Name $networkdevice.portClassification
+      if (-not $nicargs.PortClassification) {
+        throw "Port classification $($networkdevice.portClassification) not found"
+      }
+    }
+    if ($networkdevice.staticIPAddressPool) {
+      $nicargs.IPv4AddressType = 'Static'
+    }
     if ($networkslot -eq 0) {
-      Set-SCVirtualNetworkAdapter -JobGroup $JobGroupID -SlotID $networkslot -VMNetwork $VMNetwork -VMSubnet $VMSubnet
+      Set-SCVirtualNetworkAdapter @nicargs
     } else {
-      New-SCVirtualNetworkAdapter -JobGroup $JobGroupID -SlotID $networkslot -VMNetwork $VMNetwork -VMSubnet $VMSubnet
+      New-SCVirtualNetworkAdapter @nicargs
     }
     $networkslot = $networkslot + 1
   }
@@ -121,6 +166,30 @@ try {
   } else {
     $vmargs.VMConfiguration = New-SCVMConfiguration -VMTemplate $VMTemplateObj -Name $vmname -VMHostGroup $hostgroup
   }
+  if ($vmhost) {
+    # Placement was done beforehand
+    $targethost = Get-SCVMHost -ComputerName $vmhost
+    if (-not $targethost) {
+      throw "Host $vmhost not found"
+    }
+    Set-SCVMConfiguration -VMConfiguration $vmargs.VMConfiguration -VMHost $targethost | out-null
+  }
+  $networkslot = 0
+  foreach ($networkdevice in ($networkdevices | ConvertFrom-Json)) {
+    if ($networkdevice.staticIPAddressPool) {
+      $ippool = Get-SCStaticIPAddressPool -Name $networkdevice.staticIPAddressPool
+      if (-not $ippool) {
+        throw "Static IP address pool $($networkdevice.staticIPAddressPool) not found"
+      }
+      $nicconfig = Get-SCVirtualNetworkAdapterConfiguration -VMConfiguration $vmargs.VMConfiguration | Where-Object { $_.SlotID -eq $networkslot }
+      Set-SCVirtualNetworkAdapterConfiguration -VirtualNetworkAdapterConfiguration $nicconfig -IPv4AddressPool $ippool | out-null
+      $updateconfig = $true
+    }
+    $networkslot = $networkslot + 1
+  }
+  if ($updateconfig) {
+    Update-SCVMConfiguration -VMConfiguration $vmargs.VMConfiguration | out-null
+  }
   $vmargs.Cloud = Get-SCCloud -Name $cloud
 
   if ($memorymin -ge 0) {
@@ -136,7 +205,7 @@ try {
     $vmargs.DynamicMemoryBuffer = $memorybuffer
   }
   if ($memory -gt 0) { $vmargs.MemoryMB = $memory }
-  $vm = New-SCVirtualMachine @vmargs -JobGroup $JobGroupID -RunAsynchronously -ErrorAction Stop
+  $vm = New-SCVirtualMachine @vmargs -JobGroup $JobGroupID -RunAsynchronously -JobVariable 'createjob' -ErrorAction Stop
   if ($vm.Status -eq 'CreationFailed') {
     $msg = "Unknown error"
     if ($vm.MostRecentTaskIfLocal.ErrorInfo) {
@@ -145,7 +214,7 @@ try {
     throw "Creation Failed: $msg"
   }
 
-  return VMToJson $vm "Creating"
+  return VMToJson $vm "Creating" $createjob
 } catch {
   if ($VMTemplateObj) {
     try {
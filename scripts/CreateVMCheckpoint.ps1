@@ -0,0 +1,21 @@
+param($id, $name, $description)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  # Don't create a second checkpoint if a previous call got lost
+  $cp = Get-SCVMCheckpoint -VM $vm | Where-Object { $_.Name -eq $name } | select -first 1
+  if (-not $cp) {
+    $cp = New-SCVMCheckpoint -VM $vm -Name $name -Description $description -RunAsynchronously -JobVariable 'cpjob'
+  }
+  $cpjson = @{
+    Result = "$($cp.ID)"
+    Message = "Creating checkpoint $name"
+  }
+  if ($cp.AddedTime -ne $null) { $cpjson.CreationTime = $cp.AddedTime.ToString('o') }
+  if ($cpjob) { $cpjson.JobId = "$($cpjob.ID)" }
+  return $cpjson | convertto-json -Compress
+} catch {
+  ErrorToJson 'Create VM Checkpoint' $_
+}
@@ -1,4 +1,4 @@
-param($id, $disks)
+param($id, $disks, [switch]$online)
 try {
   $disklist = $disks | ConvertFrom-Json
   $vm = Get-SCVirtualMachine -ID $id
@@ -6,12 +6,22 @@ try {
     throw "Virtual Machine $id not found"
   }
   foreach ($vhdisk in $vm.VirtualDiskDrives) {
+    # Spec disks are attached on bus 0, lun is the index in the disk list
+    if ($vhdisk.Bus -ne 0 -or $vhdisk.LUN -ge $disklist.Count) {
+      continue
+    }
+    # Hyper-V can only expand SCSI attached disks while the VM is running
+    if ($online -and "$($vhdisk.BusType)" -ne 'SCSI') {
+      continue
+    }
     $lun = $vhdisk.LUN
     if ((($disklist[$lun].sizeMB - 1) * 1024 * 1024) -gt $vhdisk.VirtualHardDisk.MaximumSize) {
-      $vdd = Expand-SCVirtualDiskDrive -VirtualDiskDrive $vhdisk -VirtualHardDiskSizeGB ($disklist[$lun].sizeMB / 1024) -RunAsynchronously
+      Expand-SCVirtualDiskDrive -VirtualDiskDrive $vhdisk -VirtualHardDiskSizeGB ($disklist[$lun].sizeMB / 1024) -RunAsynchronously -JobVariable 'expandjob' | out-null
+      # One disk at a time, so the job can be tracked
+      return VMToJson $vm "Resizing disk $lun" $expandjob
     }
   }
-  return VMToJson $vm "Resizing"
+  return VMToJson $vm "Disks up to date"
 } catch {
   ErrorToJson 'Expand VM Disks' $_
 }
@@ -0,0 +1,18 @@
+param($name)
+try {
+  $pool = Get-SCStaticIPAddressPool -Name $name
+  if (-not $pool) {
+    throw "Static IP address pool $name not found"
+  }
+  return @{
+    Name = "$($pool.Name)"
+    Result = "$($pool.Subnet)"
+    Gateway = "$($pool.DefaultGateways | Select-Object -First 1 -ExpandProperty IPAddress)"
+    DNSServers = @($pool.DNSServers | %{ "$_" })
+    DNSSearchSuffixes = @($pool.DNSSearchSuffixes | %{ "$_" })
+    TotalAddresses = [int]$pool.TotalAddresses
+    AvailableAddresses = [int]$pool.AvailableAddresses
+  } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Get IP Pool' $_
+}
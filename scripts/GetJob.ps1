@@ -0,0 +1,22 @@
+param($id)
+try {
+  $job = Get-SCJob -ID $id
+  if (-not $job) {
+    throw "Job $id not found"
+  }
+  $jobjson = @{
+    JobId = "$($job.ID)"
+    Name = "$($job.Name)"
+    Status = "$($job.Status)"
+    Progress = "$($job.Progress)"
+  }
+  if ($job.ErrorInfo -and $job.ErrorInfo.Problem) {
+    $jobjson.ErrorInfo = "$($job.ErrorInfo.Problem)"
+    if ($job.ErrorInfo.RecommendedAction) {
+      $jobjson.ErrorInfo += " $($job.ErrorInfo.RecommendedAction)"
+    }
+  }
+  return $jobjson | convertto-json -Compress
+} catch {
+  ErrorToJson 'Get Job' $_
+}
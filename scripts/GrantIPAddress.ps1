@@ -0,0 +1,25 @@
+param($pool, $id, $description)
+try {
+  $ippool = Get-SCStaticIPAddressPool -Name $pool
+  if (-not $ippool) {
+    throw "Static IP address pool $pool not found"
+  }
+  # Don't grant a second address if a previous call got lost
+  $ip = Get-SCIPAddress -GrantToObjectID $id | Where-Object { $_.AllocatingAddressPool.ID -eq $ippool.ID } | select -first 1
+  if (-not $ip) {
+    $ip = Grant-SCIPAddress -GrantToObjectType 'VirtualMachine' -GrantToObjectID $id -StaticIPAddressPool $ippool -Description $description
+  }
+  if (-not $ip) {
+    throw "No address available in static IP address pool $pool"
+  }
+  return @{
+    Result = "$($ip.Address)"
+    PrefixLength = [int]("$($ippool.Subnet)".Split('/')[1])
+    Gateway = "$($ippool.DefaultGateways | Select-Object -First 1 -ExpandProperty IPAddress)"
+    DNSServers = @($ippool.DNSServers | %{ "$_" })
+    DNSSearchSuffixes = @($ippool.DNSSearchSuffixes | %{ "$_" })
+    Message = "Granted $($ip.Address) from $pool"
+  } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Grant IP Address' $_
+}
@@ -0,0 +1,95 @@
+param($id, $vmhost, $hostgroup, $cloud, $path, [switch]$uselan, $requirehostgroup, $requirecloud)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  if ($vmhost) {
+    $targethost = Get-SCVMHost -ComputerName $vmhost
+    if (-not $targethost) {
+      throw "Host $vmhost not found"
+    }
+  } else {
+    # Let SCVMM pick the best rated host in the host group or cloud
+    # The host group and the cloud are different parameter sets, the host group wins
+    $ratingargs = @{ VM = $vm }
+    if ($hostgroup) {
+      $group = Get-SCVMHostGroup -Name $hostgroup | select -first 1
+      if (-not $group) {
+        throw "Host group $hostgroup not found"
+      }
+      $ratingargs.VMHost = @(Get-SCVMHost -VMHostGroup $group)
+    } elseif ($cloud) {
+      $ratingargs.Cloud = Get-SCCloud -Name $cloud
+      if (-not $ratingargs.Cloud) {
+        throw "Cloud $cloud not found"
+      }
+    } else {
+      $ratingargs.VMHost = @(Get-SCVMHost)
+    }
+    $rating = Get-SCVMHostRating @ratingargs -IsMigration |
+      Where-Object { $_.Rating -gt 0 -and $_.VMHost.Name -ne $vm.VMHost.Name } |
+      Sort-Object -Property Rating -Descending | select -first 1
+    if (-not $rating) {
+      throw "No suitable host found for $($vm.Name)"
+    }
+    $targethost = $rating.VMHost
+  }
+  if ($requirehostgroup) {
+    # The target host has to be in the host group of the failure domain, or one of its children
+    $hg = $targethost.VMHostGroup
+    while ($hg -and $hg.Name -ne $requirehostgroup -and $hg.Path -ne $requirehostgroup) {
+      $hg = $hg.ParentHostGroup
+    }
+    if (-not $hg) {
+      return @{
+        Result = "Refused"
+        Message = "Host $($targethost.Name) is not in host group $requirehostgroup"
+      } | convertto-json -Compress
+    }
+  }
+  if ($requirecloud) {
+    if ($cloud -and $cloud -ne $requirecloud) {
+      return @{
+        Result = "Refused"
+        Message = "Cloud $cloud is not $requirecloud"
+      } | convertto-json -Compress
+    }
+    # The target host has to be in a host group of the cloud of the failure domain, or one of their children
+    $reqcloud = Get-SCCloud -Name $requirecloud
+    if (-not $reqcloud) {
+      throw "Cloud $requirecloud not found"
+    }
+    $cloudgroups = @($reqcloud.HostGroup | ForEach-Object { $_.ID })
+    $hg = $targethost.VMHostGroup
+    while ($hg -and $cloudgroups -notcontains $hg.ID) {
+      $hg = $hg.ParentHostGroup
+    }
+    if (-not $hg) {
+      return @{
+        Result = "Refused"
+        Message = "Host $($targethost.Name) is not in cloud $requirecloud"
+      } | convertto-json -Compress
+    }
+  }
+  if ($targethost.Name -eq $vm.VMHost.Name) {
+    return VMToJson $vm "Already on $($targethost.Name)"
+  }
+  $moveargs = @{
+    VM = $vm
+    VMHost = $targethost
+    RunAsynchronously = $true
+    JobVariable = 'movejob'
+  }
+  if ($path) {
+    $moveargs.Path = $path
+  }
+  if ($uselan) {
+    $moveargs.UseLAN = $true
+  }
+  # The move job keeps the VM locked, the cloud is set after it finishes
+  $vm = Move-SCVirtualMachine @moveargs
+  return VMToJson $vm "Migrating to $($targethost.Name)" $movejob
+} catch {
+  ErrorToJson 'Move VM' $_
+}
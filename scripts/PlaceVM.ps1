@@ -0,0 +1,41 @@
+param($hostgroup, $vmname, $vmtemplate, $hardwareprofile, [int]$memory, [double]$diskspacegb, $preferredhosts = @(), $excludedhosts = @(), [int]$minfreememory)
+try {
+  $ratingargs = @{
+    VMName = $vmname
+    DiskSpaceGB = $diskspacegb
+  }
+  if ($vmtemplate) {
+    $ratingargs.Template = Get-SCVMTemplate -Name $vmtemplate
+  } else {
+    $ratingargs.HardwareProfile = Get-SCHardwareProfile | Where-Object {$_.Name -eq $hardwareprofile }
+  }
+  $ratingargs.VMHostGroup = Get-SCVMHostGroup -Name $hostgroup | select -first 1
+  if (-not $ratingargs.VMHostGroup) {
+    throw "Host group $hostgroup not found"
+  }
+  $ratings = @(Get-SCVMHostRating @ratingargs)
+  $candidates = @($ratings | Where-Object {
+    $shortname = ($_.VMHost.Name -split '\.')[0]
+    $_.Rating -gt 0 -and
+    $excludedhosts -notcontains $_.VMHost.Name -and $excludedhosts -notcontains $shortname -and
+    ($_.VMHost.AvailableMemory - $memory) -ge $minfreememory
+  })
+  if ($candidates.Count -eq 0) {
+    $reasons = @($ratings | Where-Object { $_.Rating -le 0 -and $_.ZeroRatingReasonList } |
+      %{ "$($_.VMHost.Name): $($_.ZeroRatingReasonList | select -first 1)" })
+    return @{
+      Result = "InsufficientCapacity"
+      Message = "None of $($ratings.Count) hosts in $hostgroup has capacity for $($vmname) $($reasons -join '; ')"
+    } | convertto-json -Compress
+  }
+  $best = $candidates | Sort-Object -Property `
+    @{ Expression = { $shortname = ($_.VMHost.Name -split '\.')[0]; $preferredhosts -contains $_.VMHost.Name -or $preferredhosts -contains $shortname }; Descending = $true },
+    @{ Expression = { $_.Rating }; Descending = $true } | select -first 1
+  return @{
+    Result = "Placed"
+    VMHost = $best.VMHost.Name
+    Message = "Rating $($best.Rating) of $($candidates.Count) candidates"
+  } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Place VM' $_
+}
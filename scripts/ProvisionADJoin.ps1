@@ -0,0 +1,21 @@
+param($name, $domain, $oupath, $domaincontroller)
+try {
+  # djoin runs as the winrm user, which needs the right to reset the password of the computer account
+  $blobfile = Join-Path $env:TEMP "$($name)-odj-$([GUID]::NewGuid().ToString()).txt"
+  $djoinargs = @('/provision', '/domain', $domain, '/machine', $name, '/machineou', $oupath, '/savefile', $blobfile, '/reuse')
+  if ($domaincontroller) {
+    $djoinargs += @('/dcname', $domaincontroller)
+  }
+  $output = & djoin.exe @djoinargs 2>&1
+  if ($LASTEXITCODE -ne 0) {
+    throw "djoin failed ($LASTEXITCODE): $output"
+  }
+  $blob = [Convert]::ToBase64String([System.IO.File]::ReadAllBytes($blobfile))
+  return @{ Result = $blob; Message = "Domain join for $($name) provisioned" } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Provision AD Join' $_
+} finally {
+  if ($blobfile -and (Test-Path $blobfile)) {
+    Remove-Item $blobfile
+  }
+}
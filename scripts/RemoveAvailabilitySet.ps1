@@ -0,0 +1,20 @@
+param($hostgroup, $name)
+try {
+  $members = @(Get-SCVirtualMachine | Where-Object { @($_.AvailabilitySetNames) -contains $name })
+  if ($members.Count -gt 0) {
+    return @{ Message = "In use by $($members.Count) VMs" } | convertto-json -Compress
+  }
+  $hg = Get-SCVMHostGroup -Name $hostgroup | select -first 1
+  if (-not $hg) {
+    return @{ Message = "Removed" } | convertto-json -Compress
+  }
+  $clusters = @(Get-SCVMHostCluster | Where-Object { $_.HostGroup.Path -eq $hg.Path -or "$($_.HostGroup.Path)".StartsWith("$($hg.Path)\") })
+  foreach ($cluster in $clusters) {
+    if (@($cluster.AvailabilitySetNames) -contains $name) {
+      Set-SCVMHostCluster -VMHostCluster $cluster -AvailabilitySetNames @($cluster.AvailabilitySetNames | Where-Object { $_ -ne $name }) | out-null
+    }
+  }
+  return @{ Message = "Removed" } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Remove Availability Set' $_
+}
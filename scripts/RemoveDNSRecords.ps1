@@ -0,0 +1,32 @@
+param($server, $zone, $name, $addresses)
+try {
+  $cimparam = @{}
+  if ($server) {
+    $cimparam.ComputerName = $server
+  }
+  if (${env:ACTIVEDIRECTORY_USERNAME} -and ${env:ACTIVEDIRECTORY_PASSWORD}) {
+    $cimparam.Credential = new-object PSCredential(${env:ACTIVEDIRECTORY_USERNAME}, (ConvertTo-Securestring -force -AsPlainText -String ${env:ACTIVEDIRECTORY_PASSWORD}))
+  }
+  $session = New-CimSession @cimparam
+  try {
+    $fqdn = "$($name).$($zone)."
+    # Only remove the records of the addresses that were registered, the name could have been reused
+    Get-DnsServerResourceRecord -CimSession $session -ZoneName $zone -Name $name -ErrorAction SilentlyContinue |
+      Where-Object { ($_.RecordType -eq 'A' -and $addresses -contains "$($_.RecordData.IPv4Address)") -or
+                     ($_.RecordType -eq 'AAAA' -and $addresses -contains "$($_.RecordData.IPv6Address)") } |
+      Remove-DnsServerResourceRecord -CimSession $session -ZoneName $zone -Force
+    foreach ($addr in $addresses) {
+      $reverse = ReverseDNSRecord $session $addr
+      if ($reverse) {
+        Get-DnsServerResourceRecord -CimSession $session -ZoneName $reverse.Zone -Name $reverse.Name -RRType Ptr -ErrorAction SilentlyContinue |
+          Where-Object { $_.RecordData.PtrDomainName -eq $fqdn } |
+          Remove-DnsServerResourceRecord -CimSession $session -ZoneName $reverse.Zone -Force
+      }
+    }
+    return @{ Message = "Removed $($fqdn)" } | convertto-json -Compress
+  } finally {
+    Remove-CimSession $session
+  }
+} catch {
+  ErrorToJson 'Remove DNS Records' $_
+}
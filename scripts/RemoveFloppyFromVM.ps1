@@ -0,0 +1,16 @@
+param($id, $cipath)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  $vfdname = Split-Path $cipath -Leaf
+  $FloppyDrive = Get-SCVirtualFloppyDrive -VM $vm | Where-Object { $_.VirtualFloppyDisk -and (Split-Path "$($_.VirtualFloppyDisk.SharePath)" -Leaf) -eq $vfdname } | select -first 1
+  if ($FloppyDrive) {
+    Set-SCVirtualFloppyDrive -VirtualFloppyDrive $FloppyDrive -NoMedia | out-null
+  }
+
+  return VMToJson $vm "Removed VFD"
+} catch {
+  ErrorToJson 'Remove VFD from VM' $_
+}
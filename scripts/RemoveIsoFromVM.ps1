@@ -1,17 +1,16 @@
-param($id, $isopath)
+param($id, $cipath)
 try {
   $vm = Get-SCVirtualMachine -ID $id
   if (-not $vm) {
     throw "Virtual Machine $id not found"
   }
-  $DVDDrive = Get-SCVirtualDVDDrive -VM $vm | Where-Object { $_.ISO.SharePath -eq $isopath } | select -first 1
-  if (-not $DVDDrive) {
-    throw "Isofile $isopath not connected to $id $($vm.name)"
+  $isoname = Split-Path $cipath -Leaf
+  $DVDDrive = Get-SCVirtualDVDDrive -VM $vm | Where-Object { $_.ISO -and (Split-Path "$($_.ISO.SharePath)" -Leaf) -eq $isoname } | select -first 1
+  if ($DVDDrive) {
+    Set-SCVirtualDVDDrive -VirtualDVDDrive $DVDDrive -NoMedia | out-null
   }
-  $ISO = $DVDDrive.ISO
-  Remove-SCISO -ISO $ISO | out-null
 
-  return VMToJson $vm "Removing ISO"
+  return VMToJson $vm "Removed ISO"
 } catch {
   ErrorToJson 'Remove ISO from VM' $_
 }
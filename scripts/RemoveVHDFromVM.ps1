@@ -0,0 +1,22 @@
+param($id, $cipath)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  $vhdname = Split-Path $cipath -Leaf
+  $DiskDrive = Get-SCVirtualDiskDrive -VM $vm | Where-Object { $_.VirtualHardDisk -and (Split-Path "$($_.VirtualHardDisk.SharePath)" -Leaf) -eq $vhdname } | select -first 1
+  if ($DiskDrive) {
+    if ("$($DiskDrive.BusType)" -eq 'IDE' -and $vm.Status -ne 'PowerOff') {
+      return @{
+        Result = "NeedsPowerOff"
+        Message = "IDE disk $vhdname can only be removed when the VM is powered off"
+      } | convertto-json -Compress
+    }
+    Remove-SCVirtualDiskDrive -VirtualDiskDrive $DiskDrive | out-null
+  }
+
+  return VMToJson $vm "Removed VHD"
+} catch {
+  ErrorToJson 'Remove VHD from VM' $_
+}
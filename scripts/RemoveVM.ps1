@@ -8,11 +8,11 @@ try {
     return (@{ Message = "Removed" } | convertto-json)
   }
   if ($vm.Status -eq 'PowerOff') {
-    $vm = Remove-SCVirtualMachine $vm -RunAsynchronously
-    VMToJson $vm "Removing"
+    $vm = Remove-SCVirtualMachine $vm -RunAsynchronously -JobVariable 'removejob'
+    VMToJson $vm "Removing" $removejob
   } else {
-    $vm = Stop-SCVirtualmachine $vm -Force -RunAsynchronously
-    VMToJson $vm "Stopping"
+    $vm = Stop-SCVirtualmachine $vm -Force -RunAsynchronously -JobVariable 'stopjob'
+    VMToJson $vm "Stopping" $stopjob
   }
 } catch {
   ErrorToJson 'Remove VM' $_
@@ -0,0 +1,15 @@
+param($id, $checkpointid)
+try {
+  $vm = Get-SCVirtualMachine -ID $id -ErrorAction SilentlyContinue
+  if (-not $vm) {
+    return @{ Message = "Removed" } | convertto-json
+  }
+  $cp = Get-SCVMCheckpoint -VM $vm | Where-Object { "$($_.ID)" -eq $checkpointid } | select -first 1
+  if (-not $cp) {
+    return @{ Message = "Removed" } | convertto-json
+  }
+  Remove-SCVMCheckpoint -VMCheckpoint $cp -RunAsynchronously -JobVariable 'removejob' | out-null
+  return @{ Message = "Removing"; JobId = "$($removejob.ID)" } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Remove VM Checkpoint' $_
+}
@@ -0,0 +1,19 @@
+param($id, $checkpointid)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  $cp = Get-SCVMCheckpoint -VM $vm | Where-Object { "$($_.ID)" -eq $checkpointid } | select -first 1
+  if (-not $cp) {
+    throw "Checkpoint $checkpointid of $($vm.Name) not found"
+  }
+  if ($vm.Status -eq 'Running') {
+    # Power cycle: the VM is turned off for the restore, and started again afterwards
+    $vm = Stop-SCVirtualMachine -VM $vm -Force
+  }
+  Restore-SCVMCheckpoint -VMCheckpoint $cp -RunAsynchronously -JobVariable 'restorejob' | out-null
+  return VMToJson $vm "Restoring checkpoint $($cp.Name)" $restorejob
+} catch {
+  ErrorToJson 'Restore VM Checkpoint' $_
+}
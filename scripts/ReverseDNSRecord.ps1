@@ -0,0 +1,20 @@
+param($session, $address)
+# Returns the reverse lookup zone and record name for the PTR record of an address,
+# or nothing when the dns server has no reverse lookup zone for it
+$ip = [System.Net.IPAddress]::Parse($address)
+$bytes = $ip.GetAddressBytes()
+if ($ip.AddressFamily -eq 'InterNetwork') {
+  $rname = (($bytes[3..0]) -join '.') + '.in-addr.arpa'
+} else {
+  $nibbles = foreach ($b in $bytes) { '{0:x}' -f ($b -shr 4); '{0:x}' -f ($b -band 15) }
+  [array]::Reverse($nibbles)
+  $rname = ($nibbles -join '.') + '.ip6.arpa'
+}
+$rzone = Get-DnsServerZone -CimSession $session | Where-Object { $_.IsReverseLookupZone -and $rname.EndsWith(".$($_.ZoneName)") } |
+  Sort-Object { $_.ZoneName.Length } -Descending | Select-Object -First 1
+if ($rzone) {
+  return @{
+    Zone = $rzone.ZoneName
+    Name = $rname.Substring(0, $rname.Length - $rzone.ZoneName.Length - 1)
+  }
+}
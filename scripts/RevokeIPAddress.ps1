@@ -0,0 +1,11 @@
+param($id)
+try {
+  $ips = @(Get-SCIPAddress -GrantToObjectID $id)
+  if ($ips.Count -eq 0) {
+    return @{ Message = "No address granted to $id" } | convertto-json -Compress
+  }
+  $ips | %{ Revoke-SCIPAddress -AllocatedIPAddress $_ | out-null }
+  return @{ Message = "Revoked $(($ips | %{ "$($_.Address)" }) -join ', ')" } | convertto-json -Compress
+} catch {
+  ErrorToJson 'Revoke IP Address' $_
+}
@@ -0,0 +1,18 @@
+param($id, $cloud)
+try {
+  $vm = Get-SCVirtualMachine -ID $id
+  if (-not $vm) {
+    throw "Virtual Machine $id not found"
+  }
+  if ($vm.Cloud.Name -eq $cloud) {
+    return VMToJson $vm "Already in cloud $cloud"
+  }
+  $target = Get-SCCloud -Name $cloud
+  if (-not $target) {
+    throw "Cloud $cloud not found"
+  }
+  $vm = Set-SCVirtualMachine -VM $vm -Cloud $target
+  return VMToJson $vm "Moved to cloud $cloud"
+} catch {
+  ErrorToJson 'Set VM Cloud' $_
+}
@@ -4,8 +4,8 @@ try {
   if (-not $vm) {
     return @{ Message = "VM $($id) not found" } | convertto-json
   }
-  $vm = Start-SCVirtualMachine -VM $vm -RunAsynchronously
-  return VMToJson $vm "Starting"
+  $vm = Start-SCVirtualMachine -VM $vm -RunAsynchronously -JobVariable 'startjob'
+  return VMToJson $vm "Starting" $startjob
 } catch {
   ErrorToJson 'Start VM' $_
 }
@@ -1,7 +1,7 @@
 param($id)
 try {
-  $vm = Stop-SCVirtualMachine -ID $id -RunAsynchronously
-  return VMToJson $vm "Stopping"
+  $vm = Stop-SCVirtualMachine -ID $id -RunAsynchronously -JobVariable 'stopjob'
+  return VMToJson $vm "Stopping" $stopjob
 } catch {
   ErrorToJson 'Stop VM' $_
 }
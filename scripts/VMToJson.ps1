@@ -1,8 +1,12 @@
-param($vm, $message = "")
+param($vm, $message = "", $job = $null)
 
 $vmjson = @{}
 if ($vm.Cloud -ne $null) { $vmjson.Cloud = $vm.Cloud.Name }
 if ($vm.Name -ne $null) { $vmjson.Name = $vm.Name }
+if ($vm.VMHost -ne $null) {
+  $vmjson.VMHost = $vm.VMHost.Name
+  $vmjson.HostGroup = "$($vm.VMHost.VMHostGroup.Path)"
+}
 if ($vm.Status -ne $null) { $vmjson.Status = "$($vm.Status)" }
 if ($vm.Memory -ne $null) { $vmjson.Memory = $vm.Memory }
 if ($vm.CpuCount -ne $null) { $vmjson.CpuCount = $vm.CpuCount }
@@ -11,12 +15,46 @@ if ($vm.VirtualNetworkAdapters -ne $null) {
   if ($vm.VirtualNetworkAdapters.IPv4Addresses) {
     $vmjson.IPv4Addresses = @($vm.VirtualNetworkAdapters.IPv4Addresses)
   }
+  if ($vm.VirtualNetworkAdapters.IPv6Addresses) {
+    $vmjson.IPv6Addresses = @($vm.VirtualNetworkAdapters.IPv6Addresses | Where-Object { -not "$_".StartsWith('fe80:') } | %{ "$_" })
+  }
+  $vmjson.MACAddresses = @($vm.VirtualNetworkAdapters | Sort-Object -Property SlotId | %{ "$($_.MACAddress)" })
+  $vmjson.NetworkAdapters = @($vm.VirtualNetworkAdapters | Sort-Object -Property SlotId | %{
+    $nic = @{
+      SlotId = $_.SlotId
+      MACAddress = "$($_.MACAddress)"
+      MACAddressType = "$($_.MACAddressType)"
+      VMNetwork = "$($_.VMNetwork.Name)"
+      VMSubnet = "$($_.VMSubnet.Name)"
+      PortClassification = "$($_.PortClassification.Name)"
+    }
+    if ($_.VLanEnabled) { $nic.VLanID = $_.VLanID }
+    if ("$($_.IPv4AddressType)" -eq 'Static') {
+      $nic.StaticIPAddresses = @(Get-SCIPAddress -GrantToObjectID $_.ID | %{
+        $pool = $_.AllocatingAddressPool
+        @{
+          Address = "$($_.Address)/$("$($pool.Subnet)".Split('/')[1])"
+          Gateway = "$($pool.DefaultGateways | Select-Object -First 1 -ExpandProperty IPAddress)"
+          DNSServers = @($pool.DNSServers | %{ "$_" })
+          DNSSearchSuffixes = @($pool.DNSSearchSuffixes | %{ "$_" })
+        }
+      })
+    }
+    $nic
+  })
   if ($vm.VirtualNetworkAdapters.Name) {
     $vmjson.Hostname = $vm.VirtualNetworkAdapters.Name | select -first 1
   }
 }
-if ($vm.VirtualHardDisks -ne $null) {
-  $vmjson.VirtualDisks = @($vm.VirtualHardDisks | select Size, MaximumSize)
+if ($vm.VirtualDiskDrives -ne $null) {
+  $vmjson.VirtualDisks = @($vm.VirtualDiskDrives | %{ @{
+    Size = $_.VirtualHardDisk.Size
+    MaximumSize = $_.VirtualHardDisk.MaximumSize
+    SharePath = "$($_.VirtualHardDisk.SharePath)"
+    BusType = "$($_.BusType)"
+    Bus = $_.Bus
+    Lun = $_.LUN
+  } })
 }
 if ($vm.VirtualDVDDrives -ne $null) {
   $vmjson.ISOs = @($vm.VirtualDVDDrives.ISO | select Size, SharePath)
@@ -30,4 +68,5 @@ if ($vm.ModifiedTime -ne $null) { $vmjson.ModifiedTime = $vm.ModifiedTime.ToStri
 if ($vm.Tag -ne $null) { $vmjson.Tag = "$($vm.Tag)" }
 if ($vm.CustomProperty -ne $null) { $vmjson.CustomProperty = $vm.CustomProperty }
 if ($message) { $vmjson.Message = $message }
-$vmjson | convertto-json -Depth 2 -Compress
+if ($job) { $vmjson.JobId = "$($job.ID)" }
+$vmjson | convertto-json -Depth 6 -Compress